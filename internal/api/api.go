@@ -24,6 +24,7 @@ import (
 	"github.com/bluenviron/mediamtx/internal/protocols/httpp"
 	"github.com/bluenviron/mediamtx/internal/recordstore"
 	"github.com/bluenviron/mediamtx/internal/restrictnetwork"
+	"github.com/bluenviron/mediamtx/internal/servers/beacon_stream"
 	"github.com/bluenviron/mediamtx/internal/servers/hls"
 	"github.com/bluenviron/mediamtx/internal/servers/rtmp"
 	"github.com/bluenviron/mediamtx/internal/servers/rtsp"
@@ -119,6 +120,13 @@ type WebRTCServer interface {
 	APISessionsKick(uuid.UUID) error
 }
 
+// BeaconServer contains methods used by the API and Metrics server.
+type BeaconServer interface {
+	APISessionsList() (*defs.APIBeaconSessionList, error)
+	APISessionsGet(uuid.UUID) (*defs.APIBeaconSession, error)
+	APISessionsKick(uuid.UUID) error
+}
+
 type apiAuthManager interface {
 	Authenticate(req *auth.Request) error
 }
@@ -147,6 +155,7 @@ type API struct {
 	HLSServer      HLSServer
 	WebRTCServer   WebRTCServer
 	SRTServer      SRTServer
+	BeaconServer   BeaconServer
 	Parent         apiParent
 
 	httpServer *httpp.Server
@@ -224,6 +233,12 @@ func (a *API) Initialize() error {
 		group.POST("/srtconns/kick/:id", a.onSRTConnsKick)
 	}
 
+	if !interfaceIsEmpty(a.BeaconServer) {
+		group.GET("/beaconsessions/list", a.onBeaconSessionsList)
+		group.GET("/beaconsessions/get/:id", a.onBeaconSessionsGet)
+		group.POST("/beaconsessions/kick/:id", a.onBeaconSessionsKick)
+	}
+
 	group.GET("/recordings/list", a.onRecordingsList)
 	group.GET("/recordings/get/*name", a.onRecordingsGet)
 	group.DELETE("/recordings/deletesegment", a.onRecordingDeleteSegment)
@@ -1076,6 +1091,64 @@ func (a *API) onSRTConnsKick(ctx *gin.Context) {
 	ctx.Status(http.StatusOK)
 }
 
+func (a *API) onBeaconSessionsList(ctx *gin.Context) {
+	data, err := a.BeaconServer.APISessionsList()
+	if err != nil {
+		a.writeError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	data.ItemCount = len(data.Items)
+	pageCount, err := paginate(&data.Items, ctx.Query("itemsPerPage"), ctx.Query("page"))
+	if err != nil {
+		a.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	data.PageCount = pageCount
+
+	ctx.JSON(http.StatusOK, data)
+}
+
+func (a *API) onBeaconSessionsGet(ctx *gin.Context) {
+	uuid, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		a.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	data, err := a.BeaconServer.APISessionsGet(uuid)
+	if err != nil {
+		if errors.Is(err, beacon_stream.ErrSessionNotFound) {
+			a.writeError(ctx, http.StatusNotFound, err)
+		} else {
+			a.writeError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
+
+func (a *API) onBeaconSessionsKick(ctx *gin.Context) {
+	uuid, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		a.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	err = a.BeaconServer.APISessionsKick(uuid)
+	if err != nil {
+		if errors.Is(err, beacon_stream.ErrSessionNotFound) {
+			a.writeError(ctx, http.StatusNotFound, err)
+		} else {
+			a.writeError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
 func (a *API) onRecordingsList(ctx *gin.Context) {
 	a.mutex.RLock()
 	c := a.Conf
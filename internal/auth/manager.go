@@ -61,7 +61,7 @@ type Request struct {
 	IP     net.IP
 	Action conf.AuthAction
 
-	// only for ActionPublish, ActionRead, ActionPlayback
+	// only for ActionPublish, ActionRead, ActionPlayback, ActionTelemetry, ActionCommand
 	Path     string
 	Protocol Protocol
 	ID       *uuid.UUID
@@ -91,7 +91,9 @@ func matchesPermission(perms []conf.AuthInternalUserPermission, req *Request) bo
 		if perm.Action == req.Action {
 			if perm.Action == conf.AuthActionPublish ||
 				perm.Action == conf.AuthActionRead ||
-				perm.Action == conf.AuthActionPlayback {
+				perm.Action == conf.AuthActionPlayback ||
+				perm.Action == conf.AuthActionTelemetry ||
+				perm.Action == conf.AuthActionCommand {
 				switch {
 				case perm.Path == "":
 					return true
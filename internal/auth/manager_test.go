@@ -142,6 +142,47 @@ func TestAuthInternal(t *testing.T) {
 	}
 }
 
+func TestAuthInternalTelemetry(t *testing.T) {
+	m := Manager{
+		Method: conf.AuthMethodInternal,
+		InternalUsers: []conf.AuthInternalUser{
+			{
+				User: "testuser",
+				Pass: "testpass",
+				Permissions: []conf.AuthInternalUserPermission{
+					{
+						Action: conf.AuthActionRead,
+					},
+					{
+						Action: conf.AuthActionTelemetry,
+						Path:   "~^cam",
+					},
+				},
+			},
+		},
+		HTTPAddress:     "",
+		RTSPAuthMethods: nil,
+	}
+
+	err := m.Authenticate(&Request{
+		User:   "testuser",
+		Pass:   "testpass",
+		IP:     net.ParseIP("127.1.1.1"),
+		Action: conf.AuthActionTelemetry,
+		Path:   "cam1",
+	})
+	require.NoError(t, err)
+
+	err = m.Authenticate(&Request{
+		User:   "testuser",
+		Pass:   "testpass",
+		IP:     net.ParseIP("127.1.1.1"),
+		Action: conf.AuthActionTelemetry,
+		Path:   "other",
+	})
+	require.Error(t, err)
+}
+
 func TestAuthInternalRTSPDigest(t *testing.T) {
 	m := Manager{
 		Method: conf.AuthMethodInternal,
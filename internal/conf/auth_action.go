@@ -10,12 +10,14 @@ type AuthAction string
 
 // auth actions
 const (
-	AuthActionPublish  AuthAction = "publish"
-	AuthActionRead     AuthAction = "read"
-	AuthActionPlayback AuthAction = "playback"
-	AuthActionAPI      AuthAction = "api"
-	AuthActionMetrics  AuthAction = "metrics"
-	AuthActionPprof    AuthAction = "pprof"
+	AuthActionPublish   AuthAction = "publish"
+	AuthActionRead      AuthAction = "read"
+	AuthActionPlayback  AuthAction = "playback"
+	AuthActionTelemetry AuthAction = "telemetry"
+	AuthActionCommand   AuthAction = "command"
+	AuthActionAPI       AuthAction = "api"
+	AuthActionMetrics   AuthAction = "metrics"
+	AuthActionPprof     AuthAction = "pprof"
 )
 
 // MarshalJSON implements json.Marshaler.
@@ -34,6 +36,8 @@ func (d *AuthAction) UnmarshalJSON(b []byte) error {
 	case string(AuthActionPublish),
 		string(AuthActionRead),
 		string(AuthActionPlayback),
+		string(AuthActionTelemetry),
+		string(AuthActionCommand),
 		string(AuthActionAPI),
 		string(AuthActionMetrics),
 		string(AuthActionPprof):
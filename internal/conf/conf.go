@@ -134,6 +134,9 @@ var defaultAuthInternalUsers = AuthInternalUsers{
 			{
 				Action: AuthActionPlayback,
 			},
+			{
+				Action: AuthActionTelemetry,
+			},
 		},
 	},
 	{
@@ -154,13 +157,6 @@ var defaultAuthInternalUsers = AuthInternalUsers{
 	},
 }
 
-type GPSConfig struct {
-	Protocol   string `json:"protocol"`  // ws, tcp, or udp
-	IPAddress  string `json:"ipAddress"` // IP address to connect to
-	Port       int    `json:"port"`      // Port number of the server
-	RawDataLog bool   `json:"rawDataLog"`
-}
-
 // Conf is a configuration.
 // WARNING: Avoid using slices directly due to https://github.com/golang/go/issues/21092
 type Conf struct {
@@ -292,6 +288,15 @@ type Conf struct {
 	SRT        bool   `json:"srt"`
 	SRTAddress string `json:"srtAddress"`
 
+	// GPS server
+	GPSAddress        string     `json:"gpsAddress"`
+	GPSEncryption     bool       `json:"gpsEncryption"`
+	GPSServerKey      string     `json:"gpsServerKey"`
+	GPSServerCert     string     `json:"gpsServerCert"`
+	GPSAllowOrigin    string     `json:"gpsAllowOrigin"`
+	GPSTrustedProxies IPNetworks `json:"gpsTrustedProxies"`
+	GPSTCPAddress     string     `json:"gpsTCPAddress"`
+
 	// Record (deprecated)
 	Record                *bool           `json:"record,omitempty"`                // deprecated
 	RecordPath            *string         `json:"recordPath,omitempty"`            // deprecated
@@ -307,8 +312,8 @@ type Conf struct {
 	OptionalPaths map[string]*OptionalPath `json:"paths"`
 	Paths         map[string]*Path         `json:"-"` // filled by Check()
 
-	// For gps stream config
-	GpsConfig *GPSConfig `json:"gpsConfig"`
+	// GPS (deprecated)
+	GpsConfig *GPSConfig `json:"gpsConfig,omitempty"` // deprecated
 }
 
 func (conf *Conf) setDefaults() {
@@ -416,12 +421,14 @@ func (conf *Conf) setDefaults() {
 	conf.SRT = true
 	conf.SRTAddress = ":8890"
 
-	conf.PathDefaults.setDefaults()
+	// GPS server
+	conf.GPSAddress = ":8080"
+	conf.GPSServerKey = "server.key"
+	conf.GPSServerCert = "server.crt"
+	conf.GPSAllowOrigin = "*"
+	conf.GPSTCPAddress = ":13371"
 
-	// * Initialize GpsConfig
-	if conf.GpsConfig == nil {
-		conf.GpsConfig = &GPSConfig{}
-	}
+	conf.PathDefaults.setDefaults()
 }
 
 // Load loads a Conf.
@@ -556,6 +563,9 @@ func (conf *Conf) Validate() error {
 					{
 						Action: AuthActionPlayback,
 					},
+					{
+						Action: AuthActionTelemetry,
+					},
 				},
 			},
 			{
@@ -700,6 +710,25 @@ func (conf *Conf) Validate() error {
 		conf.PathDefaults.RecordDeleteAfter = *conf.RecordDeleteAfter
 	}
 
+	// GPS (deprecated)
+	// the global gpsConfig describes a single upstream, that is shared by all
+	// non-regexp paths that don't have a gpsConfig. Only fields that existed when
+	// gpsConfig was global are kept, in order to use the defaults of the others.
+	if conf.GpsConfig != nil {
+		gpsConfig := conf.PathDefaults.GPSConfig
+		gpsConfig.Protocol = conf.GpsConfig.Protocol
+		gpsConfig.IPAddress = conf.GpsConfig.IPAddress
+		gpsConfig.Port = conf.GpsConfig.Port
+		gpsConfig.RawDataLog = conf.GpsConfig.RawDataLog
+
+		err := gpsConfig.validate()
+		if err != nil {
+			return fmt.Errorf("invalid 'gpsConfig': %w", err)
+		}
+
+		*conf.GpsConfig = gpsConfig
+	}
+
 	hasAllOthers := false
 	for name := range conf.OptionalPaths {
 		if name == "all" || name == "all_others" || name == "~^.*$" {
@@ -730,6 +759,72 @@ func (conf *Conf) Validate() error {
 		}
 	}
 
+	err := checkPathSourceCycles(conf.Paths)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// checkPathSourceCycles checks that paths don't read from each other through 'path://' sources.
+// Sources that contain regular expression groups are checked after their resolution.
+func checkPathSourceCycles(paths map[string]*Path) error {
+	targets := func(pconf *Path) []string {
+		var ret []string
+		for _, source := range append(PathSources{pconf.Source}, pconf.Sources...) {
+			name, ok := strings.CutPrefix(source, "path://")
+			if !ok || strings.Contains(name, "$") {
+				continue
+			}
+			if target, ok := paths[name]; ok && target.Regexp == nil {
+				ret = append(ret, name)
+			}
+		}
+		return ret
+	}
+
+	// 1 = being visited, 2 = visited
+	state := make(map[string]int)
+
+	var visit func(name string, chain []string) error
+	visit = func(name string, chain []string) error {
+		chain = append(chain, name)
+
+		switch state[name] {
+		case 1:
+			return fmt.Errorf("paths can't read from each other: %s", strings.Join(chain, " -> "))
+		case 2:
+			return nil
+		}
+
+		state[name] = 1
+		for _, target := range targets(paths[name]) {
+			err := visit(target, chain)
+			if err != nil {
+				return err
+			}
+		}
+		state[name] = 2
+
+		return nil
+	}
+
+	names := make([]string, 0, len(paths))
+	for name, pconf := range paths {
+		if pconf.Regexp == nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		err := visit(name, nil)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
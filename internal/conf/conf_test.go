@@ -50,8 +50,12 @@ func TestConfFromFile(t *testing.T) {
 		require.Equal(t, &Path{
 			Name:                       "cam1",
 			Source:                     "publisher",
+			Sources:                    PathSources{},
+			IncludeTracks:              TrackSelectors{},
+			ExcludeTracks:              TrackSelectors{},
 			SourceOnDemandStartTimeout: 10 * StringDuration(time.Second),
 			SourceOnDemandCloseAfter:   10 * StringDuration(time.Second),
+			TimeShiftMaxSize:           50 * 1024 * 1024,
 			RecordPath:                 "./recordings/%path/%Y-%m-%d_%H-%M-%S-%f",
 			RecordFormat:               RecordFormatFMP4,
 			RecordPartDuration:         StringDuration(1 * time.Second),
@@ -78,8 +82,16 @@ func TestConfFromFile(t *testing.T) {
 			RPICameraBitrate:           5000000,
 			RPICameraProfile:           "main",
 			RPICameraLevel:             "4.1",
-			RunOnDemandStartTimeout:    5 * StringDuration(time.Second),
-			RunOnDemandCloseAfter:      10 * StringDuration(time.Second),
+			GPSConfig: GPSConfig{
+				Format:          "json",
+				ReplaySpeed:     1,
+				MaxCommandRate:  10,
+				WriteQueueSize:  64,
+				HistorySize:     10,
+				HistoryDuration: 10 * StringDuration(time.Second),
+			},
+			RunOnDemandStartTimeout: 5 * StringDuration(time.Second),
+			RunOnDemandCloseAfter:   10 * StringDuration(time.Second),
 		}, pa)
 	}()
 
@@ -212,6 +224,9 @@ func TestConfDeprecatedAuth(t *testing.T) {
 				{
 					Action: AuthActionPlayback,
 				},
+				{
+					Action: AuthActionTelemetry,
+				},
 			},
 		},
 		{
@@ -253,6 +268,62 @@ func TestConfDeprecatedAuth(t *testing.T) {
 	}, conf.AuthInternalUsers)
 }
 
+func TestConfDeprecatedGPS(t *testing.T) {
+	tmpf, err := createTempFile([]byte(
+		"gpsConfig:\n" +
+			"  protocol: udp\n" +
+			"  ipAddress: 127.0.0.1\n" +
+			"  port: 13370\n" +
+			"  rawDataLog: true\n" +
+			"paths:\n" +
+			"  cam:\n" +
+			"  cam2:\n" +
+			"  all_others:\n"))
+	require.NoError(t, err)
+	defer os.Remove(tmpf)
+
+	conf, _, err := Load(tmpf, nil)
+	require.NoError(t, err)
+
+	require.Equal(t, &GPSConfig{
+		Protocol:        "udp",
+		IPAddress:       "127.0.0.1",
+		Port:            13370,
+		RawDataLog:      true,
+		Format:          "json",
+		ReplaySpeed:     1,
+		MaxCommandRate:  10,
+		WriteQueueSize:  64,
+		HistorySize:     10,
+		HistoryDuration: 10 * StringDuration(time.Second),
+	}, conf.GpsConfig)
+
+	// the upstream is shared, therefore it is not copied into paths.
+	require.Equal(t, false, conf.Paths["cam"].GPSConfig.IsEnabled())
+	require.Equal(t, false, conf.Paths["all_others"].GPSConfig.IsEnabled())
+}
+
+func TestConfGPSGeofencesFromEnv(t *testing.T) {
+	t.Setenv("MTX_PATHS_CAM1_GPSCONFIG_PROTOCOL", "publisher")
+	t.Setenv("MTX_PATHS_CAM1_GPSCONFIG_WRITEQUEUESIZE", "64")
+	t.Setenv("MTX_PATHS_CAM1_GPSCONFIG_GEOFENCES_0_NAME", "area")
+	t.Setenv("MTX_PATHS_CAM1_GPSCONFIG_GEOFENCES_0_POLYGON", "45,9;45,10;46,10")
+	t.Setenv("MTX_PATHS_CAM1_GPSCONFIG_CAMERAPOSE_HEADING", "90")
+
+	conf, _, err := Load("", nil)
+	require.NoError(t, err)
+
+	pa, ok := conf.Paths["cam1"]
+	require.Equal(t, true, ok)
+
+	require.Equal(t, GPSGeofences{{
+		Name:    "area",
+		Polygon: GPSGeofencePolygon{{45, 9}, {45, 10}, {46, 10}},
+	}}, pa.GPSConfig.Geofences)
+
+	require.Equal(t, &GPSCameraPose{Heading: 90}, pa.GPSConfig.CameraPose)
+}
+
 func TestConfErrors(t *testing.T) {
 	for _, ca := range []struct {
 		name string
@@ -374,6 +445,155 @@ func TestConfErrors(t *testing.T) {
 				"authJWTClaimKey: \"\"",
 			"'authJWTClaimKey' is empty",
 		},
+		{
+			"invalid gps protocol",
+			"paths:\n" +
+				"  my_path:\n" +
+				"    gpsConfig:\n" +
+				"      protocol: http\n",
+			"invalid 'gpsConfig': invalid protocol 'http': must be one of 'ws', 'tcp', 'udp', 'publisher', or 'file'",
+		},
+		{
+			"invalid gps format",
+			"paths:\n" +
+				"  my_path:\n" +
+				"    gpsConfig:\n" +
+				"      protocol: udp\n" +
+				"      ipAddress: 127.0.0.1\n" +
+				"      port: 13370\n" +
+				"      format: xml\n",
+			"invalid 'gpsConfig': invalid format 'xml': must be one of 'json', 'nmea', or 'mavlink'",
+		},
+		{
+			"invalid gps geofence",
+			"paths:\n" +
+				"  my_path:\n" +
+				"    gpsConfig:\n" +
+				"      protocol: publisher\n" +
+				"      writeQueueSize: 64\n" +
+				"      geofences:\n" +
+				"        - name: area\n" +
+				"          polygon: [[45, 9], [45, 10]]\n",
+			"invalid 'gpsConfig': invalid geofence 0: polygon must have at least 3 vertices",
+		},
+		{
+			"duplicate gps geofence",
+			"paths:\n" +
+				"  my_path:\n" +
+				"    gpsConfig:\n" +
+				"      protocol: publisher\n" +
+				"      writeQueueSize: 64\n" +
+				"      geofences:\n" +
+				"        - name: area\n" +
+				"          polygon: [[45, 9], [45, 10], [46, 10]]\n" +
+				"        - name: area\n" +
+				"          frame: camera\n" +
+				"          polygon: [[0, 0], [1, 0], [1, 1]]\n",
+			"invalid 'gpsConfig': duplicate geofence 'area'",
+		},
+		{
+			"gps file without path",
+			"paths:\n" +
+				"  my_path:\n" +
+				"    gpsConfig:\n" +
+				"      protocol: file\n",
+			"invalid 'gpsConfig': 'file' is empty",
+		},
+		{
+			"invalid gps replay speed",
+			"paths:\n" +
+				"  my_path:\n" +
+				"    gpsConfig:\n" +
+				"      protocol: file\n" +
+				"      file: telemetry.ndjson\n" +
+				"      replaySpeed: 0\n",
+			"invalid 'gpsConfig': 'replaySpeed' must be greater than zero",
+		},
+		{
+			"health close source without checks",
+			"paths:\n" +
+				"  my_path:\n" +
+				"    healthCloseSource: yes\n",
+			"'healthCloseSource' requires at least one health check",
+		},
+		{
+			"source and sources",
+			"paths:\n" +
+				"  my_path:\n" +
+				"    source: rtsp://localhost:8554/a\n" +
+				"    sources: [rtsp://localhost:8554/b]\n",
+			"'source' and 'sources' cannot be used together",
+		},
+		{
+			"publisher in sources",
+			"paths:\n" +
+				"  my_path:\n" +
+				"    sources: [rtsp://localhost:8554/a, publisher]\n",
+			"'publisher' cannot be used in 'sources'",
+		},
+		{
+			"invalid track position",
+			"paths:\n" +
+				"  my_path:\n" +
+				"    includeTracks: [video, 0]\n",
+			"invalid 'includeTracks': invalid track position: 0",
+		},
+		{
+			"empty track selector",
+			"paths:\n" +
+				"  my_path:\n" +
+				"    excludeTracks: ['']\n",
+			"invalid 'excludeTracks': empty selector",
+		},
+		{
+			"negative time shift duration",
+			"paths:\n" +
+				"  my_path:\n" +
+				"    timeShiftDuration: -1s\n",
+			"invalid 'timeShiftDuration': -1s",
+		},
+		{
+			"slate with static source",
+			"paths:\n" +
+				"  my_path:\n" +
+				"    source: rtsp://localhost:8554/a\n" +
+				"    slate: black\n",
+			"'slate' can only be used when source is 'publisher'",
+		},
+		{
+			"slate with run on demand",
+			"paths:\n" +
+				"  my_path:\n" +
+				"    runOnDemand: ffmpeg\n" +
+				"    slate: black\n",
+			"'slate' and 'runOnDemand' cannot be used together",
+		},
+		{
+			"path source with invalid name",
+			"paths:\n" +
+				"  my_path:\n" +
+				"    source: path://\n",
+			"'path://': cannot be empty",
+		},
+		{
+			"path source reading from itself",
+			"paths:\n" +
+				"  my_path:\n" +
+				"    source: path://my_path\n",
+			"a path can't read from itself",
+		},
+		{
+			"path sources reading from each other",
+			"paths:\n" +
+				"  path_a:\n" +
+				"    source: path://path_b\n" +
+				"    sourceOnDemand: yes\n" +
+				"  path_b:\n" +
+				"    sources:\n" +
+				"      - rtsp://127.0.0.1:8555/stream\n" +
+				"      - path://path_a\n",
+			"paths can't read from each other: path_a -> path_b -> path_a",
+		},
 	} {
 		t.Run(ca.name, func(t *testing.T) {
 			tmpf, err := createTempFile([]byte(ca.conf))
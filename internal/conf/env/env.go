@@ -155,6 +155,13 @@ func loadEnvInternal(env map[string]string, prefix string, prv reflect.Value) er
 		return nil
 
 	case reflect.Struct:
+		if prv.IsNil() {
+			if !envHasAtLeastAKeyWithPrefix(env, prefix) {
+				return nil
+			}
+			prv.Set(reflect.New(rt))
+		}
+
 		flen := rt.NumField()
 		for i := 0; i < flen; i++ {
 			f := rt.Field(i)
@@ -94,6 +94,8 @@ type testStruct struct {
 	MySliceSubStructEmpty    []mySubStruct        `json:"mySliceSubStructEmpty"`
 	MySliceSubStructOpt      *[]mySubStruct       `json:"mySliceSubStructOpt"`
 	MySliceSubStructOptUnset *[]mySubStruct       `json:"mySliceSubStructOptUnset"`
+	MySubStructOpt           *subStruct           `json:"mySubStructOpt"`
+	MySubStructOptUnset      *subStruct           `json:"mySubStructOptUnset"`
 	Unset                    *bool                `json:"unset"`
 }
 
@@ -125,6 +127,7 @@ func TestLoad(t *testing.T) {
 		"MYPREFIX_MYSLICESUBSTRUCT_1_PASSWORD":    "pass2",
 		"MYPREFIX_MYSLICESUBSTRUCTEMPTY":          "",
 		"MYPREFIX_MYSLICESUBSTRUCTOPT_1_PASSWORD": "pwd",
+		"MYPREFIX_MYSUBSTRUCTOPT_MYPARAM":         "789",
 	}
 
 	for key, val := range env {
@@ -182,6 +185,9 @@ func TestLoad(t *testing.T) {
 			},
 		},
 		MySliceSubStructEmpty: []mySubStruct{},
+		MySubStructOpt: &subStruct{
+			MyParam: 789,
+		},
 	}, s)
 }
 
@@ -0,0 +1,140 @@
+package conf
+
+import (
+	"fmt"
+)
+
+// GPSConfig is the configuration of the telemetry source of a path.
+type GPSConfig struct {
+	Protocol   string `json:"protocol"`  // ws, tcp, udp, publisher, or file
+	IPAddress  string `json:"ipAddress"` // IP address to connect to
+	Port       int    `json:"port"`      // Port number of the server
+	Format     string `json:"format"`    // json, nmea, or mavlink
+	RawDataLog bool   `json:"rawDataLog"`
+
+	// log that is replayed by the file protocol.
+	// Packets are paced by their timestamps, divided by ReplaySpeed.
+	File        string  `json:"file"`
+	ReplaySpeed float64 `json:"replaySpeed"`
+	ReplayLoop  bool    `json:"replayLoop"`
+
+	// maximum number of commands per second that each session
+	// can send to the source. 0 disables commands.
+	MaxCommandRate int `json:"maxCommandRate"`
+
+	// number of packets that can be queued for each data channel.
+	// When the queue is full, the oldest packets are discarded.
+	WriteQueueSize int `json:"writeQueueSize"`
+
+	// packets that are sent to clients when they connect.
+	// Packets are kept until one of the two limits is reached.
+	HistorySize     int            `json:"historySize"`
+	HistoryDuration StringDuration `json:"historyDuration"`
+
+	// options of the data channels created by the GPS server.
+	Unordered      bool `json:"unordered"`
+	Unreliable     bool `json:"unreliable"`
+	MaxRetransmits int  `json:"maxRetransmits"` // only with unreliable
+
+	// embed packets into the H264 and H265 tracks of the path,
+	// as user data unregistered SEI NAL units.
+	EmbedInStream bool `json:"embedInStream"`
+
+	// areas in which the position of tracked objects is checked.
+	// Objects are the source itself (position packets) and markers (marker packets).
+	Geofences GPSGeofences `json:"geofences"`
+
+	// pose of the camera, used to compute the position of markers.
+	CameraPose *GPSCameraPose `json:"cameraPose"`
+}
+
+// IsEnabled checks whether a telemetry source is configured.
+func (c GPSConfig) IsEnabled() bool {
+	return c.Protocol != ""
+}
+
+func (c GPSConfig) validate() error {
+	if !c.IsEnabled() {
+		return nil
+	}
+
+	switch c.Protocol {
+	case "ws", "tcp", "udp", "publisher", "file":
+	default:
+		return fmt.Errorf("invalid protocol '%s': must be one of 'ws', 'tcp', 'udp', 'publisher', or 'file'", c.Protocol)
+	}
+
+	switch c.Format {
+	case "", "json", "nmea", "mavlink":
+	default:
+		return fmt.Errorf("invalid format '%s': must be one of 'json', 'nmea', or 'mavlink'", c.Format)
+	}
+
+	switch c.Protocol {
+	case "file":
+		if c.File == "" {
+			return fmt.Errorf("'file' is empty")
+		}
+
+		// logs are read line by line
+		if c.Format == "mavlink" {
+			return fmt.Errorf("format 'mavlink' is not supported by protocol 'file'")
+		}
+
+		if c.ReplaySpeed <= 0 {
+			return fmt.Errorf("'replaySpeed' must be greater than zero")
+		}
+
+	// publishers connect to the server
+	case "publisher":
+
+	default:
+		if c.IPAddress == "" {
+			return fmt.Errorf("'ipAddress' is empty")
+		}
+
+		if c.Port <= 0 || c.Port > 65535 {
+			return fmt.Errorf("invalid port %d", c.Port)
+		}
+	}
+
+	if c.MaxCommandRate < 0 {
+		return fmt.Errorf("invalid 'maxCommandRate': %d", c.MaxCommandRate)
+	}
+
+	if c.WriteQueueSize <= 0 {
+		return fmt.Errorf("'writeQueueSize' must be greater than zero")
+	}
+
+	if c.HistorySize < 0 {
+		return fmt.Errorf("invalid 'historySize': %d", c.HistorySize)
+	}
+
+	if c.HistoryDuration < 0 {
+		return fmt.Errorf("invalid 'historyDuration': %v", c.HistoryDuration)
+	}
+
+	if c.MaxRetransmits < 0 || c.MaxRetransmits > 65535 {
+		return fmt.Errorf("invalid 'maxRetransmits': %d", c.MaxRetransmits)
+	}
+
+	names := make(map[string]struct{})
+	for i, g := range c.Geofences {
+		if err := g.validate(); err != nil {
+			return fmt.Errorf("invalid geofence %d: %w", i, err)
+		}
+
+		if _, ok := names[g.Name]; ok {
+			return fmt.Errorf("duplicate geofence '%s'", g.Name)
+		}
+		names[g.Name] = struct{}{}
+	}
+
+	if c.CameraPose != nil {
+		if err := c.CameraPose.validate(); err != nil {
+			return fmt.Errorf("invalid 'cameraPose': %w", err)
+		}
+	}
+
+	return nil
+}
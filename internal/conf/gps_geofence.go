@@ -0,0 +1,143 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/bluenviron/mediamtx/internal/conf/env"
+)
+
+// GPSGeofencePolygon is a list of vertices.
+// In environment variables, vertices are separated by semicolons
+// and coordinates are separated by commas.
+type GPSGeofencePolygon [][2]float64
+
+// UnmarshalEnv implements env.Unmarshaler.
+func (p *GPSGeofencePolygon) UnmarshalEnv(_ string, v string) error {
+	*p = nil
+
+	if v == "" {
+		return nil
+	}
+
+	for _, vertex := range strings.Split(v, ";") {
+		coords := strings.Split(vertex, ",")
+		if len(coords) != 2 {
+			return fmt.Errorf("invalid vertex '%s'", vertex)
+		}
+
+		var out [2]float64
+		for i, coord := range coords {
+			tmp, err := strconv.ParseFloat(strings.TrimSpace(coord), 64)
+			if err != nil {
+				return err
+			}
+			out[i] = tmp
+		}
+
+		*p = append(*p, out)
+	}
+
+	return nil
+}
+
+// GPSGeofence is an area in which the position of tracked objects is checked.
+type GPSGeofence struct {
+	Name string `json:"name"`
+
+	// frame of the vertices. It can be:
+	// * wgs84: latitude and longitude, in degrees.
+	// * camera: meters to the right of the camera and in front of it, on the horizontal plane.
+	Frame   string             `json:"frame"`
+	Polygon GPSGeofencePolygon `json:"polygon"`
+}
+
+func (g GPSGeofence) validate() error {
+	if g.Name == "" {
+		return fmt.Errorf("name is empty")
+	}
+
+	switch g.Frame {
+	case "", "wgs84", "camera":
+	default:
+		return fmt.Errorf("invalid frame '%s': must be one of 'wgs84' or 'camera'", g.Frame)
+	}
+
+	if len(g.Polygon) < 3 {
+		return fmt.Errorf("polygon must have at least 3 vertices")
+	}
+
+	for _, vertex := range g.Polygon {
+		if math.IsNaN(vertex[0]) || math.IsInf(vertex[0], 0) ||
+			math.IsNaN(vertex[1]) || math.IsInf(vertex[1], 0) {
+			return fmt.Errorf("invalid vertex %v", vertex)
+		}
+
+		if g.Frame != "camera" && (vertex[0] < -90 || vertex[0] > 90 || vertex[1] < -180 || vertex[1] > 180) {
+			return fmt.Errorf("invalid vertex %v", vertex)
+		}
+	}
+
+	return nil
+}
+
+// GPSGeofences is a list of GPSGeofence.
+type GPSGeofences []GPSGeofence
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *GPSGeofences) UnmarshalJSON(b []byte) error {
+	// remove default value before loading new value
+	// https://github.com/golang/go/issues/21092
+	*s = nil
+
+	err := json.Unmarshal(b, (*[]GPSGeofence)(s))
+	if err != nil {
+		return err
+	}
+
+	// an empty list is equivalent to the default value
+	if len(*s) == 0 {
+		*s = nil
+	}
+
+	return nil
+}
+
+// UnmarshalEnv implements env.Unmarshaler.
+func (s *GPSGeofences) UnmarshalEnv(prefix string, _ string) error {
+	// remove existing value before loading new value
+	*s = nil
+	return env.Load(prefix, (*[]GPSGeofence)(s))
+}
+
+// GPSCameraPose is the pose of the camera that detects markers.
+// It is used to compute the position of markers.
+type GPSCameraPose struct {
+	Latitude  float64 `json:"latitude"`  // in degrees
+	Longitude float64 `json:"longitude"` // in degrees
+	Heading   float64 `json:"heading"`   // in degrees, clockwise from true north
+	Pitch     float64 `json:"pitch"`     // in degrees, positive upwards
+}
+
+func (p GPSCameraPose) validate() error {
+	if p.Latitude < -90 || p.Latitude > 90 {
+		return fmt.Errorf("latitude out of range: %v", p.Latitude)
+	}
+
+	if p.Longitude < -180 || p.Longitude > 180 {
+		return fmt.Errorf("longitude out of range: %v", p.Longitude)
+	}
+
+	if p.Heading < 0 || p.Heading >= 360 {
+		return fmt.Errorf("heading out of range: %v", p.Heading)
+	}
+
+	if p.Pitch < -90 || p.Pitch > 90 {
+		return fmt.Errorf("pitch out of range: %v", p.Pitch)
+	}
+
+	return nil
+}
@@ -45,6 +45,94 @@ func srtCheckPassphrase(passphrase string) error {
 	}
 }
 
+func checkSource(source string) error {
+	switch {
+	case source == "publisher":
+
+	case strings.HasPrefix(source, "rtsp://") ||
+		strings.HasPrefix(source, "rtsps://"):
+		_, err := base.ParseURL(source)
+		if err != nil {
+			return fmt.Errorf("'%s' is not a valid URL", source)
+		}
+
+	case strings.HasPrefix(source, "rtmp://") ||
+		strings.HasPrefix(source, "rtmps://"):
+		u, err := gourl.Parse(source)
+		if err != nil {
+			return fmt.Errorf("'%s' is not a valid URL", source)
+		}
+
+		if u.User != nil {
+			pass, _ := u.User.Password()
+			user := u.User.Username()
+			if user != "" && pass == "" ||
+				user == "" && pass != "" {
+				return fmt.Errorf("username and password must be both provided")
+			}
+		}
+
+	case strings.HasPrefix(source, "http://") ||
+		strings.HasPrefix(source, "https://"):
+		u, err := gourl.Parse(source)
+		if err != nil {
+			return fmt.Errorf("'%s' is not a valid URL", source)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("'%s' is not a valid URL", source)
+		}
+
+		if u.User != nil {
+			pass, _ := u.User.Password()
+			user := u.User.Username()
+			if user != "" && pass == "" ||
+				user == "" && pass != "" {
+				return fmt.Errorf("username and password must be both provided")
+			}
+		}
+
+	case strings.HasPrefix(source, "udp://"):
+		_, _, err := net.SplitHostPort(source[len("udp://"):])
+		if err != nil {
+			return fmt.Errorf("'%s' is not a valid UDP URL", source)
+		}
+
+	case strings.HasPrefix(source, "srt://"):
+
+		_, err := gourl.Parse(source)
+		if err != nil {
+			return fmt.Errorf("'%s' is not a valid URL", source)
+		}
+
+	case strings.HasPrefix(source, "whep://") ||
+		strings.HasPrefix(source, "wheps://"):
+		_, err := gourl.Parse(source)
+		if err != nil {
+			return fmt.Errorf("'%s' is not a valid URL", source)
+		}
+
+	case source == "redirect":
+
+	case strings.HasPrefix(source, "path://"):
+		name := source[len("path://"):]
+
+		// names that contain regular expression groups or query parameters are checked after their resolution
+		if !strings.Contains(name, "$") {
+			err := isValidPathName(name)
+			if err != nil {
+				return fmt.Errorf("'%s': %w", source, err)
+			}
+		}
+
+	case source == "rpiCamera":
+
+	default:
+		return fmt.Errorf("invalid source: '%s'", source)
+	}
+
+	return nil
+}
+
 // FindPathConf returns the configuration corresponding to the given path name.
 func FindPathConf(pathConfs map[string]*Path, name string) (*Path, []string, error) {
 	err := isValidPathName(name)
@@ -88,6 +176,7 @@ type Path struct {
 
 	// General
 	Source                     string         `json:"source"`
+	Sources                    PathSources    `json:"sources"`
 	SourceFingerprint          string         `json:"sourceFingerprint"`
 	SourceOnDemand             bool           `json:"sourceOnDemand"`
 	SourceOnDemandStartTimeout StringDuration `json:"sourceOnDemandStartTimeout"`
@@ -95,6 +184,16 @@ type Path struct {
 	MaxReaders                 int            `json:"maxReaders"`
 	SRTReadPassphrase          string         `json:"srtReadPassphrase"`
 	Fallback                   string         `json:"fallback"`
+	IncludeTracks              TrackSelectors `json:"includeTracks"`
+	ExcludeTracks              TrackSelectors `json:"excludeTracks"`
+	TimeShiftDuration          StringDuration `json:"timeShiftDuration"`
+	TimeShiftMaxSize           StringSize     `json:"timeShiftMaxSize"`
+
+	// Health
+	HealthNoDataTimeout       StringDuration `json:"healthNoDataTimeout"`
+	HealthMaxTimestampJump    StringDuration `json:"healthMaxTimestampJump"`
+	HealthMaxKeyFrameInterval StringDuration `json:"healthMaxKeyFrameInterval"`
+	HealthCloseSource         bool           `json:"healthCloseSource"`
 
 	// Record
 	Record                bool           `json:"record"`
@@ -117,6 +216,7 @@ type Path struct {
 	OverridePublisher        bool   `json:"overridePublisher"`
 	DisablePublisherOverride *bool  `json:"disablePublisherOverride,omitempty"` // deprecated
 	SRTPublishPassphrase     string `json:"srtPublishPassphrase"`
+	Slate                    string `json:"slate"`
 
 	// RTSP source
 	RTSPTransport       RTSPTransport  `json:"rtspTransport"`
@@ -166,6 +266,9 @@ type Path struct {
 	RPICameraProfile           string    `json:"rpiCameraProfile"`
 	RPICameraLevel             string    `json:"rpiCameraLevel"`
 
+	// GPS
+	GPSConfig GPSConfig `json:"gpsConfig"`
+
 	// Hooks
 	RunOnInit                  string         `json:"runOnInit"`
 	RunOnInitRestart           bool           `json:"runOnInitRestart"`
@@ -182,13 +285,20 @@ type Path struct {
 	RunOnUnread                string         `json:"runOnUnread"`
 	RunOnRecordSegmentCreate   string         `json:"runOnRecordSegmentCreate"`
 	RunOnRecordSegmentComplete string         `json:"runOnRecordSegmentComplete"`
+	RunOnGeofenceEvent         string         `json:"runOnGeofenceEvent"`
+	RunOnUnhealthy             string         `json:"runOnUnhealthy"`
+	RunOnHealthy               string         `json:"runOnHealthy"`
 }
 
 func (pconf *Path) setDefaults() {
 	// General
 	pconf.Source = "publisher"
+	pconf.Sources = PathSources{}
+	pconf.IncludeTracks = TrackSelectors{}
+	pconf.ExcludeTracks = TrackSelectors{}
 	pconf.SourceOnDemandStartTimeout = 10 * StringDuration(time.Second)
 	pconf.SourceOnDemandCloseAfter = 10 * StringDuration(time.Second)
+	pconf.TimeShiftMaxSize = 50 * 1024 * 1024
 
 	// Record
 	pconf.RecordPath = "./recordings/%path/%Y-%m-%d_%H-%M-%S-%f"
@@ -200,6 +310,14 @@ func (pconf *Path) setDefaults() {
 	// Publisher source
 	pconf.OverridePublisher = true
 
+	// GPS
+	pconf.GPSConfig.Format = "json"
+	pconf.GPSConfig.ReplaySpeed = 1
+	pconf.GPSConfig.MaxCommandRate = 10
+	pconf.GPSConfig.WriteQueueSize = 64
+	pconf.GPSConfig.HistorySize = 10
+	pconf.GPSConfig.HistoryDuration = 10 * StringDuration(time.Second)
+
 	// Raspberry Pi Camera source
 	pconf.RPICameraWidth = 1920
 	pconf.RPICameraHeight = 1080
@@ -279,85 +397,36 @@ func (pconf *Path) validate(
 
 	// General
 
-	if pconf.Source != "publisher" && pconf.Source != "redirect" &&
-		pconf.Regexp != nil && !pconf.SourceOnDemand {
-		return fmt.Errorf("a path with a regular expression (or path 'all') and a static source" +
-			" must have 'sourceOnDemand' set to true")
-	}
-	switch {
-	case pconf.Source == "publisher":
-
-	case strings.HasPrefix(pconf.Source, "rtsp://") ||
-		strings.HasPrefix(pconf.Source, "rtsps://"):
-		_, err := base.ParseURL(pconf.Source)
-		if err != nil {
-			return fmt.Errorf("'%s' is not a valid URL", pconf.Source)
-		}
-
-	case strings.HasPrefix(pconf.Source, "rtmp://") ||
-		strings.HasPrefix(pconf.Source, "rtmps://"):
-		u, err := gourl.Parse(pconf.Source)
-		if err != nil {
-			return fmt.Errorf("'%s' is not a valid URL", pconf.Source)
+	if len(pconf.Sources) != 0 {
+		if pconf.Source != "publisher" {
+			return fmt.Errorf("'source' and 'sources' cannot be used together")
 		}
-
-		if u.User != nil {
-			pass, _ := u.User.Password()
-			user := u.User.Username()
-			if user != "" && pass == "" ||
-				user == "" && pass != "" {
-				return fmt.Errorf("username and password must be both provided")
+		for _, source := range pconf.Sources {
+			if source == "publisher" || source == "redirect" {
+				return fmt.Errorf("'%s' cannot be used in 'sources'", source)
 			}
-		}
-
-	case strings.HasPrefix(pconf.Source, "http://") ||
-		strings.HasPrefix(pconf.Source, "https://"):
-		u, err := gourl.Parse(pconf.Source)
-		if err != nil {
-			return fmt.Errorf("'%s' is not a valid URL", pconf.Source)
-		}
-		if u.Scheme != "http" && u.Scheme != "https" {
-			return fmt.Errorf("'%s' is not a valid URL", pconf.Source)
-		}
-
-		if u.User != nil {
-			pass, _ := u.User.Password()
-			user := u.User.Username()
-			if user != "" && pass == "" ||
-				user == "" && pass != "" {
-				return fmt.Errorf("username and password must be both provided")
+			err := checkSource(source)
+			if err != nil {
+				return err
 			}
 		}
-
-	case strings.HasPrefix(pconf.Source, "udp://"):
-		_, _, err := net.SplitHostPort(pconf.Source[len("udp://"):])
-		if err != nil {
-			return fmt.Errorf("'%s' is not a valid UDP URL", pconf.Source)
-		}
-
-	case strings.HasPrefix(pconf.Source, "srt://"):
-
-		_, err := gourl.Parse(pconf.Source)
-		if err != nil {
-			return fmt.Errorf("'%s' is not a valid URL", pconf.Source)
-		}
-
-	case strings.HasPrefix(pconf.Source, "whep://") ||
-		strings.HasPrefix(pconf.Source, "wheps://"):
-		_, err := gourl.Parse(pconf.Source)
-		if err != nil {
-			return fmt.Errorf("'%s' is not a valid URL", pconf.Source)
+	}
+	if pconf.HasStaticSource() && pconf.Regexp != nil && !pconf.SourceOnDemand {
+		return fmt.Errorf("a path with a regular expression (or path 'all') and a static source" +
+			" must have 'sourceOnDemand' set to true")
+	}
+	if err := checkSource(pconf.Source); err != nil {
+		return err
+	}
+	if pconf.Regexp == nil {
+		for _, source := range append(PathSources{pconf.Source}, pconf.Sources...) {
+			if source == "path://"+pconf.Name {
+				return fmt.Errorf("a path can't read from itself")
+			}
 		}
-
-	case pconf.Source == "redirect":
-
-	case pconf.Source == "rpiCamera":
-
-	default:
-		return fmt.Errorf("invalid source: '%s'", pconf.Source)
 	}
 	if pconf.SourceOnDemand {
-		if pconf.Source == "publisher" {
+		if pconf.hasPublisherSource() {
 			return fmt.Errorf("'sourceOnDemand' is useless when source is 'publisher'")
 		}
 	}
@@ -380,6 +449,17 @@ func (pconf *Path) validate(
 			}
 		}
 	}
+	err := pconf.IncludeTracks.validate()
+	if err != nil {
+		return fmt.Errorf("invalid 'includeTracks': %w", err)
+	}
+	err = pconf.ExcludeTracks.validate()
+	if err != nil {
+		return fmt.Errorf("invalid 'excludeTracks': %w", err)
+	}
+	if pconf.TimeShiftDuration < 0 {
+		return fmt.Errorf("invalid 'timeShiftDuration': %v", time.Duration(pconf.TimeShiftDuration))
+	}
 
 	// Record
 
@@ -471,7 +551,7 @@ func (pconf *Path) validate(
 		pconf.OverridePublisher = !*pconf.DisablePublisherOverride
 	}
 	if pconf.SRTPublishPassphrase != "" {
-		if pconf.Source != "publisher" {
+		if !pconf.hasPublisherSource() {
 			return fmt.Errorf("'srtPublishPassphase' can only be used when source is 'publisher'")
 		}
 
@@ -480,6 +560,14 @@ func (pconf *Path) validate(
 			return fmt.Errorf("invalid 'srtPublishPassphrase': %w", err)
 		}
 	}
+	if pconf.Slate != "" {
+		if !pconf.hasPublisherSource() {
+			return fmt.Errorf("'slate' can only be used when source is 'publisher'")
+		}
+		if pconf.RunOnDemand != "" {
+			return fmt.Errorf("'slate' and 'runOnDemand' cannot be used together")
+		}
+	}
 
 	// RTSP source
 
@@ -558,13 +646,35 @@ func (pconf *Path) validate(
 		return fmt.Errorf("invalid 'rpiCameraCodec' value")
 	}
 
+	// Health
+
+	if pconf.HealthNoDataTimeout < 0 {
+		return fmt.Errorf("invalid 'healthNoDataTimeout': %v", pconf.HealthNoDataTimeout)
+	}
+	if pconf.HealthMaxTimestampJump < 0 {
+		return fmt.Errorf("invalid 'healthMaxTimestampJump': %v", pconf.HealthMaxTimestampJump)
+	}
+	if pconf.HealthMaxKeyFrameInterval < 0 {
+		return fmt.Errorf("invalid 'healthMaxKeyFrameInterval': %v", pconf.HealthMaxKeyFrameInterval)
+	}
+	if pconf.HealthCloseSource && !pconf.HasHealthCheck() {
+		return fmt.Errorf("'healthCloseSource' requires at least one health check")
+	}
+
+	// GPS
+
+	err = pconf.GPSConfig.validate()
+	if err != nil {
+		return fmt.Errorf("invalid 'gpsConfig': %w", err)
+	}
+
 	// Hooks
 
 	if pconf.RunOnInit != "" && pconf.Regexp != nil {
 		return fmt.Errorf("a path with a regular expression (or path 'all')" +
 			" does not support option 'runOnInit'; use another path")
 	}
-	if (pconf.RunOnDemand != "" || pconf.RunOnUnDemand != "") && pconf.Source != "publisher" {
+	if (pconf.RunOnDemand != "" || pconf.RunOnUnDemand != "") && !pconf.hasPublisherSource() {
 		return fmt.Errorf("'runOnDemand' and 'runOnUnDemand' can be used only when source is 'publisher'")
 	}
 
@@ -578,7 +688,13 @@ func (pconf *Path) Equal(other *Path) bool {
 
 // HasStaticSource checks whether the path has a static source.
 func (pconf Path) HasStaticSource() bool {
-	return pconf.Source != "publisher" && pconf.Source != "redirect"
+	return len(pconf.Sources) != 0 ||
+		(pconf.Source != "publisher" && pconf.Source != "redirect")
+}
+
+// hasPublisherSource checks whether the stream of the path is provided by publishers.
+func (pconf Path) hasPublisherSource() bool {
+	return pconf.Source == "publisher" && len(pconf.Sources) == 0
 }
 
 // HasOnDemandStaticSource checks whether the path has a on demand static source.
@@ -586,6 +702,13 @@ func (pconf Path) HasOnDemandStaticSource() bool {
 	return pconf.HasStaticSource() && pconf.SourceOnDemand
 }
 
+// HasHealthCheck checks whether the health of the stream is checked.
+func (pconf Path) HasHealthCheck() bool {
+	return pconf.HealthNoDataTimeout > 0 ||
+		pconf.HealthMaxTimestampJump > 0 ||
+		pconf.HealthMaxKeyFrameInterval > 0
+}
+
 // HasOnDemandPublisher checks whether the path has a on-demand publisher.
 func (pconf Path) HasOnDemandPublisher() bool {
 	return pconf.RunOnDemand != ""
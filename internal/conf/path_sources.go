@@ -0,0 +1,32 @@
+package conf
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// PathSources is a list of redundant sources of a path, in order of priority.
+type PathSources []string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *PathSources) UnmarshalJSON(b []byte) error {
+	// remove default value before loading new value
+	// https://github.com/golang/go/issues/21092
+	*s = nil
+	return json.Unmarshal(b, (*[]string)(s))
+}
+
+// UnmarshalEnv implements env.Unmarshaler.
+func (s *PathSources) UnmarshalEnv(_ string, v string) error {
+	*s = nil
+
+	if v == "" {
+		return nil
+	}
+
+	for _, source := range strings.Split(v, ",") {
+		*s = append(*s, strings.TrimSpace(source))
+	}
+
+	return nil
+}
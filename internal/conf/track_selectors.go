@@ -0,0 +1,70 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TrackSelectors is a list of track selectors.
+// A selector is a media type (video, audio, application),
+// a codec name or the position of the track, starting from 1.
+type TrackSelectors []string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *TrackSelectors) UnmarshalJSON(b []byte) error {
+	// remove default value before loading new value
+	// https://github.com/golang/go/issues/21092
+	*s = TrackSelectors{}
+
+	var in []interface{}
+	err := json.Unmarshal(b, &in)
+	if err != nil {
+		return err
+	}
+
+	// positions can be provided as numbers
+	for _, selector := range in {
+		switch selector := selector.(type) {
+		case string:
+			*s = append(*s, selector)
+
+		case float64:
+			*s = append(*s, strconv.FormatFloat(selector, 'f', -1, 64))
+
+		default:
+			return fmt.Errorf("invalid track selector: %v", selector)
+		}
+	}
+
+	return nil
+}
+
+// UnmarshalEnv implements env.Unmarshaler.
+func (s *TrackSelectors) UnmarshalEnv(_ string, v string) error {
+	*s = nil
+
+	if v == "" {
+		return nil
+	}
+
+	for _, selector := range strings.Split(v, ",") {
+		*s = append(*s, strings.TrimSpace(selector))
+	}
+
+	return nil
+}
+
+func (s TrackSelectors) validate() error {
+	for _, selector := range s {
+		if selector == "" {
+			return fmt.Errorf("empty selector")
+		}
+
+		if n, err := strconv.ParseInt(selector, 10, 64); err == nil && n < 1 {
+			return fmt.Errorf("invalid track position: %d", n)
+		}
+	}
+	return nil
+}
@@ -5,7 +5,6 @@ import (
 	"context"
 	_ "embed"
 	"fmt"
-	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -54,6 +53,33 @@ var cli struct {
 	Confpath string `arg:"" default:""`
 }
 
+func anyPathHasGPSConfig(c *conf.Conf) bool {
+	// the deprecated global gpsConfig is shared by paths.
+	if c.GpsConfig != nil && c.GpsConfig.IsEnabled() {
+		return true
+	}
+
+	for _, pathConf := range c.Paths {
+		if pathConf.GPSConfig.IsEnabled() {
+			return true
+		}
+	}
+	return false
+}
+
+func anyPathHasGPSPublisher(c *conf.Conf) bool {
+	if c.GpsConfig != nil && c.GpsConfig.Protocol == "publisher" {
+		return true
+	}
+
+	for _, pathConf := range c.Paths {
+		if pathConf.GPSConfig.Protocol == "publisher" {
+			return true
+		}
+	}
+	return false
+}
+
 // Core is an instance of MediaMTX.
 type Core struct {
 	ctx             context.Context
@@ -75,6 +101,7 @@ type Core struct {
 	hlsServer       *hls.Server
 	webRTCServer    *webrtc.Server
 	srtServer       *srt.Server
+	beaconServer    *beacon_stream.Server
 	api             *api.API
 	confWatcher     *confwatcher.ConfWatcher
 
@@ -336,6 +363,11 @@ func (p *Core) createResources(initial bool) error {
 	}
 
 	if p.pathManager == nil {
+		if p.conf.GpsConfig != nil {
+			p.Log(logger.Warn, "the global 'gpsConfig' is deprecated and is shared by all paths "+
+				"without a gpsConfig, move it into the configuration of paths")
+		}
+
 		p.pathManager = &pathManager{
 			logLevel:          p.conf.LogLevel,
 			authManager:       p.authManager,
@@ -345,6 +377,7 @@ func (p *Core) createResources(initial bool) error {
 			writeQueueSize:    p.conf.WriteQueueSize,
 			udpMaxPayloadSize: p.conf.UDPMaxPayloadSize,
 			pathConfs:         p.conf.Paths,
+			gpsConfig:         p.conf.GpsConfig,
 			externalCmdPool:   p.externalCmdPool,
 			parent:            p,
 		}
@@ -551,6 +584,7 @@ func (p *Core) createResources(initial bool) error {
 			HandshakeTimeout:      p.conf.WebRTCHandshakeTimeout,
 			TrackGatherTimeout:    p.conf.WebRTCTrackGatherTimeout,
 			ExternalCmdPool:       p.externalCmdPool,
+			AuthManager:           p.authManager,
 			PathManager:           p.pathManager,
 			Parent:                p,
 		}
@@ -591,6 +625,50 @@ func (p *Core) createResources(initial bool) error {
 		}
 	}
 
+	if anyPathHasGPSConfig(p.conf) &&
+		p.beaconServer == nil {
+		// TCP ingest is only needed by paths that receive data from publishers
+		var tcpAddress string
+		if anyPathHasGPSPublisher(p.conf) {
+			tcpAddress = p.conf.GPSTCPAddress
+		}
+
+		i := &beacon_stream.Server{
+			Address:               p.conf.GPSAddress,
+			Encryption:            p.conf.GPSEncryption,
+			ServerKey:             p.conf.GPSServerKey,
+			ServerCert:            p.conf.GPSServerCert,
+			AllowOrigin:           p.conf.GPSAllowOrigin,
+			TrustedProxies:        p.conf.GPSTrustedProxies,
+			TCPAddress:            tcpAddress,
+			ReadTimeout:           p.conf.ReadTimeout,
+			ICEServers:            p.conf.WebRTCICEServers2,
+			HandshakeTimeout:      p.conf.WebRTCHandshakeTimeout,
+			IPsFromInterfaces:     p.conf.WebRTCIPsFromInterfaces,
+			IPsFromInterfacesList: p.conf.WebRTCIPsFromInterfacesList,
+			AdditionalHosts:       p.conf.WebRTCAdditionalHosts,
+			AuthManager:           p.authManager,
+			PathManager:           p.pathManager,
+			Parent:                p,
+		}
+
+		// share ICE ports with the WebRTC server
+		if p.webRTCServer != nil {
+			i.ICEUDPMux = p.webRTCServer.ICEUDPMux()
+			i.ICETCPMux = p.webRTCServer.ICETCPMux()
+		}
+
+		err = i.Initialize()
+		if err != nil {
+			return err
+		}
+		p.beaconServer = i
+
+		if p.metrics != nil {
+			p.metrics.SetBeaconServer(p.beaconServer)
+		}
+	}
+
 	if p.conf.API &&
 		p.api == nil {
 		i := &api.API{
@@ -611,6 +689,7 @@ func (p *Core) createResources(initial bool) error {
 			HLSServer:      p.hlsServer,
 			WebRTCServer:   p.webRTCServer,
 			SRTServer:      p.srtServer,
+			BeaconServer:   p.beaconServer,
 			Parent:         p,
 		}
 		err = i.Initialize()
@@ -627,50 +706,6 @@ func (p *Core) createResources(initial bool) error {
 		}
 	}
 
-	// * Unified GPSConfig validation and server start
-	if p.conf.GpsConfig != nil {
-		// * Validate the gpsConfig details
-		if p.conf.GpsConfig.Protocol != "" && p.conf.GpsConfig.IPAddress != "" && p.conf.GpsConfig.Port != 0 {
-			// * Validate the protocol
-			validProtocols := map[string]bool{"ws": true, "tcp": true, "udp": true}
-			if validProtocols[p.conf.GpsConfig.Protocol] {
-				p.Log(logger.Info, "GPS stream is enabled with protocol:%s", p.conf.GpsConfig.Protocol)
-				p.Log(logger.Info, "GPS stream is enabled with protocol:%s", p.conf.GpsConfig.Protocol)
-				p.Log(logger.Info, "Raw data log: %v", p.conf.GpsConfig.RawDataLog)
-
-				go func() {
-					mux := http.NewServeMux()
-
-					mux.HandleFunc("/ice", func(w http.ResponseWriter, r *http.Request) {
-						beacon_stream.ICEHandler(w, r, &p.conf.WebRTCICEServers2)
-					})
-
-					mux.HandleFunc("/gps-ws", func(w http.ResponseWriter, r *http.Request) {
-						beacon_stream.HandleBeaconStreamWebSocket(w, r, p.conf.GpsConfig, &p.conf.WebRTCICEServers2, p.conf.GpsConfig.RawDataLog)
-					})
-
-					handler := beacon_stream.EnableCORS(mux)
-
-					p.Log(logger.Info, "Starting beacon server on :8080")
-
-					// * Start the server with the handler
-					if err := http.ListenAndServe(":8080", handler); err != nil && err != http.ErrServerClosed {
-						p.Log(logger.Error, "Beacon server failed: %v", err)
-					}
-				}()
-			} else {
-				p.Log(logger.Info, "GPS stream protocol is invalid. Must be one of 'ws', 'tcp', or 'udp'. GPS stream is disabled.")
-				p.Log(logger.Info, "GPS stream is disabled due to invalid protocol.")
-			}
-		} else {
-			// * Incomplete configuration, disable the GPS stream
-			p.Log(logger.Info, "GPS stream is disabled due to incomplete configuration.")
-		}
-	} else {
-		// * gpsConfig is not set, so GPS stream is disabled
-		p.Log(logger.Info, "GPS stream is disabled because gpsConfig is not set.")
-	}
-
 	return nil
 }
 
@@ -745,6 +780,7 @@ func (p *Core) closeResources(newConf *conf.Conf, calledByAPI bool) {
 		newConf.WriteTimeout != p.conf.WriteTimeout ||
 		newConf.WriteQueueSize != p.conf.WriteQueueSize ||
 		newConf.UDPMaxPayloadSize != p.conf.UDPMaxPayloadSize ||
+		!reflect.DeepEqual(newConf.GpsConfig, p.conf.GpsConfig) ||
 		closeMetrics ||
 		closeAuthManager ||
 		closeLogger
@@ -863,6 +899,7 @@ func (p *Core) closeResources(newConf *conf.Conf, calledByAPI bool) {
 		newConf.WebRTCHandshakeTimeout != p.conf.WebRTCHandshakeTimeout ||
 		newConf.WebRTCTrackGatherTimeout != p.conf.WebRTCTrackGatherTimeout ||
 		closeMetrics ||
+		closeAuthManager ||
 		closePathManager ||
 		closeLogger
 
@@ -879,6 +916,24 @@ func (p *Core) closeResources(newConf *conf.Conf, calledByAPI bool) {
 		closePathManager ||
 		closeLogger
 
+	closeBeaconServer := newConf == nil ||
+		anyPathHasGPSConfig(newConf) != anyPathHasGPSConfig(p.conf) ||
+		anyPathHasGPSPublisher(newConf) != anyPathHasGPSPublisher(p.conf) ||
+		newConf.GPSAddress != p.conf.GPSAddress ||
+		newConf.GPSEncryption != p.conf.GPSEncryption ||
+		newConf.GPSServerKey != p.conf.GPSServerKey ||
+		newConf.GPSServerCert != p.conf.GPSServerCert ||
+		newConf.GPSAllowOrigin != p.conf.GPSAllowOrigin ||
+		!reflect.DeepEqual(newConf.GPSTrustedProxies, p.conf.GPSTrustedProxies) ||
+		newConf.GPSTCPAddress != p.conf.GPSTCPAddress ||
+		newConf.ReadTimeout != p.conf.ReadTimeout ||
+		!reflect.DeepEqual(newConf.WebRTCICEServers2, p.conf.WebRTCICEServers2) ||
+		closeMetrics ||
+		closeAuthManager ||
+		closePathManager ||
+		closeWebRTCServer ||
+		closeLogger
+
 	closeAPI := newConf == nil ||
 		newConf.API != p.conf.API ||
 		newConf.APIAddress != p.conf.APIAddress ||
@@ -896,6 +951,7 @@ func (p *Core) closeResources(newConf *conf.Conf, calledByAPI bool) {
 		closeHLSServer ||
 		closeWebRTCServer ||
 		closeSRTServer ||
+		closeBeaconServer ||
 		closeLogger
 
 	if newConf == nil && p.confWatcher != nil {
@@ -912,6 +968,15 @@ func (p *Core) closeResources(newConf *conf.Conf, calledByAPI bool) {
 		}
 	}
 
+	if closeBeaconServer && p.beaconServer != nil {
+		if p.metrics != nil {
+			p.metrics.SetBeaconServer(nil)
+		}
+
+		p.beaconServer.Close()
+		p.beaconServer = nil
+	}
+
 	if closeSRTServer && p.srtServer != nil {
 		if p.metrics != nil {
 			p.metrics.SetSRTServer(nil)
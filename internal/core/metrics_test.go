@@ -248,21 +248,69 @@ webrtc_sessions_bytes_sent 0
 			`^paths\{name=".*?",state="ready"\} 1`+"\n"+
 				`paths_bytes_received\{name=".*?",state="ready"\} [0-9]+`+"\n"+
 				`paths_bytes_sent\{name=".*?",state="ready"\} [0-9]+`+"\n"+
+				`paths_tracks_bitrate\{name=".*?",track="0",codec="H264"\} [0-9.e+-]+`+"\n"+
+				`paths_tracks_frame_rate\{name=".*?",track="0",codec="H264"\} [0-9.e+-]+`+"\n"+
+				`paths_tracks_gop_length\{name=".*?",track="0",codec="H264"\} [0-9]+`+"\n"+
+				`paths_tracks_keyframe_interval_seconds\{name=".*?",track="0",codec="H264"\} [0-9.e+-]+`+"\n"+
+				`paths_readers_units_delivered\{name=".*?",type="hlsMuxer",id=""\} [0-9]+`+"\n"+
+				`paths_readers_units_dropped\{name=".*?",type="hlsMuxer",id=""\} 0`+"\n"+
+				`paths_readers_queue_depth\{name=".*?",type="hlsMuxer",id=""\} [0-9]+`+"\n"+
+				`paths_readers_max_lag_seconds\{name=".*?",type="hlsMuxer",id=""\} [0-9.e-]+`+"\n"+
 				`paths\{name=".*?",state="ready"\} 1`+"\n"+
 				`paths_bytes_received\{name=".*?",state="ready"\} [0-9]+`+"\n"+
 				`paths_bytes_sent\{name=".*?",state="ready"\} [0-9]+`+"\n"+
+				`paths_tracks_bitrate\{name=".*?",track="0",codec="H264"\} [0-9.e+-]+`+"\n"+
+				`paths_tracks_frame_rate\{name=".*?",track="0",codec="H264"\} [0-9.e+-]+`+"\n"+
+				`paths_tracks_gop_length\{name=".*?",track="0",codec="H264"\} [0-9]+`+"\n"+
+				`paths_tracks_keyframe_interval_seconds\{name=".*?",track="0",codec="H264"\} [0-9.e+-]+`+"\n"+
+				`paths_readers_units_delivered\{name=".*?",type="hlsMuxer",id=""\} [0-9]+`+"\n"+
+				`paths_readers_units_dropped\{name=".*?",type="hlsMuxer",id=""\} 0`+"\n"+
+				`paths_readers_queue_depth\{name=".*?",type="hlsMuxer",id=""\} [0-9]+`+"\n"+
+				`paths_readers_max_lag_seconds\{name=".*?",type="hlsMuxer",id=""\} [0-9.e-]+`+"\n"+
 				`paths\{name=".*?",state="ready"\} 1`+"\n"+
 				`paths_bytes_received\{name=".*?",state="ready"\} [0-9]+`+"\n"+
 				`paths_bytes_sent\{name=".*?",state="ready"\} [0-9]+`+"\n"+
+				`paths_tracks_bitrate\{name=".*?",track="0",codec="H264"\} [0-9.e+-]+`+"\n"+
+				`paths_tracks_frame_rate\{name=".*?",track="0",codec="H264"\} [0-9.e+-]+`+"\n"+
+				`paths_tracks_gop_length\{name=".*?",track="0",codec="H264"\} [0-9]+`+"\n"+
+				`paths_tracks_keyframe_interval_seconds\{name=".*?",track="0",codec="H264"\} [0-9.e+-]+`+"\n"+
+				`paths_readers_units_delivered\{name=".*?",type="hlsMuxer",id=""\} [0-9]+`+"\n"+
+				`paths_readers_units_dropped\{name=".*?",type="hlsMuxer",id=""\} 0`+"\n"+
+				`paths_readers_queue_depth\{name=".*?",type="hlsMuxer",id=""\} [0-9]+`+"\n"+
+				`paths_readers_max_lag_seconds\{name=".*?",type="hlsMuxer",id=""\} [0-9.e-]+`+"\n"+
 				`paths\{name=".*?",state="ready"\} 1`+"\n"+
 				`paths_bytes_received\{name=".*?",state="ready"\} [0-9]+`+"\n"+
 				`paths_bytes_sent\{name=".*?",state="ready"\} [0-9]+`+"\n"+
+				`paths_tracks_bitrate\{name=".*?",track="0",codec="H264"\} [0-9.e+-]+`+"\n"+
+				`paths_tracks_frame_rate\{name=".*?",track="0",codec="H264"\} [0-9.e+-]+`+"\n"+
+				`paths_tracks_gop_length\{name=".*?",track="0",codec="H264"\} [0-9]+`+"\n"+
+				`paths_tracks_keyframe_interval_seconds\{name=".*?",track="0",codec="H264"\} [0-9.e+-]+`+"\n"+
+				`paths_readers_units_delivered\{name=".*?",type="hlsMuxer",id=""\} [0-9]+`+"\n"+
+				`paths_readers_units_dropped\{name=".*?",type="hlsMuxer",id=""\} 0`+"\n"+
+				`paths_readers_queue_depth\{name=".*?",type="hlsMuxer",id=""\} [0-9]+`+"\n"+
+				`paths_readers_max_lag_seconds\{name=".*?",type="hlsMuxer",id=""\} [0-9.e-]+`+"\n"+
 				`paths\{name=".*?",state="ready"\} 1`+"\n"+
 				`paths_bytes_received\{name=".*?",state="ready"\} [0-9]+`+"\n"+
 				`paths_bytes_sent\{name=".*?",state="ready"\} [0-9]+`+"\n"+
+				`paths_tracks_bitrate\{name=".*?",track="0",codec="H264"\} [0-9.e+-]+`+"\n"+
+				`paths_tracks_frame_rate\{name=".*?",track="0",codec="H264"\} [0-9.e+-]+`+"\n"+
+				`paths_tracks_gop_length\{name=".*?",track="0",codec="H264"\} [0-9]+`+"\n"+
+				`paths_tracks_keyframe_interval_seconds\{name=".*?",track="0",codec="H264"\} [0-9.e+-]+`+"\n"+
+				`paths_readers_units_delivered\{name=".*?",type="hlsMuxer",id=""\} [0-9]+`+"\n"+
+				`paths_readers_units_dropped\{name=".*?",type="hlsMuxer",id=""\} 0`+"\n"+
+				`paths_readers_queue_depth\{name=".*?",type="hlsMuxer",id=""\} [0-9]+`+"\n"+
+				`paths_readers_max_lag_seconds\{name=".*?",type="hlsMuxer",id=""\} [0-9.e-]+`+"\n"+
 				`paths\{name=".*?",state="ready"\} 1`+"\n"+
 				`paths_bytes_received\{name=".*?",state="ready"\} [0-9]+`+"\n"+
 				`paths_bytes_sent\{name=".*?",state="ready"\} [0-9]+`+"\n"+
+				`paths_tracks_bitrate\{name=".*?",track="0",codec="H264"\} [0-9.e+-]+`+"\n"+
+				`paths_tracks_frame_rate\{name=".*?",track="0",codec="H264"\} [0-9.e+-]+`+"\n"+
+				`paths_tracks_gop_length\{name=".*?",track="0",codec="H264"\} [0-9]+`+"\n"+
+				`paths_tracks_keyframe_interval_seconds\{name=".*?",track="0",codec="H264"\} [0-9.e+-]+`+"\n"+
+				`paths_readers_units_delivered\{name=".*?",type="hlsMuxer",id=""\} [0-9]+`+"\n"+
+				`paths_readers_units_dropped\{name=".*?",type="hlsMuxer",id=""\} 0`+"\n"+
+				`paths_readers_queue_depth\{name=".*?",type="hlsMuxer",id=""\} [0-9]+`+"\n"+
+				`paths_readers_max_lag_seconds\{name=".*?",type="hlsMuxer",id=""\} [0-9.e-]+`+"\n"+
 				`hls_muxers\{name=".*?"\} 1`+"\n"+
 				`hls_muxers_bytes_sent\{name=".*?"\} 0`+"\n"+
 				`hls_muxers\{name=".*?"\} 1`+"\n"+
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"net/url"
 	"strconv"
 	"strings"
 	"sync"
@@ -18,9 +19,15 @@ import (
 	"github.com/bluenviron/mediamtx/internal/hooks"
 	"github.com/bluenviron/mediamtx/internal/logger"
 	"github.com/bluenviron/mediamtx/internal/recorder"
+	"github.com/bluenviron/mediamtx/internal/servers/beacon_stream"
+	"github.com/bluenviron/mediamtx/internal/slate"
 	"github.com/bluenviron/mediamtx/internal/stream"
 )
 
+const (
+	pathHealthCheckPeriod = 1 * time.Second
+)
+
 func emptyTimer() *time.Timer {
 	t := time.NewTimer(0)
 	<-t.C
@@ -29,6 +36,7 @@ func emptyTimer() *time.Timer {
 
 type pathParent interface {
 	logger.Writer
+	AddReader(req defs.PathAddReaderReq) (defs.Path, *stream.Stream, error)
 	pathReady(*path)
 	pathNotReady(*path)
 	closePath(*path)
@@ -76,6 +84,7 @@ type path struct {
 	matches           []string
 	wg                *sync.WaitGroup
 	externalCmdPool   *externalcmd.Pool
+	sharedBeaconHub   *beacon_stream.Hub
 	parent            pathParent
 
 	ctx                            context.Context
@@ -84,10 +93,18 @@ type path struct {
 	source                         defs.Source
 	publisherQuery                 string
 	stream                         *stream.Stream
+	publisherStream                *stream.Stream
+	publisherRelay                 *stream.Relay
+	slate                          *slate.Slate
 	recorder                       *recorder.Recorder
+	telemetryRecorder              *recorder.TelemetryRecorder
+	beaconHub                      *beacon_stream.Hub
 	readyTime                      time.Time
 	onUnDemandHook                 func(string)
 	onNotReadyHook                 func()
+	watchdog                       *stream.Watchdog
+	healthCheckTimer               *time.Timer
+	onHealthyHook                  func()
 	readers                        map[defs.Reader]struct{}
 	describeRequestsOnHold         []defs.PathDescribeReq
 	readerAddRequestsOnHold        []defs.PathAddReaderReq
@@ -125,6 +142,7 @@ func (pa *path) initialize() {
 	pa.onDemandStaticSourceCloseTimer = emptyTimer()
 	pa.onDemandPublisherReadyTimer = emptyTimer()
 	pa.onDemandPublisherCloseTimer = emptyTimer()
+	pa.healthCheckTimer = emptyTimer()
 	pa.chReloadConf = make(chan *conf.Path)
 	pa.chStaticSourceSetReady = make(chan defs.PathSourceStaticSetReadyReq)
 	pa.chStaticSourceSetNotReady = make(chan defs.PathSourceStaticSetNotReadyReq)
@@ -138,6 +156,18 @@ func (pa *path) initialize() {
 	pa.chAPIPathsGet = make(chan pathAPIPathsGetReq)
 	pa.done = make(chan struct{})
 
+	if pa.conf.GPSConfig.IsEnabled() {
+		pa.beaconHub = &beacon_stream.Hub{
+			Conf:              pa.conf.GPSConfig,
+			OverridePublisher: pa.conf.OverridePublisher,
+			OnGeofenceEvent:   pa.onGeofenceEvent,
+			Parent:            pa,
+		}
+		pa.beaconHub.Initialize()
+	} else {
+		pa.beaconHub = pa.sharedBeaconHub
+	}
+
 	pa.Log(logger.Debug, "created")
 
 	pa.wg.Add(1)
@@ -169,13 +199,15 @@ func (pa *path) run() {
 		pa.source = &sourceRedirect{}
 	} else if pa.conf.HasStaticSource() {
 		pa.source = &staticSourceHandler{
-			conf:           pa.conf,
-			logLevel:       pa.logLevel,
-			readTimeout:    pa.readTimeout,
-			writeTimeout:   pa.writeTimeout,
-			writeQueueSize: pa.writeQueueSize,
-			matches:        pa.matches,
-			parent:         pa,
+			conf:              pa.conf,
+			logLevel:          pa.logLevel,
+			readTimeout:       pa.readTimeout,
+			writeTimeout:      pa.writeTimeout,
+			writeQueueSize:    pa.writeQueueSize,
+			udpMaxPayloadSize: pa.udpMaxPayloadSize,
+			matches:           pa.matches,
+			pathManager:       pa.parent,
+			parent:            pa,
 		}
 		pa.source.(*staticSourceHandler).initialize()
 
@@ -202,6 +234,7 @@ func (pa *path) run() {
 	pa.onDemandStaticSourceCloseTimer.Stop()
 	pa.onDemandPublisherReadyTimer.Stop()
 	pa.onDemandPublisherCloseTimer.Stop()
+	pa.healthCheckTimer.Stop()
 
 	onUnInitHook()
 
@@ -231,6 +264,10 @@ func (pa *path) run() {
 		pa.onUnDemandHook("path destroyed")
 	}
 
+	if pa.beaconHub != nil && pa.beaconHub != pa.sharedBeaconHub {
+		pa.beaconHub.Close()
+	}
+
 	pa.Log(logger.Debug, "destroyed: %v", err)
 }
 
@@ -261,6 +298,9 @@ func (pa *path) runInner() error {
 		case <-pa.onDemandPublisherCloseTimer.C:
 			pa.doOnDemandPublisherCloseTimer()
 
+		case <-pa.healthCheckTimer.C:
+			pa.doHealthCheck()
+
 		case newConf := <-pa.chReloadConf:
 			pa.doReloadConf(newConf)
 
@@ -357,7 +397,48 @@ func (pa *path) doOnDemandPublisherCloseTimer() {
 	pa.onDemandPublisherStop("not needed by anyone")
 }
 
+func (pa *path) doHealthCheck() {
+	pa.healthCheckTimer = time.NewTimer(pathHealthCheckPeriod)
+
+	health, changed := pa.watchdog.Check(time.Now())
+	if !changed {
+		return
+	}
+
+	if health.Healthy {
+		pa.Log(logger.Info, "stream is healthy")
+		pa.onHealthyHook()
+		pa.onHealthyHook = nil
+		return
+	}
+
+	pa.Log(logger.Warn, "stream is unhealthy: %s", health.Reason)
+
+	pa.onHealthyHook = hooks.OnUnhealthy(hooks.OnUnhealthyParams{
+		Logger:          pa,
+		ExternalCmdPool: pa.externalCmdPool,
+		Conf:            pa.conf,
+		ExternalCmdEnv:  pa.ExternalCmdEnv(),
+		Reason:          health.Reason,
+	})
+
+	if pa.conf.HealthCloseSource {
+		switch source := pa.source.(type) {
+		case *staticSourceHandler:
+			source.restart("stream is unhealthy")
+
+		case defs.Publisher:
+			pa.Log(logger.Info, "closing publisher since stream is unhealthy")
+			source.Close()
+		}
+	}
+}
+
 func (pa *path) doReloadConf(newConf *conf.Path) {
+	healthChanged := newConf.HealthNoDataTimeout != pa.conf.HealthNoDataTimeout ||
+		newConf.HealthMaxTimestampJump != pa.conf.HealthMaxTimestampJump ||
+		newConf.HealthMaxKeyFrameInterval != pa.conf.HealthMaxKeyFrameInterval
+
 	pa.confMutex.Lock()
 	pa.conf = newConf
 	pa.confMutex.Unlock()
@@ -371,8 +452,16 @@ func (pa *path) doReloadConf(newConf *conf.Path) {
 			pa.startRecording()
 		}
 	} else if pa.recorder != nil {
-		pa.recorder.Close()
-		pa.recorder = nil
+		pa.stopRecording()
+	}
+
+	if healthChanged && pa.stream != nil {
+		if pa.watchdog != nil {
+			pa.stopWatchdog()
+		}
+		if pa.conf.HasHealthCheck() {
+			pa.startWatchdog()
+		}
 	}
 }
 
@@ -415,9 +504,7 @@ func (pa *path) doDescribe(req defs.PathDescribeReq) {
 	}
 
 	if pa.stream != nil {
-		req.Res <- defs.PathDescribeRes{
-			Stream: pa.stream,
-		}
+		pa.describePost(req)
 		return
 	}
 
@@ -465,7 +552,7 @@ func (pa *path) doRemovePublisher(req defs.PathRemovePublisherReq) {
 }
 
 func (pa *path) doAddPublisher(req defs.PathAddPublisherReq) {
-	if pa.conf.Source != "publisher" {
+	if pa.conf.Source != "publisher" || pa.conf.HasStaticSource() {
 		req.Res <- defs.PathAddPublisherRes{
 			Err: fmt.Errorf("can't publish to path '%s' since 'source' is not 'publisher'", pa.name),
 		}
@@ -495,7 +582,7 @@ func (pa *path) doStartPublisher(req defs.PathStartPublisherReq) {
 		return
 	}
 
-	err := pa.setReady(req.Desc, req.GenerateRTPPackets)
+	strm, err := pa.setPublisherReady(req.Desc, req.GenerateRTPPackets)
 	if err != nil {
 		req.Res <- defs.PathStartPublisherRes{Err: err}
 		return
@@ -513,12 +600,12 @@ func (pa *path) doStartPublisher(req defs.PathStartPublisherReq) {
 
 	pa.consumeOnHoldRequests()
 
-	req.Res <- defs.PathStartPublisherRes{Stream: pa.stream}
+	req.Res <- defs.PathStartPublisherRes{Stream: strm}
 }
 
 func (pa *path) doStopPublisher(req defs.PathStopPublisherReq) {
 	if req.Author == pa.source && pa.stream != nil {
-		pa.setNotReady()
+		pa.setPublisherNotReady()
 	}
 	close(req.Res)
 }
@@ -574,6 +661,10 @@ func (pa *path) doAPIPathsGet(req pathAPIPathsGetReq) {
 			ConfName: pa.conf.Name,
 			Source: func() *defs.APIPathSourceOrReader {
 				if pa.source == nil {
+					if pa.slate != nil {
+						v := pa.slate.APISourceDescribe()
+						return &v
+					}
 					return nil
 				}
 				v := pa.source.APISourceDescribe()
@@ -593,6 +684,12 @@ func (pa *path) doAPIPathsGet(req pathAPIPathsGetReq) {
 				}
 				return defs.MediasToCodecs(pa.stream.Desc().Medias)
 			}(),
+			TrackStats: func() []defs.APIPathTrackStats {
+				if pa.stream == nil {
+					return []defs.APIPathTrackStats{}
+				}
+				return apiTrackStats(pa.stream.TrackStats())
+			}(),
 			BytesReceived: func() uint64 {
 				if pa.stream == nil {
 					return 0
@@ -605,17 +702,106 @@ func (pa *path) doAPIPathsGet(req pathAPIPathsGetReq) {
 				}
 				return pa.stream.BytesSent()
 			}(),
-			Readers: func() []defs.APIPathSourceOrReader {
-				ret := []defs.APIPathSourceOrReader{}
+			Readers: func() []defs.APIPathReader {
+				ret := []defs.APIPathReader{}
 				for r := range pa.readers {
-					ret = append(ret, r.APIReaderDescribe())
+					ret = append(ret, pa.apiReaderDescribe(r))
 				}
 				return ret
 			}(),
+			Health: func() *defs.APIPathHealth {
+				if pa.watchdog == nil {
+					return nil
+				}
+				health := pa.watchdog.Health()
+				return &defs.APIPathHealth{
+					Healthy: health.Healthy,
+					Reason:  health.Reason,
+					Since:   health.Since,
+				}
+			}(),
+			GPS: func() *defs.APIPathGPS {
+				if pa.beaconHub == nil {
+					return nil
+				}
+				return &defs.APIPathGPS{
+					UpstreamConnected: pa.beaconHub.UpstreamConnected(),
+					PacketsReceived:   pa.beaconHub.PacketsReceived(),
+					PacketsDropped:    pa.beaconHub.PacketsDropped(),
+					Geofences:         pa.beaconHub.APIGeofences(),
+				}
+			}(),
 		},
 	}
 }
 
+func apiTrackStats(stats []stream.TrackStats) []defs.APIPathTrackStats {
+	ret := make([]defs.APIPathTrackStats, len(stats))
+
+	for i, st := range stats {
+		ret[i] = defs.APIPathTrackStats{
+			Codec:   st.Codec,
+			Bitrate: st.Bitrate,
+		}
+
+		if st.Video != nil {
+			ret[i].Video = &defs.APIPathTrackVideo{
+				FrameRate:        st.Video.FrameRate,
+				GOPLength:        st.Video.GOPLength,
+				KeyFrameInterval: conf.StringDuration(st.Video.KeyFrameInterval),
+				Width:            st.Video.Width,
+				Height:           st.Video.Height,
+				Profile:          st.Video.Profile,
+			}
+
+			if !st.Video.LastKeyFrameTime.IsZero() {
+				v := st.Video.LastKeyFrameTime
+				ret[i].Video.LastKeyFrameTime = &v
+			}
+		}
+
+		if st.Audio != nil {
+			ret[i].Audio = &defs.APIPathTrackAudio{
+				SampleRate:   st.Audio.SampleRate,
+				ChannelCount: st.Audio.ChannelCount,
+			}
+		}
+	}
+
+	return ret
+}
+
+func (pa *path) apiReaderDescribe(r defs.Reader) defs.APIPathReader {
+	desc := r.APIReaderDescribe()
+	ret := defs.APIPathReader{
+		Type: desc.Type,
+		ID:   desc.ID,
+	}
+
+	if pa.stream == nil {
+		return ret
+	}
+
+	sr, ok := r.(stream.Reader)
+	if !ok {
+		return ret
+	}
+
+	// RTSP readers don't read through the stream.
+	stats, ok := pa.stream.ReaderStats(sr)
+	if !ok {
+		return ret
+	}
+
+	ret.Stats = &defs.APIPathReaderStats{
+		UnitsDelivered: stats.UnitsDelivered,
+		UnitsDropped:   stats.UnitsDropped,
+		QueueDepth:     stats.QueueDepth,
+		MaxLag:         conf.StringDuration(stats.MaxLag),
+	}
+	return ret
+}
+
 func (pa *path) SafeConf() *conf.Path {
 	pa.confMutex.RLock()
 	defer pa.confMutex.RUnlock()
@@ -639,6 +825,28 @@ func (pa *path) ExternalCmdEnv() externalcmd.Environment {
 	return env
 }
 
+// onGeofenceEvent is called by the beacon hub routine.
+func (pa *path) onGeofenceEvent(ev *beacon_stream.GeofenceEvent) {
+	cnf := pa.SafeConf()
+
+	if cnf.RunOnGeofenceEvent != "" {
+		env := pa.ExternalCmdEnv()
+		env["MTX_GEOFENCE"] = ev.Geofence
+		env["MTX_GEOFENCE_EVENT"] = ev.Event
+		env["MTX_GEOFENCE_OBJECT"] = ev.Object
+		env["MTX_GEOFENCE_POSITION"] = strconv.FormatFloat(ev.Position[0], 'f', -1, 64) + "," +
+			strconv.FormatFloat(ev.Position[1], 'f', -1, 64)
+
+		pa.Log(logger.Info, "runOnGeofenceEvent command launched")
+		externalcmd.NewCmd(
+			pa.externalCmdPool,
+			cnf.RunOnGeofenceEvent,
+			false,
+			env,
+			nil)
+	}
+}
+
 func (pa *path) shouldClose() bool {
 	return pa.conf.Regexp != nil &&
 		pa.source == nil &&
@@ -709,6 +917,17 @@ func (pa *path) onDemandPublisherStop(reason string) {
 }
 
 func (pa *path) setReady(desc *description.Session, allocateEncoder bool) error {
+	if len(pa.conf.IncludeTracks) != 0 || len(pa.conf.ExcludeTracks) != 0 {
+		medias := stream.SelectMedias(desc.Medias, pa.conf.IncludeTracks, pa.conf.ExcludeTracks)
+		if len(medias) == 0 {
+			return fmt.Errorf("all tracks have been excluded by 'includeTracks' and 'excludeTracks'")
+		}
+
+		filtered := *desc
+		filtered.Medias = medias
+		desc = &filtered
+	}
+
 	var err error
 	pa.stream, err = stream.New(
 		pa.writeQueueSize,
@@ -721,12 +940,27 @@ func (pa *path) setReady(desc *description.Session, allocateEncoder bool) error
 		return err
 	}
 
+	if pa.conf.TimeShiftDuration > 0 {
+		pa.stream.EnableTimeShift(time.Duration(pa.conf.TimeShiftDuration), uint64(pa.conf.TimeShiftMaxSize))
+	}
+
+	if pa.beaconHub != nil && pa.conf.GPSConfig.EmbedInStream {
+		strm := pa.stream
+		pa.beaconHub.AddReader(strm, func(pkt *beacon_stream.ReceivedPacket) {
+			strm.WriteMetadata(pkt.Data)
+		})
+	}
+
 	if pa.conf.Record {
 		pa.startRecording()
 	}
 
 	pa.readyTime = time.Now()
 
+	if pa.conf.HasHealthCheck() {
+		pa.startWatchdog()
+	}
+
 	pa.onNotReadyHook = hooks.OnReady(hooks.OnReadyParams{
 		Logger:          pa,
 		ExternalCmdPool: pa.externalCmdPool,
@@ -741,11 +975,114 @@ func (pa *path) setReady(desc *description.Session, allocateEncoder bool) error
 	return nil
 }
 
+// setPublisherReady is called when a publisher starts publishing.
+// When the slate is enabled, the publisher writes into a dedicated stream,
+// that is relayed into the stream of the path, in order to allow the slate
+// to replace the publisher without disconnecting readers.
+func (pa *path) setPublisherReady(desc *description.Session, generateRTPPackets bool) (*stream.Stream, error) {
+	if pa.conf.Slate == "" {
+		err := pa.setReady(desc, generateRTPPackets)
+		if err != nil {
+			return nil, err
+		}
+		return pa.stream, nil
+	}
+
+	if pa.slate != nil {
+		pa.slate.Close()
+		pa.slate = nil
+		pa.Log(logger.Info, "slate stopped")
+	}
+
+	strm, err := stream.New(
+		pa.writeQueueSize,
+		pa.udpMaxPayloadSize,
+		desc,
+		generateRTPPackets,
+		logger.NewLimitedLogger(pa.source),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if pa.stream != nil {
+		relay := &stream.Relay{
+			Source:      strm,
+			Destination: pa.stream,
+			Parent:      pa,
+		}
+		err = relay.Initialize()
+		if err == nil {
+			pa.publisherStream = strm
+			pa.publisherRelay = relay
+			return strm, nil
+		}
+
+		pa.Log(logger.Warn, "publisher is not compatible with the current stream (%v), recreating the stream", err)
+		pa.setNotReady()
+	}
+
+	err = pa.setReady(desc, true)
+	if err != nil {
+		strm.Close()
+		return nil, err
+	}
+
+	relay := &stream.Relay{
+		Source:      strm,
+		Destination: pa.stream,
+		Parent:      pa,
+	}
+	err = relay.Initialize()
+	if err != nil {
+		pa.setNotReady()
+		strm.Close()
+		return nil, err
+	}
+
+	pa.publisherStream = strm
+	pa.publisherRelay = relay
+	return strm, nil
+}
+
+// setPublisherNotReady is called when a publisher stops publishing.
+// When the slate is enabled, it replaces the publisher, otherwise the path is set as not ready.
+func (pa *path) setPublisherNotReady() {
+	switch {
+	// the publisher never started publishing and the slate is still running
+	case pa.slate != nil:
+
+	case pa.publisherRelay != nil:
+		pa.publisherRelay.Close()
+		pa.publisherRelay = nil
+		pa.publisherStream.Close()
+		pa.publisherStream = nil
+
+		pa.slate = &slate.Slate{
+			Source:            pa.conf.Slate,
+			WriteQueueSize:    pa.writeQueueSize,
+			UDPMaxPayloadSize: pa.udpMaxPayloadSize,
+			Stream:            pa.stream,
+			Parent:            pa,
+		}
+		err := pa.slate.Initialize()
+		if err != nil {
+			pa.Log(logger.Warn, "unable to start the slate: %v", err)
+			pa.slate = nil
+			pa.setNotReady()
+			return
+		}
+
+		pa.Log(logger.Info, "publisher is absent, slate started")
+
+	default:
+		pa.setNotReady()
+	}
+}
+
 func (pa *path) consumeOnHoldRequests() {
 	for _, req := range pa.describeRequestsOnHold {
-		req.Res <- defs.PathDescribeRes{
-			Stream: pa.stream,
-		}
+		pa.describePost(req)
 	}
 	pa.describeRequestsOnHold = nil
 
@@ -765,17 +1102,59 @@ func (pa *path) setNotReady() {
 
 	pa.onNotReadyHook()
 
+	if pa.watchdog != nil {
+		pa.stopWatchdog()
+	}
+
 	if pa.recorder != nil {
-		pa.recorder.Close()
-		pa.recorder = nil
+		pa.stopRecording()
+	}
+
+	if pa.slate != nil {
+		pa.slate.Close()
+		pa.slate = nil
+	}
+
+	if pa.publisherRelay != nil {
+		pa.publisherRelay.Close()
+		pa.publisherRelay = nil
+		pa.publisherStream.Close()
+		pa.publisherStream = nil
 	}
 
 	if pa.stream != nil {
+		if pa.beaconHub != nil {
+			pa.beaconHub.RemoveReader(pa.stream)
+		}
+
 		pa.stream.Close()
 		pa.stream = nil
 	}
 }
 
+func (pa *path) startWatchdog() {
+	pa.watchdog = &stream.Watchdog{
+		Stream:              pa.stream,
+		NoDataTimeout:       time.Duration(pa.conf.HealthNoDataTimeout),
+		MaxTimestampJump:    time.Duration(pa.conf.HealthMaxTimestampJump),
+		MaxKeyFrameInterval: time.Duration(pa.conf.HealthMaxKeyFrameInterval),
+	}
+	pa.watchdog.Initialize()
+	pa.healthCheckTimer = time.NewTimer(pathHealthCheckPeriod)
+}
+
+func (pa *path) stopWatchdog() {
+	pa.healthCheckTimer.Stop()
+	pa.healthCheckTimer = emptyTimer()
+	pa.watchdog = nil
+
+	// the stream is not unhealthy anymore, since it is not checked.
+	if pa.onHealthyHook != nil {
+		pa.onHealthyHook()
+		pa.onHealthyHook = nil
+	}
+}
+
 func (pa *path) startRecording() {
 	pa.recorder = &recorder.Recorder{
 		PathFormat:      pa.conf.RecordPath,
@@ -816,6 +1195,32 @@ func (pa *path) startRecording() {
 		Parent: pa,
 	}
 	pa.recorder.Initialize()
+
+	if pa.beaconHub != nil {
+		pa.telemetryRecorder = &recorder.TelemetryRecorder{
+			PathFormat:      pa.conf.RecordPath,
+			SegmentDuration: time.Duration(pa.conf.RecordSegmentDuration),
+			PathName:        pa.name,
+			Parent:          pa,
+		}
+		pa.telemetryRecorder.Initialize()
+
+		telemetryRecorder := pa.telemetryRecorder
+		pa.beaconHub.AddReader(telemetryRecorder, func(pkt *beacon_stream.ReceivedPacket) {
+			telemetryRecorder.Write(pkt.NTP, pkt.Data)
+		})
+	}
+}
+
+func (pa *path) stopRecording() {
+	if pa.telemetryRecorder != nil {
+		pa.beaconHub.RemoveReader(pa.telemetryRecorder)
+		pa.telemetryRecorder.Close()
+		pa.telemetryRecorder = nil
+	}
+
+	pa.recorder.Close()
+	pa.recorder = nil
 }
 
 func (pa *path) executeRemoveReader(r defs.Reader) {
@@ -824,17 +1229,52 @@ func (pa *path) executeRemoveReader(r defs.Reader) {
 
 func (pa *path) executeRemovePublisher() {
 	if pa.stream != nil {
-		pa.setNotReady()
+		pa.setPublisherNotReady()
 	}
 
 	pa.source = nil
 }
 
+// readerStream returns the stream that is handed to a reader,
+// that contains only the tracks selected with the 'tracks' query parameter.
+func (pa *path) readerStream(query string) (*stream.Stream, error) {
+	q, _ := url.ParseQuery(query)
+	tracks := q.Get("tracks")
+	if tracks == "" {
+		return pa.stream, nil
+	}
+
+	medias := stream.SelectMedias(pa.stream.Desc().Medias, strings.Split(tracks, ","), nil)
+	if len(medias) == 0 {
+		return nil, fmt.Errorf("none of the tracks of path '%s' matches '%s'", pa.name, tracks)
+	}
+
+	return pa.stream.View(medias), nil
+}
+
+func (pa *path) describePost(req defs.PathDescribeReq) {
+	strm, err := pa.readerStream(req.AccessRequest.Query)
+	if err != nil {
+		req.Res <- defs.PathDescribeRes{Err: err}
+		return
+	}
+
+	req.Res <- defs.PathDescribeRes{
+		Stream: strm,
+	}
+}
+
 func (pa *path) addReaderPost(req defs.PathAddReaderReq) {
+	strm, err := pa.readerStream(req.AccessRequest.Query)
+	if err != nil {
+		req.Res <- defs.PathAddReaderRes{Err: err}
+		return
+	}
+
 	if _, ok := pa.readers[req.Author]; ok {
 		req.Res <- defs.PathAddReaderRes{
 			Path:   pa,
-			Stream: pa.stream,
+			Stream: strm,
 		}
 		return
 	}
@@ -862,7 +1302,7 @@ func (pa *path) addReaderPost(req defs.PathAddReaderReq) {
 
 	req.Res <- defs.PathAddReaderRes{
 		Path:   pa,
-		Stream: pa.stream,
+		Stream: strm,
 	}
 }
 
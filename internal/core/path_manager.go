@@ -11,6 +11,7 @@ import (
 	"github.com/bluenviron/mediamtx/internal/defs"
 	"github.com/bluenviron/mediamtx/internal/externalcmd"
 	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/servers/beacon_stream"
 	"github.com/bluenviron/mediamtx/internal/stream"
 )
 
@@ -19,6 +20,11 @@ func pathConfCanBeUpdated(oldPathConf *conf.Path, newPathConf *conf.Path) bool {
 
 	clone.Record = newPathConf.Record
 
+	clone.HealthNoDataTimeout = newPathConf.HealthNoDataTimeout
+	clone.HealthMaxTimestampJump = newPathConf.HealthMaxTimestampJump
+	clone.HealthMaxKeyFrameInterval = newPathConf.HealthMaxKeyFrameInterval
+	clone.HealthCloseSource = newPathConf.HealthCloseSource
+
 	clone.RPICameraBrightness = newPathConf.RPICameraBrightness
 	clone.RPICameraContrast = newPathConf.RPICameraContrast
 	clone.RPICameraSaturation = newPathConf.RPICameraSaturation
@@ -39,6 +45,16 @@ func pathConfCanBeUpdated(oldPathConf *conf.Path, newPathConf *conf.Path) bool {
 	return newPathConf.Equal(clone)
 }
 
+type pathManagerBeaconHubRes struct {
+	hub *beacon_stream.Hub
+	err error
+}
+
+type pathManagerBeaconHubReq struct {
+	name string
+	res  chan pathManagerBeaconHubRes
+}
+
 type pathManagerHLSServer interface {
 	PathReady(defs.Path)
 	PathNotReady(defs.Path)
@@ -57,15 +73,17 @@ type pathManager struct {
 	writeQueueSize    int
 	udpMaxPayloadSize int
 	pathConfs         map[string]*conf.Path
+	gpsConfig         *conf.GPSConfig
 	externalCmdPool   *externalcmd.Pool
 	parent            pathManagerParent
 
-	ctx         context.Context
-	ctxCancel   func()
-	wg          sync.WaitGroup
-	hlsManager  pathManagerHLSServer
-	paths       map[string]*path
-	pathsByConf map[string]map[*path]struct{}
+	ctx             context.Context
+	ctxCancel       func()
+	wg              sync.WaitGroup
+	hlsManager      pathManagerHLSServer
+	paths           map[string]*path
+	pathsByConf     map[string]map[*path]struct{}
+	sharedBeaconHub *beacon_stream.Hub
 
 	// in
 	chReloadConf   chan map[string]*conf.Path
@@ -79,6 +97,7 @@ type pathManager struct {
 	chAddPublisher chan defs.PathAddPublisherReq
 	chAPIPathsList chan pathAPIPathsListReq
 	chAPIPathsGet  chan pathAPIPathsGetReq
+	chBeaconHub    chan pathManagerBeaconHubReq
 }
 
 func (pm *pathManager) initialize() {
@@ -99,6 +118,15 @@ func (pm *pathManager) initialize() {
 	pm.chAddPublisher = make(chan defs.PathAddPublisherReq)
 	pm.chAPIPathsList = make(chan pathAPIPathsListReq)
 	pm.chAPIPathsGet = make(chan pathAPIPathsGetReq)
+	pm.chBeaconHub = make(chan pathManagerBeaconHubReq)
+
+	if pm.gpsConfig != nil && pm.gpsConfig.IsEnabled() {
+		pm.sharedBeaconHub = &beacon_stream.Hub{
+			Conf:   *pm.gpsConfig,
+			Parent: pm,
+		}
+		pm.sharedBeaconHub.Initialize()
+	}
 
 	for _, pathConf := range pm.pathConfs {
 		if pathConf.Regexp == nil {
@@ -116,6 +144,10 @@ func (pm *pathManager) close() {
 	pm.Log(logger.Debug, "path manager is shutting down")
 	pm.ctxCancel()
 	pm.wg.Wait()
+
+	if pm.sharedBeaconHub != nil {
+		pm.sharedBeaconHub.Close()
+	}
 }
 
 // Log implements logger.Writer.
@@ -162,6 +194,9 @@ outer:
 		case req := <-pm.chAPIPathsGet:
 			pm.doAPIPathsGet(req)
 
+		case req := <-pm.chBeaconHub:
+			pm.doBeaconHub(req)
+
 		case <-pm.ctx.Done():
 			break outer
 		}
@@ -333,6 +368,21 @@ func (pm *pathManager) doAPIPathsGet(req pathAPIPathsGetReq) {
 	req.res <- pathAPIPathsGetRes{path: path}
 }
 
+func (pm *pathManager) doBeaconHub(req pathManagerBeaconHubReq) {
+	pa, ok := pm.paths[req.name]
+	if !ok {
+		req.res <- pathManagerBeaconHubRes{err: conf.ErrPathNotFound}
+		return
+	}
+
+	if pa.beaconHub == nil {
+		req.res <- pathManagerBeaconHubRes{err: fmt.Errorf("path '%s' has no GPS source", req.name)}
+		return
+	}
+
+	req.res <- pathManagerBeaconHubRes{hub: pa.beaconHub}
+}
+
 func (pm *pathManager) createPath(
 	pathConf *conf.Path,
 	name string,
@@ -353,6 +403,13 @@ func (pm *pathManager) createPath(
 		externalCmdPool:   pm.externalCmdPool,
 		parent:            pm,
 	}
+
+	// the shared hub of the deprecated global gpsConfig is applied to
+	// non-regexp paths only.
+	if pathConf.Regexp == nil {
+		pa.sharedBeaconHub = pm.sharedBeaconHub
+	}
+
 	pa.initialize()
 
 	pm.paths[name] = pa
@@ -537,3 +594,20 @@ func (pm *pathManager) APIPathsGet(name string) (*defs.APIPath, error) {
 		return nil, fmt.Errorf("terminated")
 	}
 }
+
+// BeaconHub is called by the beacon server.
+func (pm *pathManager) BeaconHub(name string) (*beacon_stream.Hub, error) {
+	req := pathManagerBeaconHubReq{
+		name: name,
+		res:  make(chan pathManagerBeaconHubRes),
+	}
+
+	select {
+	case pm.chBeaconHub <- req:
+		res := <-req.res
+		return res.hub, res.err
+
+	case <-pm.ctx.Done():
+		return nil, fmt.Errorf("terminated")
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/bluenviron/gortsplib/v4"
 	"github.com/bluenviron/gortsplib/v4/pkg/base"
 	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
 	"github.com/bluenviron/gortsplib/v4/pkg/headers"
 	"github.com/bluenviron/gortsplib/v4/pkg/sdp"
 	srt "github.com/datarhei/gosrt"
@@ -636,6 +637,53 @@ func TestPathRecord(t *testing.T) {
 	require.Equal(t, 2, len(files))
 }
 
+func TestPathHealthReload(t *testing.T) {
+	p, ok := newInstance("api: yes\n" +
+		"paths:\n" +
+		"  all_others:\n")
+	require.Equal(t, true, ok)
+	defer p.Close()
+
+	source := gortsplib.Client{}
+
+	err := source.StartRecording(
+		"rtsp://localhost:8554/mystream",
+		&description.Session{Medias: []*description.Media{test.UniqueMediaH264()}})
+	require.NoError(t, err)
+	defer source.Close()
+
+	data, err := p.pathManager.APIPathsGet("mystream")
+	require.NoError(t, err)
+	require.Nil(t, data.Health)
+
+	tr := &http.Transport{}
+	defer tr.CloseIdleConnections()
+	hc := &http.Client{Transport: tr}
+
+	httpRequest(t, hc, http.MethodPatch, "http://localhost:9997/v3/config/paths/patch/all_others", map[string]interface{}{
+		"healthNoDataTimeout": "10s",
+	}, nil)
+
+	time.Sleep(500 * time.Millisecond)
+
+	// the path is not recreated, therefore the publisher is still connected.
+	data, err = p.pathManager.APIPathsGet("mystream")
+	require.NoError(t, err)
+	require.Equal(t, true, data.Ready)
+	require.NotNil(t, data.Health)
+	require.Equal(t, true, data.Health.Healthy)
+
+	httpRequest(t, hc, http.MethodPatch, "http://localhost:9997/v3/config/paths/patch/all_others", map[string]interface{}{
+		"healthNoDataTimeout": "0s",
+	}, nil)
+
+	time.Sleep(500 * time.Millisecond)
+
+	data, err = p.pathManager.APIPathsGet("mystream")
+	require.NoError(t, err)
+	require.Nil(t, data.Health)
+}
+
 func TestPathFallback(t *testing.T) {
 	for _, ca := range []string{
 		"absolute",
@@ -849,3 +897,632 @@ func TestPathOverridePublisher(t *testing.T) {
 		})
 	}
 }
+
+func TestPathSourceFailover(t *testing.T) {
+	p, ok := newInstance("paths:\n" +
+		"  primary:\n" +
+		"  backup:\n" +
+		"  failover:\n" +
+		"    rtspTransport: tcp\n" +
+		"    sources:\n" +
+		"      - rtsp://127.0.0.1:8554/primary\n" +
+		"      - rtsp://127.0.0.1:8554/backup\n")
+	require.Equal(t, true, ok)
+	defer p.Close()
+
+	tcp := gortsplib.TransportTCP
+
+	terminate := make(chan struct{})
+	defer close(terminate)
+
+	publish := func(pathName string, marker byte) *gortsplib.Client {
+		medi := test.UniqueMediaH264()
+
+		source := &gortsplib.Client{Transport: &tcp}
+		err := source.StartRecording("rtsp://127.0.0.1:8554/"+pathName,
+			&description.Session{Medias: []*description.Media{medi}})
+		require.NoError(t, err)
+
+		go func() {
+			for i := 0; ; i++ {
+				select {
+				case <-time.After(50 * time.Millisecond):
+				case <-terminate:
+					return
+				}
+
+				source.WritePacketRTP(medi, &rtp.Packet{ //nolint:errcheck
+					Header: rtp.Header{
+						Version:        2,
+						PayloadType:    96,
+						SequenceNumber: uint16(i),
+						Timestamp:      uint32(i * 4500),
+						SSRC:           978651231,
+						Marker:         true,
+					},
+					Payload: []byte{5, marker},
+				})
+			}
+		}()
+
+		return source
+	}
+
+	backup := publish("backup", 2)
+	defer backup.Close()
+
+	u, err := base.ParseURL("rtsp://127.0.0.1:8554/failover")
+	require.NoError(t, err)
+
+	var reader *gortsplib.Client
+	var desc *description.Session
+
+	require.Eventually(t, func() bool {
+		reader = &gortsplib.Client{Transport: &tcp}
+		err = reader.Start(u.Scheme, u.Host)
+		require.NoError(t, err)
+
+		desc, _, err = reader.Describe(u)
+		if err != nil {
+			reader.Close()
+			return false
+		}
+		return true
+	}, 15*time.Second, 200*time.Millisecond)
+	defer reader.Close()
+
+	err = reader.SetupAll(desc.BaseURL, desc.Medias)
+	require.NoError(t, err)
+
+	recv := make(chan byte, 100)
+
+	forma := desc.Medias[0].Formats[0].(*format.H264)
+	dec, err := forma.CreateDecoder()
+	require.NoError(t, err)
+
+	reader.OnPacketRTP(desc.Medias[0], forma, func(pkt *rtp.Packet) {
+		au, err := dec.Decode(pkt)
+		if err != nil {
+			return
+		}
+
+		// parameters are prepended to key frames
+		select {
+		case recv <- au[len(au)-1][1]:
+		default:
+		}
+	})
+
+	_, err = reader.Play(nil)
+	require.NoError(t, err)
+
+	waitMarker := func(marker byte) {
+		timeout := time.After(15 * time.Second)
+		for {
+			select {
+			case m := <-recv:
+				if m == marker {
+					return
+				}
+			case <-timeout:
+				t.Errorf("marker %d not received", marker)
+				t.FailNow()
+			}
+		}
+	}
+
+	waitMarker(2)
+
+	// the primary source replaces the backup without disconnecting the reader
+	primary := publish("primary", 1)
+	defer primary.Close()
+
+	waitMarker(1)
+
+	data, err := p.pathManager.APIPathsGet("failover")
+	require.NoError(t, err)
+	require.Equal(t, "rtspSource", data.Source.Type)
+
+	// when the primary source is lost, the backup replaces it before the path is closed
+	primary.Close()
+
+	waitMarker(2)
+}
+
+func TestPathSlate(t *testing.T) {
+	p, ok := newInstance("paths:\n" +
+		"  live:\n" +
+		"    slate: black\n")
+	require.Equal(t, true, ok)
+	defer p.Close()
+
+	tcp := gortsplib.TransportTCP
+
+	publish := func(marker byte) (*gortsplib.Client, chan struct{}) {
+		medi := test.UniqueMediaH264()
+
+		source := &gortsplib.Client{Transport: &tcp}
+		err := source.StartRecording("rtsp://127.0.0.1:8554/live",
+			&description.Session{Medias: []*description.Media{medi}})
+		require.NoError(t, err)
+
+		terminate := make(chan struct{})
+
+		go func() {
+			for i := 0; ; i++ {
+				select {
+				case <-time.After(50 * time.Millisecond):
+				case <-terminate:
+					return
+				}
+
+				source.WritePacketRTP(medi, &rtp.Packet{ //nolint:errcheck
+					Header: rtp.Header{
+						Version:        2,
+						PayloadType:    96,
+						SequenceNumber: uint16(i),
+						Timestamp:      uint32(i * 4500),
+						SSRC:           978651231,
+						Marker:         true,
+					},
+					Payload: []byte{5, marker},
+				})
+			}
+		}()
+
+		return source, terminate
+	}
+
+	source, terminate := publish(1)
+
+	u, err := base.ParseURL("rtsp://127.0.0.1:8554/live")
+	require.NoError(t, err)
+
+	reader := &gortsplib.Client{Transport: &tcp}
+	err = reader.Start(u.Scheme, u.Host)
+	require.NoError(t, err)
+	defer reader.Close()
+
+	desc, _, err := reader.Describe(u)
+	require.NoError(t, err)
+
+	err = reader.SetupAll(desc.BaseURL, desc.Medias)
+	require.NoError(t, err)
+
+	recv := make(chan []byte, 100)
+
+	forma := desc.Medias[0].Formats[0].(*format.H264)
+	dec, err := forma.CreateDecoder()
+	require.NoError(t, err)
+
+	reader.OnPacketRTP(desc.Medias[0], forma, func(pkt *rtp.Packet) {
+		au, err := dec.Decode(pkt)
+		if err != nil {
+			return
+		}
+
+		select {
+		case recv <- au[len(au)-1]:
+		default:
+		}
+	})
+
+	_, err = reader.Play(nil)
+	require.NoError(t, err)
+
+	waitFrame := func(cond func([]byte) bool) {
+		timeout := time.After(10 * time.Second)
+		for {
+			select {
+			case nalu := <-recv:
+				if cond(nalu) {
+					return
+				}
+			case <-timeout:
+				t.Errorf("frame not received")
+				t.FailNow()
+			}
+		}
+	}
+
+	isMarker := func(marker byte) func([]byte) bool {
+		return func(nalu []byte) bool {
+			return len(nalu) == 2 && nalu[1] == marker
+		}
+	}
+
+	waitFrame(isMarker(1))
+
+	// the slate replaces the publisher without disconnecting the reader
+	close(terminate)
+	source.Close()
+
+	waitFrame(func(nalu []byte) bool {
+		return len(nalu) > 2
+	})
+
+	// the publisher comes back
+	source, terminate = publish(2)
+	defer source.Close()
+	defer close(terminate)
+
+	waitFrame(isMarker(2))
+}
+
+func TestPathSourcePath(t *testing.T) {
+	p, ok := newInstance("paths:\n" +
+		"  cam:\n" +
+		"  alias:\n" +
+		"    source: path://cam\n" +
+		"    sourceOnDemand: yes\n")
+	require.Equal(t, true, ok)
+	defer p.Close()
+
+	tcp := gortsplib.TransportTCP
+
+	medi := test.UniqueMediaH264()
+
+	source := &gortsplib.Client{Transport: &tcp}
+	err := source.StartRecording("rtsp://127.0.0.1:8554/cam",
+		&description.Session{Medias: []*description.Media{medi}})
+	require.NoError(t, err)
+	defer source.Close()
+
+	terminate := make(chan struct{})
+
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case <-time.After(50 * time.Millisecond):
+			case <-terminate:
+				return
+			}
+
+			source.WritePacketRTP(medi, &rtp.Packet{ //nolint:errcheck
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    96,
+					SequenceNumber: uint16(i),
+					Timestamp:      uint32(i * 4500),
+					SSRC:           978651231,
+					Marker:         true,
+				},
+				Payload: []byte{5, 1},
+			})
+		}
+	}()
+
+	u, err := base.ParseURL("rtsp://127.0.0.1:8554/alias")
+	require.NoError(t, err)
+
+	reader := &gortsplib.Client{Transport: &tcp}
+	err = reader.Start(u.Scheme, u.Host)
+	require.NoError(t, err)
+	defer reader.Close()
+
+	desc, _, err := reader.Describe(u)
+	require.NoError(t, err)
+
+	err = reader.SetupAll(desc.BaseURL, desc.Medias)
+	require.NoError(t, err)
+
+	recv := make(chan struct{})
+
+	forma := desc.Medias[0].Formats[0].(*format.H264)
+	dec, err := forma.CreateDecoder()
+	require.NoError(t, err)
+
+	reader.OnPacketRTP(desc.Medias[0], forma, func(pkt *rtp.Packet) {
+		au, err := dec.Decode(pkt)
+		if err != nil {
+			return
+		}
+
+		if au[len(au)-1][1] == 1 {
+			select {
+			case recv <- struct{}{}:
+			default:
+			}
+		}
+	})
+
+	_, err = reader.Play(nil)
+	require.NoError(t, err)
+
+	<-recv
+
+	// readers of the alias are disconnected when the stream of the other path is not available anymore
+	close(terminate)
+	source.Close()
+
+	err = reader.Wait()
+	require.Error(t, err)
+}
+
+func TestPathTracks(t *testing.T) {
+	p, ok := newInstance("paths:\n" +
+		"  cam:\n" +
+		"    excludeTracks: [3]\n")
+	require.Equal(t, true, ok)
+	defer p.Close()
+
+	tcp := gortsplib.TransportTCP
+
+	medias := []*description.Media{
+		test.UniqueMediaH264(),
+		test.UniqueMediaMPEG4Audio(),
+		test.UniqueMediaH264(),
+	}
+
+	source := &gortsplib.Client{Transport: &tcp}
+	err := source.StartRecording("rtsp://127.0.0.1:8554/cam",
+		&description.Session{Medias: medias})
+	require.NoError(t, err)
+	defer source.Close()
+
+	terminate := make(chan struct{})
+	defer close(terminate)
+
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case <-time.After(50 * time.Millisecond):
+			case <-terminate:
+				return
+			}
+
+			source.WritePacketRTP(medias[1], &rtp.Packet{ //nolint:errcheck
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    96,
+					SequenceNumber: uint16(i),
+					Timestamp:      uint32(i * 2205),
+					SSRC:           978651231,
+					Marker:         true,
+				},
+				Payload: []byte{1, 2, 3, 4},
+			})
+		}
+	}()
+
+	for _, ca := range []struct {
+		name  string
+		query string
+		types []description.MediaType
+	}{
+		{
+			"path",
+			"",
+			[]description.MediaType{description.MediaTypeVideo, description.MediaTypeAudio},
+		},
+		{
+			"reader",
+			"?tracks=audio",
+			[]description.MediaType{description.MediaTypeAudio},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			u, err := base.ParseURL("rtsp://127.0.0.1:8554/cam" + ca.query)
+			require.NoError(t, err)
+
+			reader := &gortsplib.Client{Transport: &tcp}
+			err = reader.Start(u.Scheme, u.Host)
+			require.NoError(t, err)
+			defer reader.Close()
+
+			desc, _, err := reader.Describe(u)
+			require.NoError(t, err)
+
+			var types []description.MediaType
+			for _, medi := range desc.Medias {
+				types = append(types, medi.Type)
+			}
+			require.Equal(t, ca.types, types)
+
+			err = reader.SetupAll(desc.BaseURL, desc.Medias)
+			require.NoError(t, err)
+
+			recv := make(chan struct{})
+
+			reader.OnPacketRTPAny(func(medi *description.Media, _ format.Format, _ *rtp.Packet) {
+				if medi.Type == description.MediaTypeAudio {
+					select {
+					case recv <- struct{}{}:
+					default:
+					}
+				}
+			})
+
+			_, err = reader.Play(nil)
+			require.NoError(t, err)
+
+			<-recv
+		})
+	}
+
+	u, err := base.ParseURL("rtsp://127.0.0.1:8554/cam?tracks=application")
+	require.NoError(t, err)
+
+	reader := &gortsplib.Client{Transport: &tcp}
+	err = reader.Start(u.Scheme, u.Host)
+	require.NoError(t, err)
+	defer reader.Close()
+
+	_, _, err = reader.Describe(u)
+	require.Error(t, err)
+}
+
+func TestPathTimeShift(t *testing.T) {
+	p, ok := newInstance("paths:\n" +
+		"  cam:\n" +
+		"    timeShiftDuration: 1m\n")
+	require.Equal(t, true, ok)
+	defer p.Close()
+
+	tcp := gortsplib.TransportTCP
+
+	medi := test.UniqueMediaH264()
+
+	source := &gortsplib.Client{Transport: &tcp}
+	err := source.StartRecording("rtsp://127.0.0.1:8554/cam",
+		&description.Session{Medias: []*description.Media{medi}})
+	require.NoError(t, err)
+	defer source.Close()
+
+	written := make(chan struct{})
+	terminate := make(chan struct{})
+	defer close(terminate)
+
+	go func() {
+		for i := 0; ; i++ {
+			source.WritePacketRTP(medi, &rtp.Packet{ //nolint:errcheck
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    96,
+					SequenceNumber: uint16(i),
+					Timestamp:      uint32(i * 4500),
+					SSRC:           978651231,
+					Marker:         true,
+				},
+				Payload: []byte{5, byte(i)},
+			})
+
+			if i == 5 {
+				close(written)
+			}
+
+			select {
+			case <-time.After(50 * time.Millisecond):
+			case <-terminate:
+				return
+			}
+		}
+	}()
+
+	<-written
+
+	for _, ca := range []string{"query", "range in setup", "range in play", "live"} {
+		t.Run(ca, func(t *testing.T) {
+			ur := "rtsp://127.0.0.1:8554/cam"
+			if ca == "query" {
+				ur += "?offset=-1h"
+			}
+
+			u, err := base.ParseURL(ur)
+			require.NoError(t, err)
+
+			ra := &headers.Range{
+				Value: &headers.RangeUTC{
+					Start: time.Now().Add(-time.Hour),
+				},
+			}
+
+			reader := &gortsplib.Client{Transport: &tcp}
+
+			if ca == "range in setup" {
+				reader.OnRequest = func(req *base.Request) {
+					if req.Method == base.Setup {
+						req.Header["Range"] = ra.Marshal()
+					}
+				}
+			}
+
+			err = reader.Start(u.Scheme, u.Host)
+			require.NoError(t, err)
+			defer reader.Close()
+
+			desc, _, err := reader.Describe(u)
+			require.NoError(t, err)
+
+			err = reader.SetupAll(desc.BaseURL, desc.Medias)
+			require.NoError(t, err)
+
+			forma := desc.Medias[0].Formats[0].(*format.H264)
+			dec, err := forma.CreateDecoder()
+			require.NoError(t, err)
+
+			recv := make(chan byte, 100)
+
+			reader.OnPacketRTP(desc.Medias[0], forma, func(pkt *rtp.Packet) {
+				au, err := dec.Decode(pkt)
+				if err != nil {
+					return
+				}
+
+				select {
+				case recv <- au[len(au)-1][1]:
+				default:
+				}
+			})
+
+			if ca == "range in play" {
+				_, err = reader.Play(ra)
+			} else {
+				_, err = reader.Play(nil)
+			}
+			require.NoError(t, err)
+
+			if ca == "live" {
+				// the reader starts from the live position
+				require.Greater(t, <-recv, byte(5))
+				return
+			}
+
+			// the reader starts from the beginning of the time-shift buffer
+			require.Equal(t, byte(0), <-recv)
+			require.Equal(t, byte(1), <-recv)
+		})
+	}
+}
+
+func TestPathDeprecatedGPSConfig(t *testing.T) {
+	p, ok := newInstance("gpsConfig:\n" +
+		"  protocol: udp\n" +
+		"  ipAddress: 127.0.0.1\n" +
+		"  port: 13370\n" +
+		"paths:\n" +
+		"  cam:\n" +
+		"  cam2:\n" +
+		"  cam3:\n" +
+		"    gpsConfig:\n" +
+		"      protocol: publisher\n" +
+		"      writeQueueSize: 64\n" +
+		"  all_others:\n")
+	require.Equal(t, true, ok)
+	defer p.Close()
+
+	hub1, err := p.pathManager.BeaconHub("cam")
+	require.NoError(t, err)
+
+	hub2, err := p.pathManager.BeaconHub("cam2")
+	require.NoError(t, err)
+	require.Same(t, hub1, hub2)
+
+	hub3, err := p.pathManager.BeaconHub("cam3")
+	require.NoError(t, err)
+	require.NotSame(t, hub1, hub3)
+}
+
+func TestPathGPSTCPListener(t *testing.T) {
+	for _, ca := range []string{"udp", "publisher"} {
+		t.Run(ca, func(t *testing.T) {
+			p, ok := newInstance("paths:\n" +
+				"  cam:\n" +
+				"    gpsConfig:\n" +
+				"      protocol: " + ca + "\n" +
+				"      ipAddress: 127.0.0.1\n" +
+				"      port: 13370\n" +
+				"      writeQueueSize: 64\n")
+			require.Equal(t, true, ok)
+			defer p.Close()
+
+			conn, err := net.Dial("tcp", "localhost:13371")
+			if ca == "publisher" {
+				require.NoError(t, err)
+				conn.Close()
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,386 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/stream"
+)
+
+const (
+	// time given to sources to replace the active one before the path is set as not ready.
+	staticSourceFailoverWaitTimeout = 10 * time.Second
+)
+
+type staticSourceFailoverChildSetReadyReq struct {
+	child *staticSourceFailoverChild
+	req   defs.PathSourceStaticSetReadyReq
+}
+
+type staticSourceFailoverChildSetNotReadyReq struct {
+	child *staticSourceFailoverChild
+	req   defs.PathSourceStaticSetNotReadyReq
+}
+
+type staticSourceFailoverChildError struct {
+	child *staticSourceFailoverChild
+	err   error
+}
+
+// staticSourceFailoverChild is one of the sources of a staticSourceFailover.
+type staticSourceFailoverChild struct {
+	index    int
+	source   string
+	instance defs.StaticSource
+	parent   *staticSourceFailover
+
+	ctx        context.Context
+	ctxCancel  func()
+	reloadConf chan *conf.Path
+	stream     *stream.Stream
+
+	// out
+	done chan struct{}
+}
+
+func (c *staticSourceFailoverChild) start(parentCtx context.Context, cnf *conf.Path) {
+	c.ctx, c.ctxCancel = context.WithCancel(parentCtx)
+	c.reloadConf = make(chan *conf.Path)
+	c.done = make(chan struct{})
+
+	go c.run(c.parent.ResolveSource(c.source), cnf)
+}
+
+func (c *staticSourceFailoverChild) stop() {
+	c.ctxCancel()
+	<-c.done
+}
+
+func (c *staticSourceFailoverChild) run(resolvedSource string, cnf *conf.Path) {
+	defer close(c.done)
+
+	for {
+		err := c.instance.Run(defs.StaticSourceRunParams{
+			Context:        c.ctx,
+			ResolvedSource: resolvedSource,
+			Conf:           cnf,
+			ReloadConf:     c.reloadConf,
+		})
+
+		select {
+		case c.parent.chChildError <- staticSourceFailoverChildError{child: c, err: err}:
+		case <-c.ctx.Done():
+			return
+		}
+
+		select {
+		case <-time.After(staticSourceHandlerRetryPause):
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+// Log implements logger.Writer.
+func (c *staticSourceFailoverChild) Log(level logger.Level, format string, args ...interface{}) {
+	c.parent.Parent.Log(level, fmt.Sprintf("[source %d] ", c.index+1)+format, args...)
+}
+
+// SetReady implements defs.StaticSourceParent.
+func (c *staticSourceFailoverChild) SetReady(req defs.PathSourceStaticSetReadyReq) defs.PathSourceStaticSetReadyRes {
+	req.Res = make(chan defs.PathSourceStaticSetReadyRes)
+	select {
+	case c.parent.chChildSetReady <- staticSourceFailoverChildSetReadyReq{child: c, req: req}:
+		return <-req.Res
+	case <-c.ctx.Done():
+		return defs.PathSourceStaticSetReadyRes{Err: fmt.Errorf("terminated")}
+	}
+}
+
+// SetNotReady implements defs.StaticSourceParent.
+func (c *staticSourceFailoverChild) SetNotReady(req defs.PathSourceStaticSetNotReadyReq) {
+	req.Res = make(chan struct{})
+	select {
+	case c.parent.chChildSetNotReady <- staticSourceFailoverChildSetNotReadyReq{child: c, req: req}:
+		<-req.Res
+	case <-c.ctx.Done():
+	}
+}
+
+// staticSourceFailover is a static source that reads from a list of redundant sources.
+// Sources are started in order of priority: when the current source fails, the next one is started;
+// when a source with higher priority becomes ready again, it replaces the current one.
+// Each source writes into its own stream, whose units are relayed into the stream of the path,
+// therefore readers are not disconnected when the current source changes.
+type staticSourceFailover struct {
+	Sources           []string
+	WriteQueueSize    int
+	UDPMaxPayloadSize int
+	NewInstance       func(string, defs.StaticSourceParent) defs.StaticSource
+	ResolveSource     func(string) string
+	Parent            defs.StaticSourceParent
+
+	children    []*staticSourceFailoverChild
+	activeMutex sync.RWMutex
+	active      *staticSourceFailoverChild
+
+	// in
+	chChildSetReady    chan staticSourceFailoverChildSetReadyReq
+	chChildSetNotReady chan staticSourceFailoverChildSetNotReadyReq
+	chChildError       chan staticSourceFailoverChildError
+}
+
+func (s *staticSourceFailover) initialize() {
+	s.chChildSetReady = make(chan staticSourceFailoverChildSetReadyReq)
+	s.chChildSetNotReady = make(chan staticSourceFailoverChildSetNotReadyReq)
+	s.chChildError = make(chan staticSourceFailoverChildError)
+
+	s.children = make([]*staticSourceFailoverChild, len(s.Sources))
+
+	for i, source := range s.Sources {
+		c := &staticSourceFailoverChild{
+			index:  i,
+			source: source,
+			parent: s,
+		}
+		c.instance = s.NewInstance(source, c)
+		s.children[i] = c
+	}
+}
+
+// Log implements logger.Writer.
+func (s *staticSourceFailover) Log(level logger.Level, format string, args ...interface{}) {
+	s.Parent.Log(level, "[failover] "+format, args...)
+}
+
+func (s *staticSourceFailover) getActive() *staticSourceFailoverChild {
+	s.activeMutex.RLock()
+	defer s.activeMutex.RUnlock()
+	return s.active
+}
+
+func (s *staticSourceFailover) setActive(c *staticSourceFailoverChild) {
+	s.activeMutex.Lock()
+	defer s.activeMutex.Unlock()
+	s.active = c
+}
+
+// Run implements StaticSource.
+func (s *staticSourceFailover) Run(params defs.StaticSourceRunParams) error {
+	cnf := params.Conf
+	var strm *stream.Stream
+	var relay *stream.Relay
+	started := 0
+	waitTimer := emptyTimer()
+
+	startNext := func() {
+		c := s.children[started]
+		started++
+		c.start(params.Context, cnf)
+	}
+
+	deactivate := func() {
+		if relay != nil {
+			relay.Close()
+			relay = nil
+		}
+		s.setActive(nil)
+	}
+
+	releaseChild := func(c *staticSourceFailoverChild) {
+		if s.getActive() == c {
+			deactivate()
+		}
+		if c.stream != nil {
+			c.stream.Close()
+			c.stream = nil
+		}
+	}
+
+	// stop children with index greater or equal than n
+	stopFrom := func(n int) {
+		for started > n {
+			started--
+			c := s.children[started]
+			c.stop()
+			releaseChild(c)
+		}
+	}
+
+	setNotReady := func() {
+		waitTimer.Stop()
+		waitTimer = emptyTimer()
+
+		if strm != nil {
+			s.Parent.SetNotReady(defs.PathSourceStaticSetNotReadyReq{})
+			strm = nil
+		}
+	}
+
+	// when the active source is lost, the stream is kept in order not to disconnect readers,
+	// and the other sources are given some time to replace it.
+	waitForSource := func() {
+		if strm != nil {
+			s.Log(logger.Warn, "no source is active, waiting for another one")
+			waitTimer.Stop()
+			waitTimer = time.NewTimer(staticSourceFailoverWaitTimeout)
+		}
+	}
+
+	switchTo := func(c *staticSourceFailoverChild) error {
+		deactivate()
+		stopFrom(c.index + 1)
+
+		if strm != nil {
+			relay = &stream.Relay{
+				Source:      c.stream,
+				Destination: strm,
+				Parent:      c,
+			}
+			err := relay.Initialize()
+			if err == nil {
+				waitTimer.Stop()
+				waitTimer = emptyTimer()
+				s.setActive(c)
+				s.Log(logger.Info, "switched to source %d", c.index+1)
+				return nil
+			}
+
+			relay = nil
+			c.Log(logger.Warn, "source is not compatible with the current stream (%v), recreating the stream", err)
+			setNotReady()
+		}
+
+		// the source is set as active before the path becomes ready,
+		// in order to be described by hooks.
+		s.setActive(c)
+
+		res := s.Parent.SetReady(defs.PathSourceStaticSetReadyReq{
+			Desc:               c.stream.Desc(),
+			GenerateRTPPackets: true,
+		})
+		if res.Err != nil {
+			s.setActive(nil)
+			return res.Err
+		}
+
+		strm = res.Stream
+
+		relay = &stream.Relay{
+			Source:      c.stream,
+			Destination: strm,
+			Parent:      c,
+		}
+		err := relay.Initialize()
+		if err != nil {
+			return err
+		}
+
+		s.Log(logger.Info, "using source %d", c.index+1)
+		return nil
+	}
+
+	startNext()
+
+	for {
+		select {
+		case req := <-s.chChildSetReady:
+			c := req.child
+
+			var err error
+			c.stream, err = stream.New(
+				s.WriteQueueSize,
+				s.UDPMaxPayloadSize,
+				req.req.Desc,
+				req.req.GenerateRTPPackets,
+				logger.NewLimitedLogger(c),
+			)
+			if err != nil {
+				req.req.Res <- defs.PathSourceStaticSetReadyRes{Err: err}
+				continue
+			}
+
+			req.req.Res <- defs.PathSourceStaticSetReadyRes{Stream: c.stream}
+
+			if active := s.getActive(); active == nil || c.index < active.index {
+				err = switchTo(c)
+				if err != nil {
+					stopFrom(0)
+					setNotReady()
+					return err
+				}
+			}
+
+		case req := <-s.chChildSetNotReady:
+			wasActive := s.getActive() == req.child
+			if wasActive {
+				req.child.Log(logger.Warn, "source is not ready anymore")
+			}
+			releaseChild(req.child)
+			close(req.req.Res)
+
+			if wasActive {
+				waitForSource()
+			}
+
+		case e := <-s.chChildError:
+			c := e.child
+			c.Log(logger.Error, e.err.Error())
+			wasActive := s.getActive() == c
+			releaseChild(c)
+
+			switch {
+			// the last started source failed: start the next one
+			case c.index == (started-1) && started < len(s.children):
+				s.Log(logger.Info, "source %d failed, trying source %d", c.index+1, c.index+2)
+				startNext()
+
+			// all sources are failing
+			case s.getActive() == nil && started == len(s.children):
+				setNotReady()
+			}
+
+			if wasActive {
+				waitForSource()
+			}
+
+		case <-waitTimer.C:
+			s.Log(logger.Warn, "no source became active in time")
+			setNotReady()
+
+		case newConf := <-params.ReloadConf:
+			cnf = newConf
+			for _, c := range s.children[:started] {
+				cc := c
+				go func() {
+					select {
+					case cc.reloadConf <- newConf:
+					case <-cc.ctx.Done():
+					}
+				}()
+			}
+
+		case <-params.Context.Done():
+			stopFrom(0)
+			setNotReady()
+			return fmt.Errorf("terminated")
+		}
+	}
+}
+
+// APISourceDescribe implements StaticSource.
+// It describes the active source, if any.
+func (s *staticSourceFailover) APISourceDescribe() defs.APIPathSourceOrReader {
+	if active := s.getActive(); active != nil {
+		return active.instance.APISourceDescribe()
+	}
+	return defs.APIPathSourceOrReader{
+		Type: "none",
+		ID:   "",
+	}
+}
@@ -11,12 +11,14 @@ import (
 	"github.com/bluenviron/mediamtx/internal/defs"
 	"github.com/bluenviron/mediamtx/internal/logger"
 	hlssource "github.com/bluenviron/mediamtx/internal/staticsources/hls"
+	pathsource "github.com/bluenviron/mediamtx/internal/staticsources/path"
 	rpicamerasource "github.com/bluenviron/mediamtx/internal/staticsources/rpicamera"
 	rtmpsource "github.com/bluenviron/mediamtx/internal/staticsources/rtmp"
 	rtspsource "github.com/bluenviron/mediamtx/internal/staticsources/rtsp"
 	srtsource "github.com/bluenviron/mediamtx/internal/staticsources/srt"
 	udpsource "github.com/bluenviron/mediamtx/internal/staticsources/udp"
 	webrtcsource "github.com/bluenviron/mediamtx/internal/staticsources/webrtc"
+	"github.com/bluenviron/mediamtx/internal/stream"
 )
 
 const (
@@ -35,6 +37,10 @@ func resolveSource(s string, matches []string, query string) string {
 	return s
 }
 
+type staticSourceHandlerPathManager interface {
+	AddReader(req defs.PathAddReaderReq) (defs.Path, *stream.Stream, error)
+}
+
 type staticSourceHandlerParent interface {
 	logger.Writer
 	staticSourceHandlerSetReady(context.Context, defs.PathSourceStaticSetReadyReq)
@@ -43,22 +49,26 @@ type staticSourceHandlerParent interface {
 
 // staticSourceHandler is a static source handler.
 type staticSourceHandler struct {
-	conf           *conf.Path
-	logLevel       conf.LogLevel
-	readTimeout    conf.StringDuration
-	writeTimeout   conf.StringDuration
-	writeQueueSize int
-	matches        []string
-	parent         staticSourceHandlerParent
+	conf              *conf.Path
+	logLevel          conf.LogLevel
+	readTimeout       conf.StringDuration
+	writeTimeout      conf.StringDuration
+	writeQueueSize    int
+	udpMaxPayloadSize int
+	matches           []string
+	pathManager       staticSourceHandlerPathManager
+	parent            staticSourceHandlerParent
 
 	ctx       context.Context
 	ctxCancel func()
+	source    string
 	instance  defs.StaticSource
 	running   bool
 	query     string
 
 	// in
 	chReloadConf          chan *conf.Path
+	chRestart             chan string
 	chInstanceSetReady    chan defs.PathSourceStaticSetReadyReq
 	chInstanceSetNotReady chan defs.PathSourceStaticSetNotReadyReq
 
@@ -68,57 +78,90 @@ type staticSourceHandler struct {
 
 func (s *staticSourceHandler) initialize() {
 	s.chReloadConf = make(chan *conf.Path)
+	s.chRestart = make(chan string)
 	s.chInstanceSetReady = make(chan defs.PathSourceStaticSetReadyReq)
 	s.chInstanceSetNotReady = make(chan defs.PathSourceStaticSetNotReadyReq)
 
+	// when 'sources' is provided, it replaces 'source'.
+	switch len(s.conf.Sources) {
+	case 0:
+		s.source = s.conf.Source
+	case 1:
+		s.source = s.conf.Sources[0]
+	}
+
+	if len(s.conf.Sources) > 1 {
+		s.instance = &staticSourceFailover{
+			Sources:           s.conf.Sources,
+			WriteQueueSize:    s.writeQueueSize,
+			UDPMaxPayloadSize: s.udpMaxPayloadSize,
+			NewInstance:       s.newInstance,
+			ResolveSource: func(source string) string {
+				return resolveSource(source, s.matches, s.query)
+			},
+			Parent: s,
+		}
+		s.instance.(*staticSourceFailover).initialize()
+	} else {
+		s.instance = s.newInstance(s.source, s)
+	}
+}
+
+func (s *staticSourceHandler) newInstance(source string, parent defs.StaticSourceParent) defs.StaticSource {
 	switch {
-	case strings.HasPrefix(s.conf.Source, "rtsp://") ||
-		strings.HasPrefix(s.conf.Source, "rtsps://"):
-		s.instance = &rtspsource.Source{
+	case strings.HasPrefix(source, "rtsp://") ||
+		strings.HasPrefix(source, "rtsps://"):
+		return &rtspsource.Source{
 			ReadTimeout:    s.readTimeout,
 			WriteTimeout:   s.writeTimeout,
 			WriteQueueSize: s.writeQueueSize,
-			Parent:         s,
+			Parent:         parent,
 		}
 
-	case strings.HasPrefix(s.conf.Source, "rtmp://") ||
-		strings.HasPrefix(s.conf.Source, "rtmps://"):
-		s.instance = &rtmpsource.Source{
+	case strings.HasPrefix(source, "rtmp://") ||
+		strings.HasPrefix(source, "rtmps://"):
+		return &rtmpsource.Source{
 			ReadTimeout:  s.readTimeout,
 			WriteTimeout: s.writeTimeout,
-			Parent:       s,
+			Parent:       parent,
 		}
 
-	case strings.HasPrefix(s.conf.Source, "http://") ||
-		strings.HasPrefix(s.conf.Source, "https://"):
-		s.instance = &hlssource.Source{
+	case strings.HasPrefix(source, "http://") ||
+		strings.HasPrefix(source, "https://"):
+		return &hlssource.Source{
 			ReadTimeout: s.readTimeout,
-			Parent:      s,
+			Parent:      parent,
 		}
 
-	case strings.HasPrefix(s.conf.Source, "udp://"):
-		s.instance = &udpsource.Source{
+	case strings.HasPrefix(source, "udp://"):
+		return &udpsource.Source{
 			ReadTimeout: s.readTimeout,
-			Parent:      s,
+			Parent:      parent,
 		}
 
-	case strings.HasPrefix(s.conf.Source, "srt://"):
-		s.instance = &srtsource.Source{
+	case strings.HasPrefix(source, "srt://"):
+		return &srtsource.Source{
 			ReadTimeout: s.readTimeout,
-			Parent:      s,
+			Parent:      parent,
 		}
 
-	case strings.HasPrefix(s.conf.Source, "whep://") ||
-		strings.HasPrefix(s.conf.Source, "wheps://"):
-		s.instance = &webrtcsource.Source{
+	case strings.HasPrefix(source, "whep://") ||
+		strings.HasPrefix(source, "wheps://"):
+		return &webrtcsource.Source{
 			ReadTimeout: s.readTimeout,
-			Parent:      s,
+			Parent:      parent,
+		}
+
+	case strings.HasPrefix(source, "path://"):
+		return &pathsource.Source{
+			PathManager: s.pathManager,
+			Parent:      parent,
 		}
 
-	case s.conf.Source == "rpiCamera":
-		s.instance = &rpicamerasource.Source{
+	case source == "rpiCamera":
+		return &rpicamerasource.Source{
 			LogLevel: s.logLevel,
-			Parent:   s,
+			Parent:   parent,
 		}
 
 	default:
@@ -180,7 +223,7 @@ func (s *staticSourceHandler) run() {
 	runReloadConf := make(chan *conf.Path)
 
 	recreate := func() {
-		resolvedSource := resolveSource(s.conf.Source, s.matches, s.query)
+		resolvedSource := resolveSource(s.source, s.matches, s.query)
 
 		runCtx, runCtxCancel = context.WithCancel(context.Background())
 		go func() {
@@ -225,6 +268,13 @@ func (s *staticSourceHandler) run() {
 				}()
 			}
 
+		case reason := <-s.chRestart:
+			if !recreating {
+				s.instance.Log(logger.Info, "restarting: %s", reason)
+				// the error is handled like any other error
+				runCtxCancel()
+			}
+
 		case <-recreateTimer.C:
 			recreate()
 			recreating = false
@@ -254,6 +304,22 @@ func (s *staticSourceHandler) reloadConf(newConf *conf.Path) {
 	}()
 }
 
+// restart closes and recreates the source.
+func (s *staticSourceHandler) restart(reason string) {
+	ctx := s.ctx
+
+	if !s.running {
+		return
+	}
+
+	go func() {
+		select {
+		case s.chRestart <- reason:
+		case <-ctx.Done():
+		}
+	}()
+}
+
 // APISourceDescribe instanceements source.
 func (s *staticSourceHandler) APISourceDescribe() defs.APIPathSourceOrReader {
 	return s.instance.APISourceDescribe()
@@ -26,17 +26,81 @@ type APIPathSourceOrReader struct {
 	ID   string `json:"id"`
 }
 
+// APIPathReaderStats are statistics of a reader.
+type APIPathReaderStats struct {
+	UnitsDelivered uint64              `json:"unitsDelivered"`
+	UnitsDropped   uint64              `json:"unitsDropped"`
+	QueueDepth     int                 `json:"queueDepth"`
+	MaxLag         conf.StringDuration `json:"maxLag"`
+}
+
+// APIPathReader is a reader of a path.
+type APIPathReader struct {
+	Type  string              `json:"type"`
+	ID    string              `json:"id"`
+	Stats *APIPathReaderStats `json:"stats"` // nil with RTSP readers
+}
+
+// APIPathTrackVideo contains statistics of a video track.
+type APIPathTrackVideo struct {
+	FrameRate        float64             `json:"frameRate"`
+	GOPLength        int                 `json:"gopLength"`
+	KeyFrameInterval conf.StringDuration `json:"keyFrameInterval"`
+	LastKeyFrameTime *time.Time          `json:"lastKeyFrameTime"`
+	Width            int                 `json:"width"`
+	Height           int                 `json:"height"`
+	Profile          string              `json:"profile"`
+}
+
+// APIPathTrackAudio contains statistics of an audio track.
+type APIPathTrackAudio struct {
+	SampleRate   int `json:"sampleRate"`
+	ChannelCount int `json:"channelCount"`
+}
+
+// APIPathTrackStats are statistics of a track.
+type APIPathTrackStats struct {
+	Codec   string             `json:"codec"`
+	Bitrate float64            `json:"bitrate"`
+	Video   *APIPathTrackVideo `json:"video"`
+	Audio   *APIPathTrackAudio `json:"audio"`
+}
+
+// APIPathHealth is the health state of a path.
+type APIPathHealth struct {
+	Healthy bool      `json:"healthy"`
+	Reason  string    `json:"reason"`
+	Since   time.Time `json:"since"`
+}
+
+// APIPathGPSGeofence is a geofence of the GPS source of a path.
+type APIPathGPSGeofence struct {
+	Name   string   `json:"name"`
+	Inside []string `json:"inside"`
+}
+
+// APIPathGPS is the GPS source of a path.
+type APIPathGPS struct {
+	UpstreamConnected bool                  `json:"upstreamConnected"`
+	PacketsReceived   uint64                `json:"packetsReceived"`
+	PacketsDropped    uint64                `json:"packetsDropped"`
+	Geofences         []*APIPathGPSGeofence `json:"geofences"`
+}
+
 // APIPath is a path.
 type APIPath struct {
-	Name          string                  `json:"name"`
-	ConfName      string                  `json:"confName"`
-	Source        *APIPathSourceOrReader  `json:"source"`
-	Ready         bool                    `json:"ready"`
-	ReadyTime     *time.Time              `json:"readyTime"`
-	Tracks        []string                `json:"tracks"`
-	BytesReceived uint64                  `json:"bytesReceived"`
-	BytesSent     uint64                  `json:"bytesSent"`
-	Readers       []APIPathSourceOrReader `json:"readers"`
+	Name          string                 `json:"name"`
+	ConfName      string                 `json:"confName"`
+	Source        *APIPathSourceOrReader `json:"source"`
+	Ready         bool                   `json:"ready"`
+	ReadyTime     *time.Time             `json:"readyTime"`
+	Tracks        []string               `json:"tracks"`
+	TrackStats    []APIPathTrackStats    `json:"trackStats"`
+	BytesReceived uint64                 `json:"bytesReceived"`
+	BytesSent     uint64                 `json:"bytesSent"`
+	Readers       []APIPathReader        `json:"readers"`
+	Health        *APIPathHealth         `json:"health"`
+	GPS           *APIPathGPS            `json:"gps"`
 }
 
 // APIPathList is a list of paths.
@@ -315,6 +379,26 @@ type APIWebRTCSessionList struct {
 	Items     []*APIWebRTCSession `json:"items"`
 }
 
+// APIBeaconSession is a GPS session.
+type APIBeaconSession struct {
+	ID               uuid.UUID `json:"id"`
+	Created          time.Time `json:"created"`
+	RemoteAddr       string    `json:"remoteAddr"`
+	Path             string    `json:"path"`
+	User             string    `json:"user"`
+	DataChannelState string    `json:"dataChannelState"`
+	MessagesSent     uint64    `json:"messagesSent"`
+	MessagesDropped  uint64    `json:"messagesDropped"`
+	BytesSent        uint64    `json:"bytesSent"`
+}
+
+// APIBeaconSessionList is a list of GPS sessions.
+type APIBeaconSessionList struct {
+	ItemCount int                 `json:"itemCount"`
+	PageCount int                 `json:"pageCount"`
+	Items     []*APIBeaconSession `json:"items"`
+}
+
 // APIRecordingSegment is a recording segment.
 type APIRecordingSegment struct {
 	Start time.Time `json:"start"`
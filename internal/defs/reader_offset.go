@@ -0,0 +1,29 @@
+package defs
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// ReaderOffset returns the position of the stream from which a reader wants to start,
+// relative to the live stream, set with the 'offset' query parameter (i.e. offset=-30s).
+func ReaderOffset(query string) (time.Duration, error) {
+	q, _ := url.ParseQuery(query)
+
+	v := q.Get("offset")
+	if v == "" {
+		return 0, nil
+	}
+
+	offset, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid offset: %w", err)
+	}
+
+	if offset > 0 {
+		return 0, fmt.Errorf("invalid offset: it must be negative")
+	}
+
+	return offset, nil
+}
@@ -107,6 +107,11 @@ type formatProcessorH265 struct {
 	encoder           *rtph265.Encoder
 	decoder           *rtph265.Decoder
 	randomStart       uint32
+	sei               seiQueue
+	// offset of sequence numbers of routed RTP packets, caused by SEI packets.
+	seiSeqOffset uint16
+	// whether the last routed RTP packet was not the last one of an access unit.
+	rtpInsideAU bool
 }
 
 func newH265(
@@ -261,6 +266,63 @@ func (t *formatProcessorH265) remuxAccessUnit(au [][]byte) [][]byte {
 	return filteredNALUs
 }
 
+// insertPendingSEI inserts pending SEI NAL units after parameters.
+func (t *formatProcessorH265) insertPendingSEI(au [][]byte) [][]byte {
+	n := 0
+	for n < len(au) {
+		typ := h265.NALUType((au[n][0] >> 1) & 0b111111)
+		if typ != h265.NALUType_VPS_NUT && typ != h265.NALUType_SPS_NUT && typ != h265.NALUType_PPS_NUT {
+			break
+		}
+		n++
+	}
+
+	return t.sei.insert(au, n)
+}
+
+// pendingSEIPackets encodes pending SEI NAL units into RTP packets that precede the given one,
+// and shifts sequence numbers of the given packet and of the following ones.
+func (t *formatProcessorH265) pendingSEIPackets(pkt *rtp.Packet) ([]*rtp.Packet, error) {
+	nalus := t.sei.drain()
+	if len(nalus) == 0 {
+		return nil, nil
+	}
+
+	ssrc := pkt.SSRC
+	seqNum := pkt.SequenceNumber
+	enc := &rtph265.Encoder{
+		PayloadMaxSize:        t.udpMaxPayloadSize - 12,
+		PayloadType:           pkt.PayloadType,
+		SSRC:                  &ssrc,
+		InitialSequenceNumber: &seqNum,
+		MaxDONDiff:            t.format.MaxDONDiff,
+	}
+	err := enc.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	pkts, err := enc.Encode(nalus)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, seiPkt := range pkts {
+		seiPkt.Timestamp = pkt.Timestamp
+		seiPkt.Marker = false
+	}
+
+	t.seiSeqOffset += uint16(len(pkts))
+	pkt.SequenceNumber += uint16(len(pkts))
+
+	return pkts, nil
+}
+
+// InjectSEI implements SEIInjector.
+func (t *formatProcessorH265) InjectSEI(payload []byte) {
+	t.sei.push(append([]byte{byte(h265.NALUType_PREFIX_SEI_NUT) << 1, 1}, seiUserDataUnregistered(payload)...))
+}
+
 func (t *formatProcessorH265) ProcessUnit(uu unit.Unit) error { //nolint:dupl
 	u := uu.(*unit.H265)
 
@@ -268,6 +330,8 @@ func (t *formatProcessorH265) ProcessUnit(uu unit.Unit) error { //nolint:dupl
 	u.AU = t.remuxAccessUnit(u.AU)
 
 	if u.AU != nil {
+		u.AU = t.insertPendingSEI(u.AU)
+
 		pkts, err := t.encoder.Encode(u.AU)
 		if err != nil {
 			return err
@@ -303,10 +367,23 @@ func (t *formatProcessorH265) ProcessRTPPacket( //nolint:dupl
 		pkt.Header.Padding = false
 		pkt.PaddingSize = 0
 
+		pkt.SequenceNumber += t.seiSeqOffset
+
+		// pending SEI NAL units are routed in dedicated RTP packets,
+		// placed before the first packet of the next access unit.
+		if !t.rtpInsideAU {
+			seiPkts, err := t.pendingSEIPackets(pkt)
+			if err != nil {
+				return nil, err
+			}
+			u.RTPPackets = append(seiPkts, pkt)
+		}
+		t.rtpInsideAU = !pkt.Marker
+
 		// RTP packets exceed maximum size: start re-encoding them
 		if pkt.MarshalSize() > t.udpMaxPayloadSize {
 			v1 := pkt.SSRC
-			v2 := pkt.SequenceNumber
+			v2 := u.RTPPackets[0].SequenceNumber
 			err := t.createEncoder(&v1, &v2)
 			if err != nil {
 				return nil, err
@@ -324,7 +401,11 @@ func (t *formatProcessorH265) ProcessRTPPacket( //nolint:dupl
 			}
 		}
 
-		au, err := t.decoder.Decode(pkt)
+		var au [][]byte
+		var err error
+		for _, rpkt := range u.RTPPackets {
+			au, err = t.decoder.Decode(rpkt)
+		}
 
 		if t.encoder != nil {
 			u.RTPPackets = nil
@@ -348,6 +429,8 @@ func (t *formatProcessorH265) ProcessRTPPacket( //nolint:dupl
 
 	// encode into RTP
 	if len(u.AU) != 0 {
+		u.AU = t.insertPendingSEI(u.AU)
+
 		pkts, err := t.encoder.Encode(u.AU)
 		if err != nil {
 			return nil, err
@@ -0,0 +1,116 @@
+package formatprocessor
+
+import (
+	"sync"
+)
+
+const (
+	// maximum number of SEI payloads waiting for the next access unit.
+	maxPendingSEI = 32
+
+	seiPayloadTypeUserDataUnregistered = 5
+)
+
+// SEIUUID is the UUID of user data unregistered SEI NAL units
+// that are used to embed metadata into H264 and H265 streams.
+var SEIUUID = [16]byte{
+	0x4d, 0x54, 0x58, 0x2d, 0x6d, 0x65, 0x74, 0x61,
+	0x9e, 0x2b, 0x41, 0x5c, 0xb0, 0x6d, 0x3a, 0x17,
+}
+
+// SEIInjector is implemented by processors that are able to embed
+// arbitrary metadata into the stream.
+type SEIInjector interface {
+	// queue a payload that is embedded into the next access unit,
+	// in a user data unregistered SEI NAL unit.
+	InjectSEI(payload []byte)
+}
+
+// seiUserDataUnregistered returns the RBSP of a SEI message of type user data unregistered,
+// with emulation prevention bytes.
+func seiUserDataUnregistered(payload []byte) []byte {
+	size := len(SEIUUID) + len(payload)
+
+	buf := make([]byte, 0, 1+size/255+1+size+1)
+	buf = append(buf, seiPayloadTypeUserDataUnregistered)
+
+	for size >= 255 {
+		buf = append(buf, 255)
+		size -= 255
+	}
+	buf = append(buf, byte(size))
+
+	buf = append(buf, SEIUUID[:]...)
+	buf = append(buf, payload...)
+
+	// rbsp_trailing_bits
+	buf = append(buf, 0x80)
+
+	return EmulationPreventionAdd(buf)
+}
+
+// EmulationPreventionAdd inserts emulation prevention bytes,
+// in order to prevent the payload from containing start codes.
+func EmulationPreventionAdd(buf []byte) []byte {
+	ret := make([]byte, 0, len(buf)+len(buf)/2)
+	zeros := 0
+
+	for _, b := range buf {
+		if zeros == 2 && b <= 3 {
+			ret = append(ret, 3)
+			zeros = 0
+		}
+
+		ret = append(ret, b)
+
+		if b == 0 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+	}
+
+	return ret
+}
+
+// seiQueue stores SEI NAL units until the next access unit.
+// It can be filled and drained by different routines.
+type seiQueue struct {
+	mutex   sync.Mutex
+	pending [][]byte
+}
+
+func (q *seiQueue) push(nalu []byte) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if len(q.pending) >= maxPendingSEI {
+		q.pending = q.pending[1:]
+	}
+
+	q.pending = append(q.pending, nalu)
+}
+
+// drain returns pending NAL units and empties the queue.
+func (q *seiQueue) drain() [][]byte {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	ret := q.pending
+	q.pending = nil
+	return ret
+}
+
+// insert inserts pending NAL units into an access unit, after the first n NAL units.
+func (q *seiQueue) insert(au [][]byte, n int) [][]byte {
+	pending := q.drain()
+
+	if len(pending) == 0 {
+		return au
+	}
+
+	ret := make([][]byte, 0, len(au)+len(pending))
+	ret = append(ret, au[:n]...)
+	ret = append(ret, pending...)
+	ret = append(ret, au[n:]...)
+	return ret
+}
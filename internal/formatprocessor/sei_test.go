@@ -0,0 +1,199 @@
+package formatprocessor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h265"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+func TestEmulationPreventionAdd(t *testing.T) {
+	buf := []byte{0, 0, 0, 0, 1, 0, 0, 4, 0, 0, 3}
+	enc := EmulationPreventionAdd(buf)
+	require.Equal(t, []byte{0, 0, 3, 0, 0, 3, 1, 0, 0, 4, 0, 0, 3, 3}, enc)
+	require.Equal(t, buf, h264.EmulationPreventionRemove(enc))
+}
+
+func TestSEIUserDataUnregistered(t *testing.T) {
+	payload := make([]byte, 300)
+	for i := range payload {
+		payload[i] = 'a'
+	}
+
+	buf := seiUserDataUnregistered(payload)
+
+	require.Equal(t, []byte{seiPayloadTypeUserDataUnregistered, 255, 316 - 255}, buf[:3])
+	require.Equal(t, SEIUUID[:], buf[3:19])
+	require.Equal(t, payload, buf[19:len(buf)-1])
+	require.Equal(t, byte(0x80), buf[len(buf)-1])
+}
+
+func TestH264InjectSEI(t *testing.T) {
+	forma := &format.H264{
+		PayloadTyp:        96,
+		SPS:               []byte{7, 4, 5, 6},
+		PPS:               []byte{8, 1},
+		PacketizationMode: 1,
+	}
+
+	p, err := New(1472, forma, true)
+	require.NoError(t, err)
+
+	p.(SEIInjector).InjectSEI([]byte(`{"a":1}`))
+
+	u := &unit.H264{
+		Base: unit.Base{
+			PTS: 30000,
+		},
+		AU: [][]byte{
+			{byte(h264.NALUTypeIDR), 1},
+		},
+	}
+
+	err = p.ProcessUnit(u)
+	require.NoError(t, err)
+
+	require.Equal(t, [][]byte{
+		{7, 4, 5, 6},
+		{8, 1},
+		append(append([]byte{byte(h264.NALUTypeSEI), 5, 23}, SEIUUID[:]...), []byte("{\"a\":1}\x80")...),
+		{byte(h264.NALUTypeIDR), 1},
+	}, u.AU)
+
+	// SEI is inserted once
+	u = &unit.H264{
+		AU: [][]byte{
+			{byte(h264.NALUTypeNonIDR), 1},
+		},
+	}
+
+	err = p.ProcessUnit(u)
+	require.NoError(t, err)
+
+	require.Equal(t, [][]byte{
+		{byte(h264.NALUTypeNonIDR), 1},
+	}, u.AU)
+}
+
+func TestH264InjectSEIRTP(t *testing.T) {
+	forma := &format.H264{
+		PayloadTyp:        96,
+		PacketizationMode: 1,
+	}
+
+	p, err := New(1472, forma, false)
+	require.NoError(t, err)
+
+	// packets are routed as is
+	pkt := &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         true,
+			PayloadType:    96,
+			SequenceNumber: 123,
+			Timestamp:      45343,
+			SSRC:           563423,
+		},
+		Payload: []byte{byte(h264.NALUTypeNonIDR), 1},
+	}
+
+	u, err := p.ProcessRTPPacket(pkt, time.Time{}, 0, false)
+	require.NoError(t, err)
+	require.Equal(t, []*rtp.Packet{pkt}, u.GetRTPPackets())
+
+	p.(SEIInjector).InjectSEI([]byte(`{"a":1}`))
+
+	// the SEI is routed in a dedicated packet, placed before the next access unit
+	pkt = &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         true,
+			PayloadType:    96,
+			SequenceNumber: 124,
+			Timestamp:      48343,
+			SSRC:           563423,
+		},
+		Payload: []byte{byte(h264.NALUTypeNonIDR), 2},
+	}
+
+	u, err = p.ProcessRTPPacket(pkt, time.Time{}, 0, true)
+	require.NoError(t, err)
+
+	sei := append(append([]byte{byte(h264.NALUTypeSEI), 5, 23}, SEIUUID[:]...), []byte("{\"a\":1}\x80")...)
+
+	require.Equal(t, [][]byte{
+		sei,
+		{byte(h264.NALUTypeNonIDR), 2},
+	}, u.(*unit.H264).AU)
+
+	pkts := u.GetRTPPackets()
+	require.Len(t, pkts, 2)
+	require.Equal(t, &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: 124,
+			Timestamp:      48343,
+			SSRC:           563423,
+		},
+		Payload: sei,
+	}, pkts[0])
+	require.Same(t, pkt, pkts[1])
+	require.Equal(t, uint16(125), pkts[1].SequenceNumber)
+
+	// following packets are routed as is, with shifted sequence numbers
+	pkt = &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         true,
+			PayloadType:    96,
+			SequenceNumber: 125,
+			Timestamp:      51343,
+			SSRC:           563423,
+		},
+		Payload: []byte{byte(h264.NALUTypeNonIDR), 3},
+	}
+
+	u, err = p.ProcessRTPPacket(pkt, time.Time{}, 0, false)
+	require.NoError(t, err)
+	require.Equal(t, []*rtp.Packet{pkt}, u.GetRTPPackets())
+	require.Equal(t, uint16(126), pkt.SequenceNumber)
+}
+
+func TestH265InjectSEI(t *testing.T) {
+	forma := &format.H265{
+		PayloadTyp: 96,
+		VPS:        []byte{byte(h265.NALUType_VPS_NUT) << 1, 0},
+		SPS:        []byte{byte(h265.NALUType_SPS_NUT) << 1, 1},
+		PPS:        []byte{byte(h265.NALUType_PPS_NUT) << 1, 2},
+	}
+
+	p, err := New(1472, forma, true)
+	require.NoError(t, err)
+
+	p.(SEIInjector).InjectSEI([]byte(`{"a":1}`))
+
+	u := &unit.H265{
+		AU: [][]byte{
+			{byte(h265.NALUType_CRA_NUT) << 1, 0},
+		},
+	}
+
+	err = p.ProcessUnit(u)
+	require.NoError(t, err)
+
+	require.Equal(t, [][]byte{
+		{byte(h265.NALUType_VPS_NUT) << 1, 0},
+		{byte(h265.NALUType_SPS_NUT) << 1, 1},
+		{byte(h265.NALUType_PPS_NUT) << 1, 2},
+		append(append([]byte{byte(h265.NALUType_PREFIX_SEI_NUT) << 1, 1, 5, 23}, SEIUUID[:]...),
+			[]byte("{\"a\":1}\x80")...),
+		{byte(h265.NALUType_CRA_NUT) << 1, 0},
+	}, u.AU)
+}
@@ -0,0 +1,45 @@
+package hooks
+
+import (
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/externalcmd"
+	"github.com/bluenviron/mediamtx/internal/logger"
+)
+
+// OnUnhealthyParams are the parameters of OnUnhealthy.
+type OnUnhealthyParams struct {
+	Logger          logger.Writer
+	ExternalCmdPool *externalcmd.Pool
+	Conf            *conf.Path
+	ExternalCmdEnv  externalcmd.Environment
+	Reason          string
+}
+
+// OnUnhealthy is the OnUnhealthy hook.
+// It returns a function that must be called when the stream becomes healthy again.
+func OnUnhealthy(params OnUnhealthyParams) func() {
+	env := params.ExternalCmdEnv
+	env["MTX_UNHEALTHY_REASON"] = params.Reason
+
+	if params.Conf.RunOnUnhealthy != "" {
+		params.Logger.Log(logger.Info, "runOnUnhealthy command launched")
+		externalcmd.NewCmd(
+			params.ExternalCmdPool,
+			params.Conf.RunOnUnhealthy,
+			false,
+			env,
+			nil)
+	}
+
+	return func() {
+		if params.Conf.RunOnHealthy != "" {
+			params.Logger.Log(logger.Info, "runOnHealthy command launched")
+			externalcmd.NewCmd(
+				params.ExternalCmdPool,
+				params.Conf.RunOnHealthy,
+				false,
+				env,
+				nil)
+		}
+	}
+}
@@ -62,6 +62,7 @@ type Metrics struct {
 	srtServer    api.SRTServer
 	hlsManager   api.HLSServer
 	webRTCServer api.WebRTCServer
+	beaconServer api.BeaconServer
 }
 
 // Initialize initializes metrics.
@@ -159,6 +160,52 @@ func (m *Metrics) onMetrics(ctx *gin.Context) {
 			out += metric("paths", tags, 1)
 			out += metric("paths_bytes_received", tags, int64(i.BytesReceived))
 			out += metric("paths_bytes_sent", tags, int64(i.BytesSent))
+
+			if i.Health != nil {
+				healthy := int64(0)
+				if i.Health.Healthy {
+					healthy = 1
+				}
+				out += metric("paths_healthy", tags, healthy)
+			}
+
+			for j, tr := range i.TrackStats {
+				tags := "{name=\"" + i.Name + "\",track=\"" + strconv.FormatInt(int64(j), 10) +
+					"\",codec=\"" + tr.Codec + "\"}"
+				out += metricFloat("paths_tracks_bitrate", tags, tr.Bitrate)
+
+				if tr.Video != nil {
+					out += metricFloat("paths_tracks_frame_rate", tags, tr.Video.FrameRate)
+					out += metric("paths_tracks_gop_length", tags, int64(tr.Video.GOPLength))
+					out += metricFloat("paths_tracks_keyframe_interval_seconds", tags,
+						time.Duration(tr.Video.KeyFrameInterval).Seconds())
+				}
+			}
+
+			for _, r := range i.Readers {
+				if r.Stats == nil {
+					continue
+				}
+
+				tags := "{name=\"" + i.Name + "\",type=\"" + r.Type + "\",id=\"" + r.ID + "\"}"
+				out += metric("paths_readers_units_delivered", tags, int64(r.Stats.UnitsDelivered))
+				out += metric("paths_readers_units_dropped", tags, int64(r.Stats.UnitsDropped))
+				out += metric("paths_readers_queue_depth", tags, int64(r.Stats.QueueDepth))
+				out += metricFloat("paths_readers_max_lag_seconds", tags, time.Duration(r.Stats.MaxLag).Seconds())
+			}
+
+			if i.GPS != nil {
+				tags = "{name=\"" + i.Name + "\"}"
+
+				upstreamConnected := int64(0)
+				if i.GPS.UpstreamConnected {
+					upstreamConnected = 1
+				}
+
+				out += metric("beacon_upstream_connected", tags, upstreamConnected)
+				out += metric("beacon_packets_received", tags, int64(i.GPS.PacketsReceived))
+				out += metric("beacon_packets_dropped", tags, int64(i.GPS.PacketsDropped))
+			}
 		}
 	} else {
 		out += metric("paths", "", 0)
@@ -359,6 +406,24 @@ func (m *Metrics) onMetrics(ctx *gin.Context) {
 		}
 	}
 
+	if !interfaceIsEmpty(m.beaconServer) {
+		data, err := m.beaconServer.APISessionsList()
+		if err == nil && len(data.Items) != 0 {
+			for _, i := range data.Items {
+				tags := "{id=\"" + i.ID.String() + "\",path=\"" + i.Path + "\"}"
+				out += metric("beacon_sessions", tags, 1)
+				out += metric("beacon_sessions_messages_sent", tags, int64(i.MessagesSent))
+				out += metric("beacon_sessions_messages_dropped", tags, int64(i.MessagesDropped))
+				out += metric("beacon_sessions_bytes_sent", tags, int64(i.BytesSent))
+			}
+		} else {
+			out += metric("beacon_sessions", "", 0)
+			out += metric("beacon_sessions_messages_sent", "", 0)
+			out += metric("beacon_sessions_messages_dropped", "", 0)
+			out += metric("beacon_sessions_bytes_sent", "", 0)
+		}
+	}
+
 	ctx.Writer.WriteHeader(http.StatusOK)
 	io.WriteString(ctx.Writer, out) //nolint:errcheck
 }
@@ -418,3 +483,10 @@ func (m *Metrics) SetWebRTCServer(s api.WebRTCServer) {
 	defer m.mutex.Unlock()
 	m.webRTCServer = s
 }
+
+// SetBeaconServer is called by core.
+func (m *Metrics) SetBeaconServer(s api.BeaconServer) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.beaconServer = s
+}
@@ -131,10 +131,16 @@ func (s *Server) onGet(ctx *gin.Context) {
 		return
 	}
 
+	format := ctx.Query("format")
+
+	if format == "telemetry" {
+		s.onGetTelemetry(ctx, pathName, start, duration)
+		return
+	}
+
 	ww := &writerWrapper{ctx: ctx}
 	var m muxer
 
-	format := ctx.Query("format")
 	switch format {
 	case "", "fmp4":
 		m = &muxerFMP4{w: ww}
@@ -0,0 +1,128 @@
+package playback
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/recordstore"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	telemetryMaxLineSize = 1024 * 1024
+)
+
+type telemetryWriterWrapper struct {
+	ctx     *gin.Context
+	written bool
+}
+
+func (w *telemetryWriterWrapper) Write(p []byte) (int, error) {
+	if !w.written {
+		w.written = true
+		w.ctx.Header("Accept-Ranges", "none")
+		w.ctx.Header("Content-Type", "application/x-ndjson")
+	}
+	return w.ctx.Writer.Write(p)
+}
+
+// seekAndWriteTelemetry writes the telemetry entries of the given segments
+// whose NTP timestamp is between start and start+duration.
+func seekAndWriteTelemetry(
+	segments []*recordstore.Segment,
+	start time.Time,
+	duration time.Duration,
+	w io.Writer,
+) error {
+	end := start.Add(duration)
+
+	for _, seg := range segments {
+		err := func() error {
+			f, err := os.Open(seg.Fpath)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			scanner := bufio.NewScanner(f)
+			scanner.Buffer(make([]byte, 0, 4096), telemetryMaxLineSize)
+
+			for scanner.Scan() {
+				var entry recordstore.TelemetryEntry
+				err = json.Unmarshal(scanner.Bytes(), &entry)
+				if err != nil {
+					// the last line of a segment may be truncated
+					continue
+				}
+
+				if entry.NTP.Before(start) || !entry.NTP.Before(end) {
+					continue
+				}
+
+				_, err = w.Write(append(scanner.Bytes(), '\n'))
+				if err != nil {
+					return err
+				}
+			}
+
+			return scanner.Err()
+		}()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) onGetTelemetry(ctx *gin.Context, pathName string, start time.Time, duration time.Duration) {
+	pathConf, err := s.safeFindPathConf(pathName)
+	if err != nil {
+		s.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	segments, err := recordstore.FindTelemetrySegmentsInTimespan(pathConf, pathName, start, duration)
+	if err != nil {
+		if errors.Is(err, recordstore.ErrNoSegmentsFound) {
+			s.writeError(ctx, http.StatusNotFound, err)
+		} else {
+			s.writeError(ctx, http.StatusBadRequest, err)
+		}
+		return
+	}
+
+	ww := &telemetryWriterWrapper{ctx: ctx}
+
+	err = seekAndWriteTelemetry(segments, start, duration, ww)
+	if err != nil {
+		// user aborted the download
+		var neterr *net.OpError
+		if errors.As(err, &neterr) {
+			return
+		}
+
+		// nothing has been written yet; send back JSON
+		if !ww.written {
+			s.writeError(ctx, http.StatusBadRequest, err)
+			return
+		}
+
+		// something has already been written: abort and write logs only
+		s.Log(logger.Error, err.Error())
+		return
+	}
+
+	// no entries in the timespan
+	if !ww.written {
+		ctx.Header("Content-Type", "application/x-ndjson")
+		ctx.Status(http.StatusOK)
+	}
+}
@@ -24,9 +24,35 @@ func (d listEntryDuration) MarshalJSON() ([]byte, error) {
 }
 
 type listEntry struct {
-	Start    time.Time         `json:"start"`
-	Duration listEntryDuration `json:"duration"`
-	URL      string            `json:"url"`
+	Start        time.Time         `json:"start"`
+	Duration     listEntryDuration `json:"duration"`
+	URL          string            `json:"url"`
+	TelemetryURL string            `json:"telemetryUrl,omitempty"`
+}
+
+// telemetryOverlaps checks whether at least one telemetry segment overlaps the given timespan.
+func telemetryOverlaps(
+	segments []*recordstore.Segment,
+	segmentDuration time.Duration,
+	start time.Time,
+	duration time.Duration,
+) bool {
+	end := start.Add(duration)
+
+	for i, seg := range segments {
+		// telemetry segments are rotated when segmentDuration has passed,
+		// therefore their end is either the start of the next segment or start+segmentDuration.
+		segEnd := seg.Start.Add(segmentDuration)
+		if i < len(segments)-1 && segments[i+1].Start.Before(segEnd) {
+			segEnd = segments[i+1].Start
+		}
+
+		if seg.Start.Before(end) && segEnd.After(start) {
+			return true
+		}
+	}
+
+	return false
 }
 
 func computeDurationAndConcatenate(
@@ -126,6 +152,8 @@ func (s *Server) onList(ctx *gin.Context) {
 		scheme = "http"
 	}
 
+	telemetrySegments, _ := recordstore.FindTelemetrySegments(pathConf, pathName)
+
 	for i := range entries {
 		v := url.Values{}
 		v.Add("path", pathName)
@@ -138,6 +166,13 @@ func (s *Server) onList(ctx *gin.Context) {
 			RawQuery: v.Encode(),
 		}
 		entries[i].URL = u.String()
+
+		if telemetryOverlaps(telemetrySegments, time.Duration(pathConf.RecordSegmentDuration),
+			entries[i].Start, time.Duration(entries[i].Duration)) {
+			v.Add("format", "telemetry")
+			u.RawQuery = v.Encode()
+			entries[i].TelemetryURL = u.String()
+		}
 	}
 
 	ctx.JSON(http.StatusOK, entries)
@@ -79,7 +79,9 @@ type PeerConnection struct {
 	IPsFromInterfacesList []string
 	AdditionalHosts       []string
 	Publish               bool
+	DataOnly              bool // exchange data channels only, without tracks
 	OutgoingTracks        []*OutgoingTrack
+	OnDataChannel         func(*webrtc.DataChannel)
 	Log                   logger.Writer
 
 	wr                *webrtc.PeerConnection
@@ -128,7 +130,11 @@ func (co *PeerConnection) Start() error {
 
 	mediaEngine := &webrtc.MediaEngine{}
 
-	if co.Publish {
+	switch {
+	case co.DataOnly:
+		// codecs are not needed
+
+	case co.Publish:
 		videoSetupped := false
 		audioSetupped := false
 
@@ -177,7 +183,8 @@ func (co *PeerConnection) Start() error {
 				return err
 			}
 		}
-	} else {
+
+	default:
 		for _, codec := range incomingVideoCodecs {
 			err := mediaEngine.RegisterCodec(codec, webrtc.RTPCodecTypeVideo)
 			if err != nil {
@@ -221,7 +228,11 @@ func (co *PeerConnection) Start() error {
 
 	co.ctx, co.ctxCancel = context.WithCancel(context.Background())
 
-	if co.Publish {
+	switch {
+	case co.DataOnly:
+		// tracks are not needed
+
+	case co.Publish:
 		for _, tr := range co.OutgoingTracks {
 			err = tr.setup(co)
 			if err != nil {
@@ -229,7 +240,8 @@ func (co *PeerConnection) Start() error {
 				return err
 			}
 		}
-	} else {
+
+	default:
 		_, err = co.wr.AddTransceiverFromKind(webrtc.RTPCodecTypeVideo, webrtc.RtpTransceiverInit{
 			Direction: webrtc.RTPTransceiverDirectionRecvonly,
 		})
@@ -254,6 +266,10 @@ func (co *PeerConnection) Start() error {
 		})
 	}
 
+	if co.OnDataChannel != nil {
+		co.wr.OnDataChannel(co.OnDataChannel)
+	}
+
 	co.wr.OnConnectionStateChange(func(state webrtc.PeerConnectionState) {
 		co.stateChangeMutex.Lock()
 		defer co.stateChangeMutex.Unlock()
@@ -327,6 +343,14 @@ func (co *PeerConnection) CreatePartialOffer() (*webrtc.SessionDescription, erro
 	return &offer, nil
 }
 
+// CreateDataChannel creates a data channel.
+func (co *PeerConnection) CreateDataChannel(
+	label string,
+	options *webrtc.DataChannelInit,
+) (*webrtc.DataChannel, error) {
+	return co.wr.CreateDataChannel(label, options)
+}
+
 // SetAnswer sets the answer.
 func (co *PeerConnection) SetAnswer(answer *webrtc.SessionDescription) error {
 	return co.wr.SetRemoteDescription(*answer)
@@ -539,3 +563,13 @@ func (co *PeerConnection) BytesSent() uint64 {
 	}
 	return 0
 }
+
+// DataChannelStats returns statistics of the data channel with the given label.
+func (co *PeerConnection) DataChannelStats(label string) (webrtc.DataChannelStats, bool) {
+	for _, stats := range co.wr.GetStats() {
+		if tstats, ok := stats.(webrtc.DataChannelStats); ok && tstats.Label == label {
+			return tstats, true
+		}
+	}
+	return webrtc.DataChannelStats{}, false
+}
@@ -123,6 +123,11 @@ func (c *Cleaner) processPath(now time.Time, pathName string) error {
 		return err
 	}
 
+	telemetrySegments, err := recordstore.FindTelemetrySegments(pathConf, pathName)
+	if err == nil {
+		segments = append(segments, telemetrySegments...)
+	}
+
 	for _, seg := range segments {
 		if now.Sub(seg.Start) > time.Duration(pathConf.RecordDeleteAfter) {
 			c.Log(logger.Debug, "removing %s", seg.Fpath)
@@ -32,6 +32,12 @@ func TestCleaner(t *testing.T) {
 	err = os.WriteFile(filepath.Join(dir, specialChars+"_mypath", "2009-05-20_22-15-25-000427.mp4"), []byte{1}, 0o644)
 	require.NoError(t, err)
 
+	err = os.WriteFile(filepath.Join(dir, specialChars+"_mypath", "2008-05-20_22-15-25-000125.ndjson"), []byte{1}, 0o644)
+	require.NoError(t, err)
+
+	err = os.WriteFile(filepath.Join(dir, specialChars+"_mypath", "2009-05-20_22-15-25-000427.ndjson"), []byte{1}, 0o644)
+	require.NoError(t, err)
+
 	c := &Cleaner{
 		PathConfs: map[string]*conf.Path{
 			"~^.*$": {
@@ -54,6 +60,12 @@ func TestCleaner(t *testing.T) {
 
 	_, err = os.Stat(filepath.Join(dir, specialChars+"_mypath", "2009-05-20_22-15-25-000427.mp4"))
 	require.NoError(t, err)
+
+	_, err = os.Stat(filepath.Join(dir, specialChars+"_mypath", "2008-05-20_22-15-25-000125.ndjson"))
+	require.Error(t, err)
+
+	_, err = os.Stat(filepath.Join(dir, specialChars+"_mypath", "2009-05-20_22-15-25-000427.ndjson"))
+	require.NoError(t, err)
 }
 
 func TestCleanerMultipleEntriesSamePath(t *testing.T) {
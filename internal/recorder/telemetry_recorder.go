@@ -0,0 +1,171 @@
+package recorder
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/recordstore"
+)
+
+const (
+	telemetryQueueSize = 256
+)
+
+// TelemetryRecorder writes telemetry to disk.
+// Telemetry is stored into segments that are placed next to the ones
+// of the Recorder, with the same path format and the NDJSON format.
+type TelemetryRecorder struct {
+	PathFormat      string
+	SegmentDuration time.Duration
+	PathName        string
+	Parent          logger.Writer
+
+	pathFormat  string
+	writeErrLog logger.Writer
+	queue       chan recordstore.TelemetryEntry
+	curPath     string
+	curFile     *os.File
+	curWriter   *bufio.Writer
+	curStart    time.Time
+
+	terminate chan struct{}
+	done      chan struct{}
+}
+
+// Initialize initializes TelemetryRecorder.
+func (w *TelemetryRecorder) Initialize() {
+	w.pathFormat = recordstore.PathAddTelemetryExtension(
+		strings.ReplaceAll(w.PathFormat, "%path", w.PathName),
+	)
+
+	w.writeErrLog = logger.NewLimitedLogger(w)
+	w.queue = make(chan recordstore.TelemetryEntry, telemetryQueueSize)
+	w.terminate = make(chan struct{})
+	w.done = make(chan struct{})
+
+	w.Log(logger.Info, "recording telemetry")
+
+	go w.run()
+}
+
+// Log implements logger.Writer.
+func (w *TelemetryRecorder) Log(level logger.Level, format string, args ...interface{}) {
+	w.Parent.Log(level, "[telemetry recorder] "+format, args...)
+}
+
+// Close closes the TelemetryRecorder.
+func (w *TelemetryRecorder) Close() {
+	w.Log(logger.Info, "telemetry recording stopped")
+	close(w.terminate)
+	<-w.done
+}
+
+// Write writes a telemetry packet, encoded in JSON, received at the given NTP time.
+// It does not block; when the recorder can't keep up, packets are discarded.
+func (w *TelemetryRecorder) Write(ntp time.Time, packet []byte) {
+	select {
+	case w.queue <- recordstore.TelemetryEntry{NTP: ntp, Packet: packet}:
+	default:
+		w.writeErrLog.Log(logger.Warn, "recorder is too slow, discarding telemetry")
+	}
+}
+
+func (w *TelemetryRecorder) run() {
+	defer close(w.done)
+
+	for {
+		select {
+		case entry := <-w.queue:
+			w.processEntry(entry)
+
+		case <-w.terminate:
+			// write pending entries
+			for {
+				select {
+				case entry := <-w.queue:
+					w.processEntry(entry)
+				default:
+					w.closeSegment()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (w *TelemetryRecorder) processEntry(entry recordstore.TelemetryEntry) {
+	err := w.writeEntry(entry)
+	if err != nil {
+		w.writeErrLog.Log(logger.Warn, "unable to write telemetry: %v", err)
+		w.closeSegment()
+	}
+}
+
+func (w *TelemetryRecorder) writeEntry(entry recordstore.TelemetryEntry) error {
+	if w.curFile != nil && entry.NTP.Sub(w.curStart) >= w.SegmentDuration {
+		w.closeSegment()
+	}
+
+	if w.curFile == nil {
+		w.curPath = recordstore.Path{Start: entry.NTP}.Encode(w.pathFormat)
+		w.Log(logger.Debug, "creating segment %s", w.curPath)
+
+		err := os.MkdirAll(filepath.Dir(w.curPath), 0o755)
+		if err != nil {
+			return err
+		}
+
+		fi, err := os.Create(w.curPath)
+		if err != nil {
+			return err
+		}
+
+		w.curFile = fi
+		w.curWriter = bufio.NewWriter(fi)
+		w.curStart = entry.NTP
+	}
+
+	buf, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.curWriter.Write(append(buf, '\n'))
+	if err != nil {
+		return err
+	}
+
+	// flush when there are no other pending entries,
+	// in order to make telemetry available to playback as soon as possible.
+	if len(w.queue) == 0 {
+		return w.curWriter.Flush()
+	}
+
+	return nil
+}
+
+func (w *TelemetryRecorder) closeSegment() {
+	if w.curFile == nil {
+		return
+	}
+
+	w.Log(logger.Debug, "closing segment %s", w.curPath)
+
+	err := w.curWriter.Flush()
+	err2 := w.curFile.Close()
+	if err == nil {
+		err = err2
+	}
+
+	if err != nil {
+		w.Log(logger.Warn, "unable to close segment %s: %v", w.curPath, err)
+	}
+
+	w.curFile = nil
+	w.curWriter = nil
+}
@@ -0,0 +1,45 @@
+package recorder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/test"
+)
+
+func TestTelemetryRecorder(t *testing.T) {
+	dir, err := os.MkdirTemp("", "mediamtx-telemetry")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	w := &TelemetryRecorder{
+		PathFormat:      filepath.Join(dir, "%path/%Y-%m-%d_%H-%M-%S-%f"),
+		SegmentDuration: 10 * time.Second,
+		PathName:        "mypath",
+		Parent:          test.NilLogger,
+	}
+	w.Initialize()
+
+	t1 := time.Date(2008, 5, 20, 22, 15, 25, 0, time.Local)
+	t2 := time.Date(2008, 5, 20, 22, 15, 30, 0, time.Local)
+	t3 := time.Date(2008, 5, 20, 22, 15, 36, 0, time.Local)
+
+	w.Write(t1, []byte(`{"type":"a"}`))
+	w.Write(t2, []byte(`{"type":"b"}`))
+	w.Write(t3, []byte(`{"type":"c"}`))
+
+	w.Close()
+
+	buf, err := os.ReadFile(filepath.Join(dir, "mypath", "2008-05-20_22-15-25-000000.ndjson"))
+	require.NoError(t, err)
+	require.Equal(t, `{"ntp":"`+t1.Format(time.RFC3339Nano)+`","packet":{"type":"a"}}`+"\n"+
+		`{"ntp":"`+t2.Format(time.RFC3339Nano)+`","packet":{"type":"b"}}`+"\n", string(buf))
+
+	buf, err = os.ReadFile(filepath.Join(dir, "mypath", "2008-05-20_22-15-36-000000.ndjson"))
+	require.NoError(t, err)
+	require.Equal(t, `{"ntp":"`+t3.Format(time.RFC3339Nano)+`","packet":{"type":"c"}}`+"\n", string(buf))
+}
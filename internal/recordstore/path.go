@@ -34,6 +34,11 @@ func PathAddExtension(path string, format conf.RecordFormat) string {
 	}
 }
 
+// PathAddTelemetryExtension adds the file extension of telemetry segments to the path.
+func PathAddTelemetryExtension(path string) string {
+	return path + ".ndjson"
+}
+
 // CommonPath returns the common path between all segments with given recording path.
 func CommonPath(v string) string {
 	common := ""
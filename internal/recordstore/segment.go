@@ -126,6 +126,51 @@ func FindSegments(
 		pathConf.RecordFormat,
 	)
 
+	return findSegments(recordPath)
+}
+
+// FindSegmentsInTimespan returns all segments in a certain timestamp.
+func FindSegmentsInTimespan(
+	pathConf *conf.Path,
+	pathName string,
+	start time.Time,
+	duration time.Duration,
+) ([]*Segment, error) {
+	recordPath := PathAddExtension(
+		strings.ReplaceAll(pathConf.RecordPath, "%path", pathName),
+		pathConf.RecordFormat,
+	)
+
+	return findSegmentsInTimespan(recordPath, start, duration)
+}
+
+// FindTelemetrySegments returns all telemetry segments of a path.
+func FindTelemetrySegments(
+	pathConf *conf.Path,
+	pathName string,
+) ([]*Segment, error) {
+	recordPath := PathAddTelemetryExtension(
+		strings.ReplaceAll(pathConf.RecordPath, "%path", pathName),
+	)
+
+	return findSegments(recordPath)
+}
+
+// FindTelemetrySegmentsInTimespan returns all telemetry segments in a certain timestamp.
+func FindTelemetrySegmentsInTimespan(
+	pathConf *conf.Path,
+	pathName string,
+	start time.Time,
+	duration time.Duration,
+) ([]*Segment, error) {
+	recordPath := PathAddTelemetryExtension(
+		strings.ReplaceAll(pathConf.RecordPath, "%path", pathName),
+	)
+
+	return findSegmentsInTimespan(recordPath, start, duration)
+}
+
+func findSegments(recordPath string) ([]*Segment, error) {
 	// we have to convert to absolute paths
 	// otherwise, recordPath and fpath inside Walk() won't have common elements
 	recordPath, _ = filepath.Abs(recordPath)
@@ -166,18 +211,11 @@ func FindSegments(
 	return segments, nil
 }
 
-// FindSegmentsInTimespan returns all segments in a certain timestamp.
-func FindSegmentsInTimespan(
-	pathConf *conf.Path,
-	pathName string,
+func findSegmentsInTimespan(
+	recordPath string,
 	start time.Time,
 	duration time.Duration,
 ) ([]*Segment, error) {
-	recordPath := PathAddExtension(
-		strings.ReplaceAll(pathConf.RecordPath, "%path", pathName),
-		pathConf.RecordFormat,
-	)
-
 	// we have to convert to absolute paths
 	// otherwise, recordPath and fpath inside Walk() won't have common elements
 	recordPath, _ = filepath.Abs(recordPath)
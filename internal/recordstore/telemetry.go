@@ -0,0 +1,13 @@
+package recordstore
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// TelemetryEntry is an entry of a telemetry segment.
+// Telemetry segments contain one entry per line.
+type TelemetryEntry struct {
+	NTP    time.Time       `json:"ntp"`
+	Packet json.RawMessage `json:"packet"`
+}
@@ -0,0 +1,131 @@
+package beacon_stream
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/pion/webrtc/v3"
+
+	"github.com/bluenviron/mediamtx/internal/logger"
+)
+
+const (
+	// when the amount of data buffered by a data channel exceeds this value,
+	// writes are suspended until it falls below dataChannelBufferedAmountLow.
+	dataChannelMaxBufferedAmount = 1024 * 1024
+	dataChannelBufferedAmountLow = 512 * 1024
+)
+
+// dataChannelWriter sends packets to a data channel through a bounded queue,
+// in order to prevent a slow client from blocking the others.
+// When the queue is full, the oldest packets are discarded.
+type dataChannelWriter struct {
+	dc        *webrtc.DataChannel
+	queueSize int
+	parent    logger.Writer
+
+	mutex           sync.Mutex
+	queue           [][]byte
+	messagesDropped *atomic.Uint64
+	sendErrLogger   logger.Writer
+
+	chPush           chan struct{}
+	chBufferedAmount chan struct{}
+	terminate        chan struct{}
+	done             chan struct{}
+}
+
+func (w *dataChannelWriter) initialize() {
+	w.messagesDropped = new(atomic.Uint64)
+	w.sendErrLogger = logger.NewLimitedLogger(w.parent)
+	w.chPush = make(chan struct{}, 1)
+	w.chBufferedAmount = make(chan struct{}, 1)
+	w.terminate = make(chan struct{})
+	w.done = make(chan struct{})
+
+	w.dc.SetBufferedAmountLowThreshold(dataChannelBufferedAmountLow)
+	w.dc.OnBufferedAmountLow(func() {
+		select {
+		case w.chBufferedAmount <- struct{}{}:
+		default:
+		}
+	})
+
+	go w.run()
+}
+
+func (w *dataChannelWriter) close() {
+	close(w.terminate)
+	<-w.done
+}
+
+// push queues a packet. It never blocks.
+func (w *dataChannelWriter) push(data []byte) {
+	w.mutex.Lock()
+
+	if len(w.queue) != 0 && len(w.queue) >= w.queueSize {
+		w.queue = w.queue[1:]
+		w.messagesDropped.Add(1)
+		w.sendErrLogger.Log(logger.Warn, "data channel '%s' is too slow, discarding packets", w.dc.Label())
+	}
+
+	w.queue = append(w.queue, data)
+
+	w.mutex.Unlock()
+
+	select {
+	case w.chPush <- struct{}{}:
+	default:
+	}
+}
+
+func (w *dataChannelWriter) pop() ([]byte, bool) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if len(w.queue) == 0 {
+		return nil, false
+	}
+
+	data := w.queue[0]
+	w.queue = w.queue[1:]
+	return data, true
+}
+
+func (w *dataChannelWriter) run() {
+	defer close(w.done)
+
+	for {
+		select {
+		case <-w.chPush:
+		case <-w.terminate:
+			return
+		}
+
+		for {
+			data, ok := w.pop()
+			if !ok {
+				break
+			}
+
+			// wait until the client has consumed buffered data.
+			// In the meanwhile, the queue fills up and old packets are discarded.
+			for w.dc.BufferedAmount() > dataChannelMaxBufferedAmount {
+				select {
+				case <-w.chBufferedAmount:
+				case <-w.terminate:
+					return
+				}
+			}
+
+			if w.dc.ReadyState() != webrtc.DataChannelStateOpen {
+				continue
+			}
+
+			err := w.dc.Send(data)
+			if err != nil {
+				w.sendErrLogger.Log(logger.Warn, "failed to send data to DataChannel: %v", err)
+			}
+		}
+	}
+}
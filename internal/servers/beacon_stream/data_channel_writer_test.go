@@ -0,0 +1,62 @@
+package beacon_stream
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/test"
+)
+
+func TestDataChannelWriterDropOldest(t *testing.T) {
+	pc, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+	require.NoError(t, err)
+	defer pc.Close() //nolint:errcheck
+
+	dc, err := pc.CreateDataChannel("data", nil)
+	require.NoError(t, err)
+
+	// the routine is not started, in order to inspect the queue.
+	w := &dataChannelWriter{
+		dc:              dc,
+		queueSize:       2,
+		messagesDropped: new(atomic.Uint64),
+		sendErrLogger:   test.NilLogger,
+		chPush:          make(chan struct{}, 1),
+	}
+
+	w.push([]byte("1"))
+	w.push([]byte("2"))
+	w.push([]byte("3"))
+
+	require.Equal(t, uint64(1), w.messagesDropped.Load())
+
+	data, ok := w.pop()
+	require.True(t, ok)
+	require.Equal(t, []byte("2"), data)
+
+	data, ok = w.pop()
+	require.True(t, ok)
+	require.Equal(t, []byte("3"), data)
+
+	_, ok = w.pop()
+	require.False(t, ok)
+}
+
+func TestHubDataChannelInit(t *testing.T) {
+	h := &Hub{}
+	require.Equal(t, &webrtc.DataChannelInit{}, h.DataChannelInit())
+
+	h.Conf.Unordered = true
+	h.Conf.Unreliable = true
+	h.Conf.MaxRetransmits = 2
+
+	ordered := false
+	maxRetransmits := uint16(2)
+	require.Equal(t, &webrtc.DataChannelInit{
+		Ordered:        &ordered,
+		MaxRetransmits: &maxRetransmits,
+	}, h.DataChannelInit())
+}
@@ -0,0 +1,42 @@
+package beacon_stream
+
+import (
+	"fmt"
+	"time"
+)
+
+// upstreamDecoder decodes the data received from the upstream into packets.
+// Decoders are stateful and must be recreated when the upstream reconnects.
+type upstreamDecoder interface {
+	// decode decodes a chunk of data.
+	// cb is called for every decoded packet or for every error.
+	decode(buf []byte, now time.Time, cb func(Packet, error))
+}
+
+func newUpstreamDecoder(format string) upstreamDecoder {
+	switch format {
+	case "nmea":
+		return &nmeaDecoder{}
+
+	case "mavlink":
+		return &mavlinkDecoder{}
+
+	default:
+		return &jsonDecoder{}
+	}
+}
+
+// jsonDecoder decodes a single JSON packet.
+type jsonDecoder struct{}
+
+func (*jsonDecoder) decode(buf []byte, _ time.Time, cb func(Packet, error)) {
+	cb(DecodePacket(buf))
+}
+
+func validatePacket(pkt Packet) (Packet, error) {
+	err := pkt.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid packet of type '%s': %w", pkt.GetType(), err)
+	}
+	return pkt, nil
+}
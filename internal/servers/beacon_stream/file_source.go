@@ -0,0 +1,202 @@
+package beacon_stream
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	"github.com/bluenviron/mediamtx/internal/logger"
+)
+
+const (
+	// minimum pause between two replays of the same file.
+	fileReplayLoopPause = 1 * time.Second
+)
+
+// fileRecordedEntry is a line of a telemetry recording.
+type fileRecordedEntry struct {
+	NTP    *time.Time      `json:"ntp"`
+	Packet json.RawMessage `json:"packet"`
+}
+
+// packetTimestamp returns the timestamp of a packet, in milliseconds.
+func packetTimestamp(pkt Packet) (int64, bool) {
+	switch pkt := pkt.(type) {
+	case TimestampedPacket:
+		return pkt.GetTimestamp(), true
+
+	case PositionPacket:
+		return pkt.Timestamp, pkt.Timestamp > 0
+
+	case VelocityPacket:
+		return pkt.Timestamp, pkt.Timestamp > 0
+	}
+
+	return 0, false
+}
+
+// fileReplayClock paces packets according to their timestamps.
+type fileReplayClock struct {
+	speed float64
+
+	initialized bool
+	startTS     time.Time
+	startWall   time.Time
+}
+
+// wait waits until the instant of a timestamp has been reached.
+// It returns false when done is closed.
+func (c *fileReplayClock) wait(ts time.Time, done <-chan struct{}) bool {
+	// initialize the clock with the first timestamp,
+	// or restart it when timestamps go backwards.
+	if !c.initialized || ts.Before(c.startTS) {
+		c.initialized = true
+		c.startTS = ts
+		c.startWall = time.Now()
+		return true
+	}
+
+	target := c.startWall.Add(time.Duration(float64(ts.Sub(c.startTS)) / c.speed))
+
+	d := time.Until(target)
+	if d <= 0 {
+		return true
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return true
+	case <-done:
+		return false
+	}
+}
+
+// replayFile reads a newline-delimited JSON or NMEA log and replays its packets,
+// honoring their timestamps.
+// JSON logs can be telemetry recordings too.
+func (h *Hub) replayFile() {
+	h.Log(logger.Info, "replaying file %s", h.Conf.File)
+	defer h.upstreamConnected.Store(false)
+
+	for {
+		start := time.Now()
+
+		err := h.replayFileOnce()
+		if err != nil {
+			h.upstreamConnected.Store(false)
+			h.Log(logger.Warn, "unable to replay file: %v", err)
+			if !h.waitRetry() {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-h.ctx.Done():
+			return
+		default:
+		}
+
+		if !h.Conf.ReplayLoop {
+			h.Log(logger.Info, "replay finished")
+			<-h.ctx.Done()
+			return
+		}
+
+		// prevent files without timestamps from being replayed too fast
+		if d := fileReplayLoopPause - time.Since(start); d > 0 {
+			select {
+			case <-time.After(d):
+			case <-h.ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+func (h *Hub) replayFileOnce() error {
+	f, err := os.Open(h.Conf.File)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h.upstreamConnected.Store(true)
+
+	// timestamps restart from the beginning of the file.
+	clear(h.lastTimestamps)
+	h.decoder = newUpstreamDecoder(h.format())
+
+	clock := &fileReplayClock{speed: h.Conf.ReplaySpeed}
+	reader := bufio.NewReader(f)
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) != 0 {
+			if !h.replayLine(line, clock) {
+				return nil
+			}
+		}
+
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// replayLine decodes a line and processes its packets at the right time.
+// It returns false when the hub is closing.
+func (h *Hub) replayLine(line []byte, clock *fileReplayClock) bool {
+	var recordedNTP *time.Time
+
+	if h.format() == "json" {
+		var entry fileRecordedEntry
+		if json.Unmarshal(line, &entry) == nil && entry.NTP != nil && entry.Packet != nil {
+			recordedNTP = entry.NTP
+			line = entry.Packet
+		}
+	}
+
+	if h.Conf.RawDataLog {
+		h.Log(logger.Info, "read raw data from %s: %s", h.Conf.File, string(line))
+	}
+
+	type decodeResult struct {
+		pkt Packet
+		err error
+	}
+	var results []decodeResult
+
+	h.decoder.decode(line, time.Now(), func(pkt Packet, err error) {
+		results = append(results, decodeResult{pkt, err})
+	})
+
+	for _, res := range results {
+		var ts *time.Time
+
+		if recordedNTP != nil {
+			ts = recordedNTP
+		} else if res.err == nil {
+			if ms, ok := packetTimestamp(res.pkt); ok {
+				tmp := time.UnixMilli(ms)
+				ts = &tmp
+			}
+		}
+
+		if ts != nil && !clock.wait(*ts, h.ctx.Done()) {
+			return false
+		}
+
+		h.processPacket(res.pkt, res.err)
+	}
+
+	return true
+}
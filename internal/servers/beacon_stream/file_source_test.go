@@ -0,0 +1,134 @@
+package beacon_stream
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/test"
+)
+
+func TestHubReplayFile(t *testing.T) {
+	for _, ca := range []string{"packets", "recording"} {
+		t.Run(ca, func(t *testing.T) {
+			var content string
+			if ca == "packets" {
+				content = `{"type":"attitude","values":[1,2,3],"timestamp":1000}` + "\n" +
+					`not json` + "\n" +
+					`{"type":"attitude","values":[1,2,3],"timestamp":1200}` + "\n" +
+					`{"type":"attitude","values":[1,2,3],"timestamp":1400}`
+			} else {
+				content = `{"ntp":"2024-01-01T00:00:00Z","packet":{"type":"attitude","values":[1,2,3],"timestamp":1}}` + "\n" +
+					`{"ntp":"2024-01-01T00:00:00.2Z","packet":{"type":"attitude","values":[1,2,3],"timestamp":2}}` + "\n" +
+					`{"ntp":"2024-01-01T00:00:00.4Z","packet":{"type":"attitude","values":[1,2,3],"timestamp":3}}` + "\n"
+			}
+
+			fpath := filepath.Join(t.TempDir(), "telemetry.ndjson")
+			err := os.WriteFile(fpath, []byte(content), 0o644)
+			require.NoError(t, err)
+
+			h := &Hub{
+				Conf: conf.GPSConfig{
+					Protocol:    "file",
+					File:        fpath,
+					ReplaySpeed: 2,
+				},
+				Parent: test.NilLogger,
+			}
+
+			var mutex sync.Mutex
+			var received []time.Time
+
+			h.Initialize()
+			defer h.Close()
+
+			h.AddReader(t, func(*ReceivedPacket) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				received = append(received, time.Now())
+			})
+
+			require.Eventually(t, func() bool {
+				mutex.Lock()
+				defer mutex.Unlock()
+				return len(received) == 2
+			}, 2*time.Second, 10*time.Millisecond)
+
+			// packets are 200ms apart, replayed at double speed
+			mutex.Lock()
+			d := received[1].Sub(received[0])
+			mutex.Unlock()
+			require.Greater(t, d, 50*time.Millisecond)
+			require.Less(t, d, 190*time.Millisecond)
+
+			require.Eventually(t, func() bool {
+				return h.PacketsReceived() == 3
+			}, 2*time.Second, 10*time.Millisecond)
+
+			if ca == "packets" {
+				require.Equal(t, uint64(1), h.PacketsDropped())
+			}
+		})
+	}
+}
+
+func TestHubReplayFileLoop(t *testing.T) {
+	fpath := filepath.Join(t.TempDir(), "telemetry.nmea")
+	err := os.WriteFile(fpath, []byte(
+		"$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47\r\n"), 0o644)
+	require.NoError(t, err)
+
+	h := &Hub{
+		Conf: conf.GPSConfig{
+			Protocol:    "file",
+			File:        fpath,
+			Format:      "nmea",
+			ReplaySpeed: 1,
+			ReplayLoop:  true,
+		},
+		Parent: test.NilLogger,
+	}
+	h.Initialize()
+	defer h.Close()
+
+	require.Eventually(t, h.UpstreamConnected, 2*time.Second, 10*time.Millisecond)
+
+	// timestamps restart from the beginning of the file,
+	// therefore packets of the second replay are not dropped.
+	require.Eventually(t, func() bool {
+		return h.PacketsReceived() >= 2
+	}, 3*time.Second, 10*time.Millisecond)
+	require.Equal(t, uint64(0), h.PacketsDropped())
+}
+
+func TestHubReplayFileRetry(t *testing.T) {
+	fpath := filepath.Join(t.TempDir(), "telemetry.ndjson")
+
+	h := &Hub{
+		Conf: conf.GPSConfig{
+			Protocol:    "file",
+			File:        fpath,
+			ReplaySpeed: 1,
+		},
+		Parent: test.NilLogger,
+	}
+	h.Initialize()
+	defer h.Close()
+
+	time.Sleep(100 * time.Millisecond)
+	require.False(t, h.UpstreamConnected())
+
+	// the file is created after the first attempt failed.
+	err := os.WriteFile(fpath, []byte(`{"type":"attitude","values":[1,2,3],"timestamp":1000}`+"\n"), 0o644)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return h.PacketsReceived() == 1
+	}, upstreamRetryPause+2*time.Second, 10*time.Millisecond)
+	require.True(t, h.UpstreamConnected())
+}
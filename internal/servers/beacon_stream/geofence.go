@@ -0,0 +1,230 @@
+package beacon_stream
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
+)
+
+const (
+	// markers that are not detected for this period are considered gone.
+	geofenceMarkerTimeout = 10 * time.Second
+)
+
+// GeofenceEvent represents a packet of type "geofence".
+// It is generated by the server when a tracked object enters or exits a geofence.
+type GeofenceEvent struct {
+	Type      string     `json:"type"`
+	Geofence  string     `json:"geofence"`
+	Event     string     `json:"event"`     // enter or exit
+	Object    string     `json:"object"`    // position or marker/ID
+	Position  [2]float64 `json:"position"`  // position of the object, in the frame of the geofence
+	Timestamp int64      `json:"timestamp"` // in milliseconds
+}
+
+// GetType returns the packet type for GeofenceEvent.
+func (e GeofenceEvent) GetType() string {
+	return e.Type
+}
+
+// Validate checks whether GeofenceEvent is valid.
+func (e GeofenceEvent) Validate() error {
+	return nil
+}
+
+// pointInPolygon checks whether a point is inside a polygon, with the ray casting algorithm.
+// Coordinates are treated as planar, therefore polygons in the wgs84 frame
+// must be small and must not cross the antimeridian.
+func pointInPolygon(pt [2]float64, polygon [][2]float64) bool {
+	inside := false
+
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+
+		if (a[1] > pt[1]) != (b[1] > pt[1]) &&
+			pt[0] < (b[0]-a[0])*(pt[1]-a[1])/(b[1]-a[1])+a[0] {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
+
+// objectPosition returns the name of the object described by a packet
+// and its position in the wgs84 and camera frames, when available.
+func objectPosition(pkt Packet, pose *conf.GPSCameraPose) (string, *[2]float64, *[2]float64) {
+	switch pkt := pkt.(type) {
+	case PositionPacket:
+		wgs84 := [2]float64{pkt.Latitude, pkt.Longitude}
+
+		if pose == nil {
+			return "position", &wgs84, nil
+		}
+
+		camera := wgs84ToCamera(pose, wgs84)
+		return "position", &wgs84, &camera
+
+	case MarkerPacket:
+		object := "marker/" + strconv.FormatInt(int64(pkt.MarkerID), 10)
+
+		if pose == nil {
+			camera := markerToCamera(pkt, 0)
+			return object, nil, &camera
+		}
+
+		camera := markerToCamera(pkt, pose.Pitch)
+		wgs84 := cameraToWGS84(pose, camera)
+		return object, &wgs84, &camera
+	}
+
+	return "", nil, nil
+}
+
+// geofenceChecker checks the position of tracked objects against geofences
+// and generates an event every time an object enters or exits one of them.
+type geofenceChecker struct {
+	geofences     []conf.GPSGeofence
+	pose          *conf.GPSCameraPose
+	markerTimeout time.Duration
+
+	mutex    sync.Mutex
+	inside   []map[string][2]float64 // objects inside each geofence, with their last position
+	lastSeen map[string]time.Time    // last detection of each marker
+}
+
+func (c *geofenceChecker) initialize() {
+	c.inside = make([]map[string][2]float64, len(c.geofences))
+	for i := range c.inside {
+		c.inside[i] = make(map[string][2]float64)
+	}
+	c.lastSeen = make(map[string]time.Time)
+}
+
+// check processes a packet and returns the resulting events.
+// Objects that are inside a geofence when they are first seen generate an enter event.
+func (c *geofenceChecker) check(pkt Packet, now time.Time) []*GeofenceEvent {
+	object, wgs84, camera := objectPosition(pkt, c.pose)
+	if object == "" {
+		return nil
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, ok := pkt.(MarkerPacket); ok {
+		c.lastSeen[object] = now
+	}
+
+	var events []*GeofenceEvent
+
+	for i, g := range c.geofences {
+		var pt *[2]float64
+		if g.Frame == "camera" {
+			pt = camera
+		} else {
+			pt = wgs84
+		}
+
+		// the position of the object can't be expressed in the frame of the geofence
+		if pt == nil {
+			continue
+		}
+
+		isInside := pointInPolygon(*pt, g.Polygon)
+		_, wasInside := c.inside[i][object]
+
+		if isInside {
+			c.inside[i][object] = *pt
+		}
+
+		if isInside == wasInside {
+			continue
+		}
+
+		var event string
+		if isInside {
+			event = "enter"
+		} else {
+			delete(c.inside[i], object)
+			event = "exit"
+		}
+
+		events = append(events, &GeofenceEvent{
+			Type:      "geofence",
+			Geofence:  g.Name,
+			Event:     event,
+			Object:    object,
+			Position:  *pt,
+			Timestamp: now.UnixMilli(),
+		})
+	}
+
+	return events
+}
+
+// expire removes markers that have not been detected within the timeout
+// and returns an exit event for every geofence they were inside.
+func (c *geofenceChecker) expire(now time.Time) []*GeofenceEvent {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var stale []string
+	for object, t := range c.lastSeen {
+		if now.Sub(t) >= c.markerTimeout {
+			stale = append(stale, object)
+		}
+	}
+	sort.Strings(stale)
+
+	var events []*GeofenceEvent
+
+	for _, object := range stale {
+		delete(c.lastSeen, object)
+
+		for i, g := range c.geofences {
+			pt, ok := c.inside[i][object]
+			if !ok {
+				continue
+			}
+
+			delete(c.inside[i], object)
+
+			events = append(events, &GeofenceEvent{
+				Type:      "geofence",
+				Geofence:  g.Name,
+				Event:     "exit",
+				Object:    object,
+				Position:  pt,
+				Timestamp: now.UnixMilli(),
+			})
+		}
+	}
+
+	return events
+}
+
+func (c *geofenceChecker) apiItems() []*defs.APIPathGPSGeofence {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	ret := make([]*defs.APIPathGPSGeofence, len(c.geofences))
+
+	for i, g := range c.geofences {
+		inside := make([]string, 0, len(c.inside[i]))
+		for object := range c.inside[i] {
+			inside = append(inside, object)
+		}
+		sort.Strings(inside)
+
+		ret[i] = &defs.APIPathGPSGeofence{
+			Name:   g.Name,
+			Inside: inside,
+		}
+	}
+
+	return ret
+}
@@ -0,0 +1,245 @@
+package beacon_stream
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/test"
+)
+
+func TestPointInPolygon(t *testing.T) {
+	square := [][2]float64{{0, 0}, {10, 0}, {10, 10}, {0, 10}}
+
+	require.True(t, pointInPolygon([2]float64{5, 5}, square))
+	require.False(t, pointInPolygon([2]float64{15, 5}, square))
+	require.False(t, pointInPolygon([2]float64{-1, -1}, square))
+
+	// concave polygon
+	l := [][2]float64{{0, 0}, {10, 0}, {10, 2}, {2, 2}, {2, 10}, {0, 10}}
+
+	require.True(t, pointInPolygon([2]float64{1, 9}, l))
+	require.False(t, pointInPolygon([2]float64{5, 5}, l))
+}
+
+func TestMarkerToCamera(t *testing.T) {
+	// marker in front of the camera
+	pt := markerToCamera(MarkerPacket{Distance: 10}, 0)
+	require.InDelta(t, 0, pt[0], 1e-9)
+	require.InDelta(t, 10, pt[1], 1e-9)
+
+	// marker on the right
+	pt = markerToCamera(MarkerPacket{AngleX: math.Pi / 2, Distance: 10}, 0)
+	require.InDelta(t, 10, pt[0], 1e-9)
+	require.InDelta(t, 0, pt[1], 1e-9)
+
+	// camera looking 30 degrees downwards, marker on the ground in the center of the image
+	pt = markerToCamera(MarkerPacket{Distance: 10}, -30)
+	require.InDelta(t, 0, pt[0], 1e-9)
+	require.InDelta(t, 10*math.Cos(math.Pi/6), pt[1], 1e-9)
+
+	// camera looking 30 degrees downwards, marker 30 degrees above the center of the image
+	pt = markerToCamera(MarkerPacket{AngleY: math.Pi / 6, Distance: 10}, -30)
+	require.InDelta(t, 0, pt[0], 1e-9)
+	require.InDelta(t, 10, pt[1], 1e-9)
+}
+
+func TestCameraToWGS84(t *testing.T) {
+	pose := &conf.GPSCameraPose{
+		Latitude:  45,
+		Longitude: 9,
+		Heading:   90,
+	}
+
+	// the camera looks east
+	pt := cameraToWGS84(pose, [2]float64{0, 100})
+	require.InDelta(t, 45, pt[0], 1e-9)
+	require.InDelta(t, 9+100/(earthRadius*math.Cos(math.Pi/4))*180/math.Pi, pt[1], 1e-9)
+
+	// right of the camera is south
+	pt = cameraToWGS84(pose, [2]float64{100, 0})
+	require.InDelta(t, 45-100/earthRadius*180/math.Pi, pt[0], 1e-9)
+	require.InDelta(t, 9, pt[1], 1e-9)
+
+	back := wgs84ToCamera(pose, cameraToWGS84(pose, [2]float64{12, 34}))
+	require.InDelta(t, 12, back[0], 1e-6)
+	require.InDelta(t, 34, back[1], 1e-6)
+}
+
+func TestGeofenceChecker(t *testing.T) {
+	c := &geofenceChecker{
+		geofences: []conf.GPSGeofence{
+			{
+				Name:    "area",
+				Frame:   "wgs84",
+				Polygon: conf.GPSGeofencePolygon{{45, 9}, {45, 9.001}, {45.001, 9.001}, {45.001, 9}},
+			},
+			{
+				Name:    "front",
+				Frame:   "camera",
+				Polygon: conf.GPSGeofencePolygon{{-2, 0}, {2, 0}, {2, 10}, {-2, 10}},
+			},
+		},
+	}
+	c.initialize()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	events := c.check(PositionPacket{Type: "position", Latitude: 45.0005, Longitude: 9.0005}, now)
+	require.Equal(t, []*GeofenceEvent{{
+		Type:      "geofence",
+		Geofence:  "area",
+		Event:     "enter",
+		Object:    "position",
+		Position:  [2]float64{45.0005, 9.0005},
+		Timestamp: now.UnixMilli(),
+	}}, events)
+
+	events = c.check(PositionPacket{Type: "position", Latitude: 45.0006, Longitude: 9.0005}, now)
+	require.Empty(t, events)
+
+	events = c.check(PositionPacket{Type: "position", Latitude: 45.002, Longitude: 9.0005}, now)
+	require.Len(t, events, 1)
+	require.Equal(t, "exit", events[0].Event)
+
+	// without a camera pose, markers are checked only against geofences in the camera frame
+	events = c.check(MarkerPacket{Type: "marker", MarkerID: 3, Distance: 5}, now)
+	require.Len(t, events, 1)
+	require.Equal(t, "front", events[0].Geofence)
+	require.Equal(t, "enter", events[0].Event)
+	require.Equal(t, "marker/3", events[0].Object)
+
+	events = c.check(AttitudePacket{Type: "attitude"}, now)
+	require.Empty(t, events)
+
+	require.Equal(t, []*defs.APIPathGPSGeofence{
+		{
+			Name:   "area",
+			Inside: []string{},
+		},
+		{
+			Name:   "front",
+			Inside: []string{"marker/3"},
+		},
+	}, c.apiItems())
+}
+
+func TestGeofenceCheckerCameraPose(t *testing.T) {
+	c := &geofenceChecker{
+		geofences: []conf.GPSGeofence{
+			{
+				Name:    "area",
+				Polygon: conf.GPSGeofencePolygon{{45, 9}, {45, 9.001}, {45.001, 9.001}, {45.001, 9}},
+			},
+		},
+		pose: &conf.GPSCameraPose{
+			Latitude:  44.9995,
+			Longitude: 9.0005,
+		},
+	}
+	c.initialize()
+
+	// the camera looks north, the marker is 100m in front of it
+	events := c.check(MarkerPacket{Type: "marker", MarkerID: 1, Distance: 100}, time.Now())
+	require.Len(t, events, 1)
+	require.Equal(t, "enter", events[0].Event)
+	require.InDelta(t, 44.9995+100/earthRadius*180/math.Pi, events[0].Position[0], 1e-9)
+	require.InDelta(t, 9.0005, events[0].Position[1], 1e-9)
+
+	// the marker is 10m in front of the camera
+	events = c.check(MarkerPacket{Type: "marker", MarkerID: 1, Distance: 10}, time.Now())
+	require.Len(t, events, 1)
+	require.Equal(t, "exit", events[0].Event)
+}
+
+func TestHubGeofenceEvents(t *testing.T) {
+	var events []*GeofenceEvent
+
+	h := &Hub{
+		Conf: conf.GPSConfig{
+			Protocol:    "publisher",
+			HistorySize: 10,
+			Geofences: conf.GPSGeofences{{
+				Name:    "area",
+				Polygon: conf.GPSGeofencePolygon{{45, 9}, {45, 9.001}, {45.001, 9.001}, {45.001, 9}},
+			}},
+		},
+		OnGeofenceEvent: func(ev *GeofenceEvent) {
+			events = append(events, ev)
+		},
+		Parent: test.NilLogger,
+	}
+	h.Initialize()
+	defer h.Close()
+
+	pub := &dummyPublisher{}
+	err := h.AddPublisher(pub)
+	require.NoError(t, err)
+
+	h.Publish(pub, "pub", []byte(`{"type":"position","latitude":45.0005,"longitude":9.0005}`))
+	h.Publish(pub, "pub", []byte(`{"type":"position","latitude":46,"longitude":9.0005}`))
+
+	require.Len(t, events, 2)
+	require.Equal(t, "enter", events[0].Event)
+	require.Equal(t, "exit", events[1].Event)
+
+	// events are delivered to clients like any other packet
+	latest := h.LatestPackets()
+	require.Equal(t, events[1], latest["geofence"].Packet)
+
+	require.Equal(t, []*defs.APIPathGPSGeofence{{
+		Name:   "area",
+		Inside: []string{},
+	}}, h.APIGeofences())
+}
+
+func TestGeofenceCheckerExpire(t *testing.T) {
+	c := &geofenceChecker{
+		geofences: []conf.GPSGeofence{
+			{
+				Name:    "front",
+				Frame:   "camera",
+				Polygon: conf.GPSGeofencePolygon{{-2, 0}, {2, 0}, {2, 10}, {-2, 10}},
+			},
+		},
+		markerTimeout: 10 * time.Second,
+	}
+	c.initialize()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	events := c.check(MarkerPacket{Type: "marker", MarkerID: 3, Distance: 5}, now)
+	require.Len(t, events, 1)
+
+	events = c.check(MarkerPacket{Type: "marker", MarkerID: 4, Distance: 50}, now)
+	require.Empty(t, events)
+
+	events = c.check(MarkerPacket{Type: "marker", MarkerID: 3, Distance: 6}, now.Add(5*time.Second))
+	require.Empty(t, events)
+
+	events = c.expire(now.Add(12 * time.Second))
+	require.Empty(t, events)
+
+	// marker 4 was never inside a geofence and is simply forgotten
+	require.Len(t, c.lastSeen, 1)
+
+	events = c.expire(now.Add(15 * time.Second))
+	require.Equal(t, []*GeofenceEvent{{
+		Type:      "geofence",
+		Geofence:  "front",
+		Event:     "exit",
+		Object:    "marker/3",
+		Position:  [2]float64{0, 6},
+		Timestamp: now.Add(15 * time.Second).UnixMilli(),
+	}}, events)
+	require.Empty(t, c.lastSeen)
+
+	require.Equal(t, []*defs.APIPathGPSGeofence{{
+		Name:   "front",
+		Inside: []string{},
+	}}, c.apiItems())
+}
@@ -0,0 +1,785 @@
+package beacon_stream
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pion/webrtc/v3"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/logger"
+)
+
+const (
+	upstreamRetryPause = 5 * time.Second
+
+	// when packets of a type are not received for this period,
+	// or their timestamp goes back by more than this amount (in milliseconds),
+	// the upstream is considered restarted and timestamps are accepted again.
+	timestampResetPause = 5 * time.Second
+	timestampResetJump  = 10000
+)
+
+// ErrUpstreamNotConnected is returned when a command can't be written
+// since the hub is not connected to the upstream.
+var ErrUpstreamNotConnected = errors.New("upstream is not connected")
+
+// HubPublisher is a device that pushes telemetry to a hub.
+type HubPublisher interface {
+	// Close closes the publisher. It must not block.
+	Close()
+	WriteCommand(cmd []byte) error
+}
+
+// ReceivedPacket is a valid packet received from the upstream.
+type ReceivedPacket struct {
+	NTP    time.Time // time of reception
+	Packet Packet
+	Data   []byte // normalized JSON representation of Packet
+}
+
+// PacketReaderFunc is the prototype of the function passed to AddReader.
+type PacketReaderFunc = func(*ReceivedPacket)
+
+// hubTimestamp is the timestamp of the last packet of a given type.
+type hubTimestamp struct {
+	value    int64
+	received time.Time
+}
+
+// Hub receives the telemetry of a single path from its upstream
+// and distributes it to the WebRTC data channels of the path.
+// With the publisher protocol, the upstream is a device that connects to the server.
+type Hub struct {
+	Conf              conf.GPSConfig
+	OverridePublisher bool
+	OnGeofenceEvent   func(*GeofenceEvent)
+	Parent            logger.Writer
+
+	ctx               context.Context
+	ctxCancel         func()
+	decodeErrLogger   logger.Writer
+	decoder           upstreamDecoder
+	geofences         *geofenceChecker
+	lastTimestamps    map[string]hubTimestamp
+	upstreamConnected *atomic.Bool
+	packetsReceived   *atomic.Uint64
+	packetsDropped    *atomic.Uint64
+	mutex             sync.Mutex
+	dataChannels      map[*webrtc.DataChannel]*dataChannelWriter
+	readers           map[interface{}]PacketReaderFunc
+	history           []*ReceivedPacket
+	latest            map[string]*ReceivedPacket
+	upstreamMutex     sync.Mutex
+	upstreamWrite     func([]byte) error
+	udpLastSender     *net.UDPAddr
+	publishMutex      sync.Mutex
+	publisher         HubPublisher
+	geofencesDone     chan struct{}
+
+	// out
+	done chan struct{}
+}
+
+// Initialize initializes Hub.
+func (h *Hub) Initialize() {
+	h.ctx, h.ctxCancel = context.WithCancel(context.Background())
+	h.decodeErrLogger = logger.NewLimitedLogger(h)
+	h.lastTimestamps = make(map[string]hubTimestamp)
+	h.upstreamConnected = new(atomic.Bool)
+	h.packetsReceived = new(atomic.Uint64)
+	h.packetsDropped = new(atomic.Uint64)
+	h.dataChannels = make(map[*webrtc.DataChannel]*dataChannelWriter)
+	h.readers = make(map[interface{}]PacketReaderFunc)
+	h.latest = make(map[string]*ReceivedPacket)
+	h.done = make(chan struct{})
+
+	if len(h.Conf.Geofences) != 0 {
+		h.geofences = &geofenceChecker{
+			geofences:     h.Conf.Geofences,
+			pose:          h.Conf.CameraPose,
+			markerTimeout: geofenceMarkerTimeout,
+		}
+		h.geofences.initialize()
+
+		h.geofencesDone = make(chan struct{})
+		go h.runGeofenceExpiry()
+	}
+
+	h.Log(logger.Info, "started with protocol %s, format %s", h.Conf.Protocol, h.format())
+
+	go h.run()
+}
+
+// Close closes Hub.
+func (h *Hub) Close() {
+	h.ctxCancel()
+	<-h.done
+
+	if h.geofencesDone != nil {
+		<-h.geofencesDone
+	}
+
+	h.publishMutex.Lock()
+	if h.publisher != nil {
+		h.publisher.Close()
+		h.publisher = nil
+	}
+	h.publishMutex.Unlock()
+
+	h.mutex.Lock()
+	writers := h.dataChannels
+	h.dataChannels = make(map[*webrtc.DataChannel]*dataChannelWriter)
+	h.mutex.Unlock()
+
+	for _, w := range writers {
+		w.close()
+	}
+}
+
+// Log implements logger.Writer.
+func (h *Hub) Log(level logger.Level, format string, args ...interface{}) {
+	h.Parent.Log(level, "[beacon] "+format, args...)
+}
+
+// UpstreamConnected returns whether the hub is connected to the upstream.
+// With UDP, it returns whether the hub is listening.
+func (h *Hub) UpstreamConnected() bool {
+	return h.upstreamConnected.Load()
+}
+
+// PacketsReceived returns the number of valid packets received from the upstream.
+func (h *Hub) PacketsReceived() uint64 {
+	return h.packetsReceived.Load()
+}
+
+// PacketsDropped returns the number of packets that have been dropped since they were invalid.
+func (h *Hub) PacketsDropped() uint64 {
+	return h.packetsDropped.Load()
+}
+
+// WriteCommand writes a command to the upstream,
+// through the same connection used to receive telemetry.
+// With UDP, the command is sent to the last address that sent a packet.
+func (h *Hub) WriteCommand(cmd []byte) error {
+	h.upstreamMutex.Lock()
+	defer h.upstreamMutex.Unlock()
+
+	if h.upstreamWrite == nil {
+		return ErrUpstreamNotConnected
+	}
+
+	return h.upstreamWrite(cmd)
+}
+
+func (h *Hub) setUpstreamWrite(w func([]byte) error) {
+	h.upstreamMutex.Lock()
+	defer h.upstreamMutex.Unlock()
+	h.upstreamWrite = w
+}
+
+func (h *Hub) format() string {
+	if h.Conf.Format == "" {
+		return "json"
+	}
+	return h.Conf.Format
+}
+
+func (h *Hub) address() string {
+	return net.JoinHostPort(h.Conf.IPAddress, strconv.FormatInt(int64(h.Conf.Port), 10))
+}
+
+func (h *Hub) run() {
+	defer close(h.done)
+
+	switch h.Conf.Protocol {
+	case "ws":
+		h.broadcastGPSDataByWebsocket(fmt.Sprintf("%s://%s", h.Conf.Protocol, h.address()))
+
+	case "tcp":
+		h.broadcastGPSDataByTCP(h.address())
+
+	case "udp":
+		h.broadcastGPSDataByUDP(h.address())
+
+	case "publisher":
+		h.Log(logger.Info, "waiting for a publisher")
+		<-h.ctx.Done()
+
+	case "file":
+		h.replayFile()
+	}
+}
+
+// waitRetry waits before reconnecting to the upstream.
+// It returns false when the hub is closing.
+func (h *Hub) waitRetry() bool {
+	select {
+	case <-time.After(upstreamRetryPause):
+		return true
+	case <-h.ctx.Done():
+		return false
+	}
+}
+
+// closeOnDone closes the given connection when the hub is closing,
+// in order to unblock readers.
+func (h *Hub) closeOnDone(c interface{ Close() error }) func() {
+	stop := make(chan struct{})
+
+	go func() {
+		select {
+		case <-h.ctx.Done():
+			c.Close()
+		case <-stop:
+		}
+	}()
+
+	return func() {
+		close(stop)
+	}
+}
+
+func (h *Hub) broadcastGPSDataByWebsocket(serverURL string) {
+	for {
+		h.Log(logger.Debug, "connecting to WebSocket server at %s", serverURL)
+
+		c, _, err := websocket.DefaultDialer.DialContext(h.ctx, serverURL, nil)
+		if err != nil {
+			h.Log(logger.Warn, "failed to connect to WebSocket server: %v", err)
+			if !h.waitRetry() {
+				return
+			}
+			continue
+		}
+
+		h.Log(logger.Info, "connected to WebSocket server at %s", serverURL)
+		h.upstreamConnected.Store(true)
+
+		// the upstream may have been restarted, therefore timestamps may restart too.
+		clear(h.lastTimestamps)
+		h.decoder = newUpstreamDecoder(h.format())
+
+		stop := h.closeOnDone(c)
+
+		// writes are serialized by upstreamMutex.
+		h.setUpstreamWrite(func(cmd []byte) error {
+			return c.WriteMessage(websocket.TextMessage, cmd)
+		})
+
+		for {
+			_, msgBytes, err := c.ReadMessage()
+			if err != nil {
+				h.Log(logger.Warn, "error reading from WebSocket server: %v", err)
+				break
+			}
+
+			h.processRawData(serverURL, msgBytes)
+		}
+
+		h.setUpstreamWrite(nil)
+		stop()
+		c.Close()
+		h.upstreamConnected.Store(false)
+
+		if !h.waitRetry() {
+			return
+		}
+	}
+}
+
+// Connects to a TCP server and expects newline-delimited GPS data.
+//
+// For dev context: Connection to the tcp server can be tested by running the nc_tcp_server_test.sh file.
+func (h *Hub) broadcastGPSDataByTCP(serverURL string) {
+	for {
+		h.Log(logger.Debug, "connecting to TCP server at %s", serverURL)
+
+		var d net.Dialer
+		conn, err := d.DialContext(h.ctx, "tcp", serverURL)
+		if err != nil {
+			h.Log(logger.Warn, "failed to connect to TCP server: %v", err)
+			if !h.waitRetry() {
+				return
+			}
+			continue
+		}
+
+		h.Log(logger.Info, "connected to TCP server at %s", serverURL)
+		h.upstreamConnected.Store(true)
+
+		// the upstream may have been restarted, therefore timestamps may restart too.
+		clear(h.lastTimestamps)
+		h.decoder = newUpstreamDecoder(h.format())
+
+		stop := h.closeOnDone(conn)
+
+		// commands are newline-delimited, like telemetry.
+		h.setUpstreamWrite(func(cmd []byte) error {
+			_, err := conn.Write(append(cmd, '\n'))
+			return err
+		})
+
+		err = readTCP(conn, h.format(), func(buf []byte) {
+			h.processRawData(serverURL, buf)
+		})
+		h.Log(logger.Warn, "error reading from TCP server: %v", err)
+
+		h.setUpstreamWrite(nil)
+		stop()
+		conn.Close()
+		h.upstreamConnected.Store(false)
+
+		if !h.waitRetry() {
+			return
+		}
+	}
+}
+
+// readTCP reads data from a TCP connection until an error occurs.
+// MAVLink is a binary stream, while other formats are newline-delimited.
+func readTCP(r io.Reader, format string, cb func([]byte)) error {
+	if format == "mavlink" {
+		buf := make([]byte, 4096)
+		for {
+			n, err := r.Read(buf)
+			if err != nil {
+				return err
+			}
+
+			cb(buf[:n])
+		}
+	}
+
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			return err
+		}
+
+		cb(line)
+	}
+}
+
+// AddPublisher sets the device that pushes telemetry to the hub.
+// If there's already a publisher, it is replaced when OverridePublisher is true,
+// otherwise an error is returned.
+func (h *Hub) AddPublisher(pub HubPublisher) error {
+	if h.Conf.Protocol != "publisher" {
+		return fmt.Errorf("the GPS source of the path doesn't accept publishers")
+	}
+
+	h.publishMutex.Lock()
+	defer h.publishMutex.Unlock()
+
+	if h.ctx.Err() != nil {
+		return fmt.Errorf("terminated")
+	}
+
+	if h.publisher != nil {
+		if !h.OverridePublisher {
+			return fmt.Errorf("someone is already publishing telemetry to the path")
+		}
+
+		h.Log(logger.Info, "closing existing publisher")
+		h.publisher.Close()
+	}
+
+	h.publisher = pub
+	h.decoder = newUpstreamDecoder(h.format())
+	clear(h.lastTimestamps)
+	h.setUpstreamWrite(pub.WriteCommand)
+	h.upstreamConnected.Store(true)
+
+	return nil
+}
+
+// RemovePublisher removes a publisher.
+func (h *Hub) RemovePublisher(pub HubPublisher) {
+	h.publishMutex.Lock()
+	defer h.publishMutex.Unlock()
+
+	if h.publisher != pub {
+		return
+	}
+
+	h.publisher = nil
+	h.setUpstreamWrite(nil)
+	h.upstreamConnected.Store(false)
+}
+
+// Publish processes data pushed by a publisher.
+// Data of publishers that have been replaced is discarded.
+func (h *Hub) Publish(pub HubPublisher, from string, data []byte) {
+	h.publishMutex.Lock()
+	defer h.publishMutex.Unlock()
+
+	if h.publisher != pub {
+		return
+	}
+
+	h.processRawData(from, data)
+}
+
+// Listens on a UDP address and expects one GPS packet per datagram.
+//
+// For dev context: Connection to the udp server can be tested by running the nc_udp_server_test.sh file.
+// In UDP communication, there's no concept of a persistent connection like there is in TCP;
+// net.ListenUDP binds to a local address and port to receive UDP packets.
+func (h *Hub) broadcastGPSDataByUDP(serverURL string) {
+	udpAddr, err := net.ResolveUDPAddr("udp", serverURL)
+	if err != nil {
+		h.Log(logger.Error, "failed to resolve UDP address: %v", err)
+		return
+	}
+
+	conn, err := net.ListenUDP("udp", udpAddr)
+	if err != nil {
+		h.Log(logger.Error, "failed to listen on UDP address: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	stop := h.closeOnDone(conn)
+	defer stop()
+
+	h.Log(logger.Info, "listening on UDP address %s", serverURL)
+	h.upstreamConnected.Store(true)
+	defer h.upstreamConnected.Store(false)
+
+	h.decoder = newUpstreamDecoder(h.format())
+
+	// there's no connection, therefore commands are sent to the last sender.
+	// upstreamWrite is called with upstreamMutex locked.
+	h.setUpstreamWrite(func(cmd []byte) error {
+		if h.udpLastSender == nil {
+			return fmt.Errorf("no packets received from the upstream yet")
+		}
+		_, err := conn.WriteToUDP(cmd, h.udpLastSender)
+		return err
+	})
+	defer h.setUpstreamWrite(nil)
+
+	buf := make([]byte, 4096)
+	for {
+		n, addr, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			select {
+			case <-h.ctx.Done():
+				return
+			default:
+			}
+
+			h.Log(logger.Warn, "error reading from UDP: %v", err)
+			if !h.waitRetry() {
+				return
+			}
+			continue
+		}
+
+		h.upstreamMutex.Lock()
+		h.udpLastSender = addr
+		h.upstreamMutex.Unlock()
+
+		h.processRawData(addr.String(), buf[:n])
+	}
+}
+
+func (h *Hub) processRawData(from string, data []byte) {
+	if h.Conf.RawDataLog {
+		if h.format() == "mavlink" {
+			h.Log(logger.Info, "received raw data from %s: %x", from, data)
+		} else {
+			h.Log(logger.Info, "received raw data from %s: %s", from, string(data))
+		}
+	}
+
+	h.decoder.decode(data, time.Now(), h.processPacket)
+}
+
+func (h *Hub) processPacket(pkt Packet, err error) {
+	if err != nil {
+		h.packetsDropped.Add(1)
+		h.decodeErrLogger.Log(logger.Warn, "packet dropped: %v", err)
+		return
+	}
+
+	if tpkt, ok := pkt.(TimestampedPacket); ok {
+		now := time.Now()
+		ts := tpkt.GetTimestamp()
+		last, ok := h.lastTimestamps[tpkt.GetType()]
+
+		if ok && ts <= last.value &&
+			now.Sub(last.received) < timestampResetPause &&
+			last.value-ts <= timestampResetJump {
+			h.packetsDropped.Add(1)
+			h.decodeErrLogger.Log(logger.Warn,
+				"packet dropped: timestamp of packet of type '%s' is not increasing (%d <= %d)",
+				tpkt.GetType(), ts, last.value)
+			return
+		}
+
+		h.lastTimestamps[tpkt.GetType()] = hubTimestamp{value: ts, received: now}
+	}
+
+	h.packetsReceived.Add(1)
+
+	h.onPacket(pkt)
+
+	if h.geofences != nil {
+		for _, ev := range h.geofences.check(pkt, time.Now()) {
+			h.onGeofenceEvent(ev)
+		}
+	}
+}
+
+// runGeofenceExpiry periodically generates exit events for markers that stopped being detected.
+func (h *Hub) runGeofenceExpiry() {
+	defer close(h.geofencesDone)
+
+	t := time.NewTicker(h.geofences.markerTimeout / 2)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			for _, ev := range h.geofences.expire(time.Now()) {
+				h.onGeofenceEvent(ev)
+			}
+
+		case <-h.ctx.Done():
+			return
+		}
+	}
+}
+
+func (h *Hub) onGeofenceEvent(ev *GeofenceEvent) {
+	if ev.Event == "enter" {
+		h.Log(logger.Info, "'%s' entered geofence '%s'", ev.Object, ev.Geofence)
+	} else {
+		h.Log(logger.Info, "'%s' exited geofence '%s'", ev.Object, ev.Geofence)
+	}
+
+	// events are delivered to clients like any other packet.
+	h.onPacket(ev)
+
+	if h.OnGeofenceEvent != nil {
+		h.OnGeofenceEvent(ev)
+	}
+}
+
+// APIGeofences returns the state of geofences.
+func (h *Hub) APIGeofences() []*defs.APIPathGPSGeofence {
+	if h.geofences == nil {
+		return nil
+	}
+	return h.geofences.apiItems()
+}
+
+// onPacket is called for every valid packet received from the upstream.
+func (h *Hub) onPacket(pkt Packet) {
+	// forward the normalized form of the packet,
+	// in order to strip fields that are not part of the schema.
+	data, err := json.Marshal(pkt)
+	if err != nil {
+		h.Log(logger.Warn, "unable to encode packet: %v", err)
+		return
+	}
+
+	rpkt := &ReceivedPacket{
+		NTP:    time.Now(),
+		Packet: pkt,
+		Data:   data,
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.latest[pkt.GetType()] = rpkt
+	h.addToHistory(rpkt)
+
+	h.broadcastDataToDataChannels(data)
+
+	for _, cb := range h.readers {
+		cb(rpkt)
+	}
+}
+
+// addToHistory adds a packet to the history.
+// It must be called with mutex locked.
+func (h *Hub) addToHistory(rpkt *ReceivedPacket) {
+	if h.Conf.HistorySize == 0 {
+		return
+	}
+
+	h.history = append(h.history, rpkt)
+
+	if len(h.history) > h.Conf.HistorySize {
+		h.history = h.history[len(h.history)-h.Conf.HistorySize:]
+	}
+
+	if h.Conf.HistoryDuration > 0 {
+		minNTP := rpkt.NTP.Add(-time.Duration(h.Conf.HistoryDuration))
+		i := 0
+		for i < len(h.history) && h.history[i].NTP.Before(minNTP) {
+			i++
+		}
+		h.history = h.history[i:]
+	}
+}
+
+// recentHistory returns the packets of the history that are not older than HistoryDuration.
+// It must be called with mutex locked.
+func (h *Hub) recentHistory(now time.Time) []*ReceivedPacket {
+	if h.Conf.HistoryDuration == 0 {
+		return h.history
+	}
+
+	minNTP := now.Add(-time.Duration(h.Conf.HistoryDuration))
+	for i, rpkt := range h.history {
+		if !rpkt.NTP.Before(minNTP) {
+			return h.history[i:]
+		}
+	}
+	return nil
+}
+
+// LatestPackets returns the last packet received of each type.
+func (h *Hub) LatestPackets() map[string]*ReceivedPacket {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	ret := make(map[string]*ReceivedPacket, len(h.latest))
+	for typ, rpkt := range h.latest {
+		ret[typ] = rpkt
+	}
+	return ret
+}
+
+// AddReader adds a reader that is called for every valid packet received from the upstream.
+// The callback is called from the hub routine, therefore it must not block.
+func (h *Hub) AddReader(reader interface{}, cb PacketReaderFunc) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.readers[reader] = cb
+}
+
+// RemoveReader removes a reader.
+func (h *Hub) RemoveReader(reader interface{}) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	delete(h.readers, reader)
+}
+
+// broadcastDataToDataChannels queues data into all the data channels of the path.
+// It must be called with mutex locked.
+func (h *Hub) broadcastDataToDataChannels(data []byte) {
+	for _, w := range h.dataChannels {
+		w.push(data)
+	}
+}
+
+// DataChannelInit returns the options of data channels that receive telemetry.
+func (h *Hub) DataChannelInit() *webrtc.DataChannelInit {
+	init := &webrtc.DataChannelInit{}
+
+	if h.Conf.Unordered {
+		ordered := false
+		init.Ordered = &ordered
+	}
+
+	if h.Conf.Unreliable {
+		maxRetransmits := uint16(h.Conf.MaxRetransmits)
+		init.MaxRetransmits = &maxRetransmits
+	}
+
+	return init
+}
+
+// AttachDataChannel attaches a data channel to the hub.
+// Telemetry is sent to the data channel as long as it is open.
+func (h *Hub) AttachDataChannel(dc *webrtc.DataChannel) {
+	dc.OnOpen(func() {
+		h.Log(logger.Debug, "data channel '%s' opened", dc.Label())
+		h.addDataChannel(dc)
+	})
+
+	dc.OnClose(func() {
+		h.Log(logger.Debug, "data channel '%s' closed", dc.Label())
+		h.removeDataChannel(dc)
+	})
+}
+
+// DetachDataChannel detaches a data channel from the hub.
+func (h *Hub) DetachDataChannel(dc *webrtc.DataChannel) {
+	h.removeDataChannel(dc)
+}
+
+// MessagesDropped returns the number of packets that have been discarded
+// since the data channel was too slow.
+func (h *Hub) MessagesDropped(dc *webrtc.DataChannel) uint64 {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	w, ok := h.dataChannels[dc]
+	if !ok {
+		return 0
+	}
+	return w.messagesDropped.Load()
+}
+
+func (h *Hub) addDataChannel(dc *webrtc.DataChannel) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	// the hub is closed
+	if h.ctx.Err() != nil {
+		return
+	}
+
+	if _, ok := h.dataChannels[dc]; ok {
+		return
+	}
+
+	w := &dataChannelWriter{
+		dc:        dc,
+		queueSize: h.Conf.WriteQueueSize,
+		parent:    h,
+	}
+	w.initialize()
+
+	// replay the history, in order to provide something to the client before the next packet.
+	// Since mutex is locked, the history can't be followed by duplicate or missing packets.
+	history := h.recentHistory(time.Now())
+	if len(history) > h.Conf.WriteQueueSize {
+		history = history[len(history)-h.Conf.WriteQueueSize:]
+	}
+	for _, rpkt := range history {
+		w.push(rpkt.Data)
+	}
+
+	h.dataChannels[dc] = w
+}
+
+func (h *Hub) removeDataChannel(dc *webrtc.DataChannel) {
+	h.mutex.Lock()
+	w, ok := h.dataChannels[dc]
+	delete(h.dataChannels, dc)
+	h.mutex.Unlock()
+
+	if ok {
+		w.close()
+	}
+}
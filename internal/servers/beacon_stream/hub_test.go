@@ -0,0 +1,293 @@
+package beacon_stream
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/test"
+)
+
+func TestHubDropInvalidPackets(t *testing.T) {
+	h := &Hub{
+		Conf: conf.GPSConfig{
+			Protocol:  "udp",
+			IPAddress: "127.0.0.1",
+			Port:      9131,
+		},
+		Parent: test.NilLogger,
+	}
+	h.Initialize()
+	defer h.Close()
+
+	// wait until the hub is listening
+	require.Eventually(t, func() bool {
+		l, err := net.ListenPacket("udp", "127.0.0.1:9131")
+		if err != nil {
+			return true
+		}
+		l.Close()
+		return false
+	}, 2*time.Second, 10*time.Millisecond)
+
+	require.Eventually(t, h.UpstreamConnected, 2*time.Second, 10*time.Millisecond)
+
+	conn, err := net.Dial("udp", "127.0.0.1:9131")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	for _, pkt := range []string{
+		`{"type":"attitude","values":[1,2,3],"timestamp":2}`,
+		`not json`,
+		`{"type":"attitude","values":[1,2,300],"timestamp":3}`,
+		`{"type":"attitude","values":[1,2,3],"timestamp":1}`,
+		`{"type":"marker","markerId":1,"angle_x":0,"angle_y":0,"distance":0}`,
+		`{"type":"attitude","values":[1,2,3],"timestamp":4}`,
+	} {
+		_, err = conn.Write([]byte(pkt))
+		require.NoError(t, err)
+	}
+
+	require.Eventually(t, func() bool {
+		return h.PacketsReceived() == 3 && h.PacketsDropped() == 3
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestHubTimestampReset(t *testing.T) {
+	h := &Hub{
+		Conf: conf.GPSConfig{
+			Protocol: "publisher",
+		},
+		Parent: test.NilLogger,
+	}
+	h.Initialize()
+	defer h.Close()
+
+	pub := &dummyPublisher{}
+	err := h.AddPublisher(pub)
+	require.NoError(t, err)
+
+	for _, pkt := range []string{
+		`{"type":"attitude","values":[1,2,3],"timestamp":100000}`,
+		`{"type":"attitude","values":[1,2,3],"timestamp":95000}`,
+		// the upstream restarted and its clock restarted from zero
+		`{"type":"attitude","values":[1,2,3],"timestamp":1}`,
+		`{"type":"attitude","values":[1,2,3],"timestamp":1}`,
+	} {
+		h.Publish(pub, "pub", []byte(pkt))
+	}
+
+	require.Equal(t, uint64(2), h.PacketsReceived())
+	require.Equal(t, uint64(2), h.PacketsDropped())
+
+	// after a pause, timestamps are accepted again
+	last := h.lastTimestamps["attitude"]
+	last.received = last.received.Add(-timestampResetPause)
+	h.lastTimestamps["attitude"] = last
+
+	h.Publish(pub, "pub", []byte(`{"type":"attitude","values":[1,2,3],"timestamp":1}`))
+	require.Equal(t, uint64(3), h.PacketsReceived())
+}
+
+func TestHubWriteCommandTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:9133")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	h := &Hub{
+		Conf: conf.GPSConfig{
+			Protocol:  "tcp",
+			IPAddress: "127.0.0.1",
+			Port:      9133,
+		},
+		Parent: test.NilLogger,
+	}
+	h.Initialize()
+	defer h.Close()
+
+	nconn, err := ln.Accept()
+	require.NoError(t, err)
+	defer nconn.Close()
+
+	require.Eventually(t, func() bool {
+		return h.WriteCommand([]byte(`{"cmd":"reset"}`)) == nil
+	}, 2*time.Second, 10*time.Millisecond)
+
+	line, err := bufio.NewReader(nconn).ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "{\"cmd\":\"reset\"}\n", line)
+}
+
+func TestHubWriteCommandUDP(t *testing.T) {
+	h := &Hub{
+		Conf: conf.GPSConfig{
+			Protocol:  "udp",
+			IPAddress: "127.0.0.1",
+			Port:      9134,
+		},
+		Parent: test.NilLogger,
+	}
+	h.Initialize()
+	defer h.Close()
+
+	require.Eventually(t, h.UpstreamConnected, 2*time.Second, 10*time.Millisecond)
+
+	// the sender is unknown until a packet is received
+	err := h.WriteCommand([]byte(`{"cmd":"reset"}`))
+	require.Error(t, err)
+
+	conn, err := net.Dial("udp", "127.0.0.1:9134")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(`{"type":"attitude","values":[1,2,3],"timestamp":1}`))
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return h.WriteCommand([]byte(`{"cmd":"reset"}`)) == nil
+	}, 2*time.Second, 10*time.Millisecond)
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second)) //nolint:errcheck
+	buf := make([]byte, 1024)
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, `{"cmd":"reset"}`, string(buf[:n]))
+}
+
+func TestHubWriteCommandNotConnected(t *testing.T) {
+	h := &Hub{
+		Conf: conf.GPSConfig{
+			Protocol:  "tcp",
+			IPAddress: "127.0.0.1",
+			Port:      9135,
+		},
+		Parent: test.NilLogger,
+	}
+	h.Initialize()
+	defer h.Close()
+
+	err := h.WriteCommand([]byte(`{"cmd":"reset"}`))
+	require.ErrorIs(t, err, ErrUpstreamNotConnected)
+}
+
+type dummyPublisher struct {
+	closed bool
+}
+
+func (p *dummyPublisher) Close() {
+	p.closed = true
+}
+
+func (p *dummyPublisher) WriteCommand(_ []byte) error {
+	return nil
+}
+
+func TestHubPublisher(t *testing.T) {
+	for _, ca := range []string{"override", "no override"} {
+		t.Run(ca, func(t *testing.T) {
+			h := &Hub{
+				Conf: conf.GPSConfig{
+					Protocol: "publisher",
+				},
+				OverridePublisher: ca == "override",
+				Parent:            test.NilLogger,
+			}
+			h.Initialize()
+			defer h.Close()
+
+			pub1 := &dummyPublisher{}
+			err := h.AddPublisher(pub1)
+			require.NoError(t, err)
+			require.True(t, h.UpstreamConnected())
+
+			h.Publish(pub1, "pub1", []byte(`{"type":"attitude","values":[1,2,3],"timestamp":1}`))
+			require.Equal(t, uint64(1), h.PacketsReceived())
+
+			pub2 := &dummyPublisher{}
+			err = h.AddPublisher(pub2)
+
+			if ca == "override" {
+				require.NoError(t, err)
+				require.True(t, pub1.closed)
+
+				// data of the replaced publisher is discarded
+				h.Publish(pub1, "pub1", []byte(`{"type":"attitude","values":[1,2,3],"timestamp":2}`))
+				require.Equal(t, uint64(1), h.PacketsReceived())
+
+				// removing the replaced publisher has no effect
+				h.RemovePublisher(pub1)
+				require.True(t, h.UpstreamConnected())
+
+				h.RemovePublisher(pub2)
+			} else {
+				require.EqualError(t, err, "someone is already publishing telemetry to the path")
+				require.False(t, pub1.closed)
+
+				h.RemovePublisher(pub1)
+			}
+
+			require.False(t, h.UpstreamConnected())
+		})
+	}
+}
+
+func TestHubPublisherWrongProtocol(t *testing.T) {
+	h := &Hub{
+		Conf: conf.GPSConfig{
+			Protocol:  "tcp",
+			IPAddress: "127.0.0.1",
+			Port:      9136,
+		},
+		Parent: test.NilLogger,
+	}
+	h.Initialize()
+	defer h.Close()
+
+	err := h.AddPublisher(&dummyPublisher{})
+	require.Error(t, err)
+}
+
+func TestHubHistory(t *testing.T) {
+	h := &Hub{
+		Conf: conf.GPSConfig{
+			Protocol:        "publisher",
+			HistorySize:     3,
+			HistoryDuration: conf.StringDuration(10 * time.Second),
+		},
+		Parent: test.NilLogger,
+	}
+	h.Initialize()
+	defer h.Close()
+
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i, d := range []time.Duration{0, 1 * time.Second, 2 * time.Second, 3 * time.Second, 12 * time.Second} {
+		h.mutex.Lock()
+		h.addToHistory(&ReceivedPacket{
+			NTP:  t0.Add(d),
+			Data: []byte{byte(i)},
+		})
+		h.mutex.Unlock()
+	}
+
+	var data []byte
+	h.mutex.Lock()
+	for _, rpkt := range h.history {
+		data = append(data, rpkt.Data...)
+	}
+	h.mutex.Unlock()
+
+	// packet 0 exceeds the size, packet 1 exceeds the duration
+	require.Equal(t, []byte{2, 3, 4}, data)
+
+	h.mutex.Lock()
+	recent := h.recentHistory(t0.Add(14 * time.Second))
+	h.mutex.Unlock()
+
+	require.Len(t, recent, 1)
+	require.Equal(t, []byte{4}, recent[0].Data)
+}
@@ -0,0 +1,221 @@
+package beacon_stream
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	mavlinkV1Magic          = 0xFE
+	mavlinkV2Magic          = 0xFD
+	mavlinkV1HeaderSize     = 6
+	mavlinkV2HeaderSize     = 10
+	mavlinkChecksumSize     = 2
+	mavlinkSignatureSize    = 13
+	mavlinkV2FlagSigned     = 0x01
+	mavlinkMsgIDAttitude    = 30
+	mavlinkMsgIDGlobalPos   = 33
+	mavlinkSupportedMsgSize = 28
+)
+
+// CRC_EXTRA of supported messages, as defined in common.xml.
+var mavlinkCRCExtras = map[uint32]byte{
+	mavlinkMsgIDAttitude:  39,
+	mavlinkMsgIDGlobalPos: 104,
+}
+
+// mavlinkDecoder decodes MAVLink v1 and v2 frames from a byte stream.
+// ATTITUDE and GLOBAL_POSITION_INT messages are converted into
+// attitude, position and velocity packets; other messages are ignored.
+type mavlinkDecoder struct {
+	buf           []byte
+	lastTimestamp int64
+}
+
+func (d *mavlinkDecoder) decode(buf []byte, now time.Time, cb func(Packet, error)) {
+	d.buf = append(d.buf, buf...)
+
+	for {
+		i := mavlinkFindMagic(d.buf)
+		if i < 0 {
+			d.buf = d.buf[:0]
+			return
+		}
+		d.buf = d.buf[i:]
+
+		var headerSize int
+		var frameSize int
+
+		if d.buf[0] == mavlinkV1Magic {
+			if len(d.buf) < 2 {
+				return
+			}
+			headerSize = mavlinkV1HeaderSize
+			frameSize = headerSize + int(d.buf[1]) + mavlinkChecksumSize
+		} else {
+			if len(d.buf) < 3 {
+				return
+			}
+			headerSize = mavlinkV2HeaderSize
+			frameSize = headerSize + int(d.buf[1]) + mavlinkChecksumSize
+			if (d.buf[2] & mavlinkV2FlagSigned) != 0 {
+				frameSize += mavlinkSignatureSize
+			}
+		}
+
+		// wait for the rest of the frame
+		if len(d.buf) < frameSize {
+			return
+		}
+
+		var msgID uint32
+		if d.buf[0] == mavlinkV1Magic {
+			msgID = uint32(d.buf[5])
+		} else {
+			msgID = uint32(d.buf[7]) | uint32(d.buf[8])<<8 | uint32(d.buf[9])<<16
+		}
+
+		crcExtra, ok := mavlinkCRCExtras[msgID]
+		if !ok {
+			d.buf = d.buf[frameSize:]
+			continue
+		}
+
+		payloadLen := int(d.buf[1])
+		crc := mavlinkCRC(d.buf[1:headerSize+payloadLen], crcExtra)
+
+		if crc != binary.LittleEndian.Uint16(d.buf[headerSize+payloadLen:]) {
+			cb(nil, fmt.Errorf("invalid MAVLink frame: checksum mismatch"))
+
+			// the magic byte may be part of another frame
+			d.buf = d.buf[1:]
+			continue
+		}
+
+		// MAVLink v2 removes trailing zeros from the payload
+		if (d.buf[0] == mavlinkV1Magic && payloadLen != mavlinkSupportedMsgSize) ||
+			payloadLen > mavlinkSupportedMsgSize {
+			cb(nil, fmt.Errorf("invalid MAVLink frame: invalid payload length %d for message %d",
+				payloadLen, msgID))
+			d.buf = d.buf[frameSize:]
+			continue
+		}
+
+		var payload [mavlinkSupportedMsgSize]byte
+		copy(payload[:], d.buf[headerSize:headerSize+payloadLen])
+		d.buf = d.buf[frameSize:]
+
+		for _, pkt := range d.decodeMessage(msgID, payload[:], now) {
+			cb(validatePacket(pkt))
+		}
+	}
+}
+
+func (d *mavlinkDecoder) decodeMessage(msgID uint32, payload []byte, now time.Time) []Packet {
+	switch msgID {
+	case mavlinkMsgIDAttitude:
+		roll := float64(math.Float32frombits(binary.LittleEndian.Uint32(payload[4:])))
+		pitch := float64(math.Float32frombits(binary.LittleEndian.Uint32(payload[8:])))
+		yaw := float64(math.Float32frombits(binary.LittleEndian.Uint32(payload[12:])))
+
+		// time_boot_ms restarts when the autopilot reboots, therefore
+		// the time of reception is used, in order to keep timestamps increasing.
+		ts := now.UnixMilli()
+		if ts <= d.lastTimestamp {
+			ts = d.lastTimestamp + 1
+		}
+		d.lastTimestamp = ts
+
+		return []Packet{
+			AttitudePacket{
+				Type: "attitude",
+				Values: [3]float64{
+					normalizeDegrees(radToDeg(yaw)),
+					radToDeg(pitch),
+					normalizeDegrees(radToDeg(roll)),
+				},
+				Timestamp: ts,
+			},
+		}
+
+	case mavlinkMsgIDGlobalPos:
+		lat := float64(int32(binary.LittleEndian.Uint32(payload[4:]))) / 1e7
+		lon := float64(int32(binary.LittleEndian.Uint32(payload[8:]))) / 1e7
+		alt := float64(int32(binary.LittleEndian.Uint32(payload[12:]))) / 1000
+		vx := float64(int16(binary.LittleEndian.Uint16(payload[20:]))) / 100
+		vy := float64(int16(binary.LittleEndian.Uint16(payload[22:]))) / 100
+		vz := float64(int16(binary.LittleEndian.Uint16(payload[24:]))) / 100
+
+		ts := now.UnixMilli()
+		vs := -vz // NED frame
+
+		vel := VelocityPacket{
+			Type:          "velocity",
+			GroundSpeed:   math.Hypot(vx, vy),
+			VerticalSpeed: &vs,
+			Timestamp:     ts,
+		}
+
+		if vel.GroundSpeed > 0 {
+			course := math.Mod(radToDeg(math.Atan2(vy, vx))+360, 360)
+			vel.Course = &course
+		}
+
+		return []Packet{
+			PositionPacket{
+				Type:      "position",
+				Latitude:  lat,
+				Longitude: lon,
+				Altitude:  &alt,
+				Timestamp: ts,
+			},
+			vel,
+		}
+	}
+
+	return nil
+}
+
+func mavlinkFindMagic(buf []byte) int {
+	for i, b := range buf {
+		if b == mavlinkV1Magic || b == mavlinkV2Magic {
+			return i
+		}
+	}
+	return -1
+}
+
+// mavlinkCRC computes the CRC-16/MCRF4XX of buf followed by crcExtra.
+func mavlinkCRC(buf []byte, crcExtra byte) uint16 {
+	crc := uint16(0xFFFF)
+
+	accumulate := func(b byte) {
+		tmp := b ^ byte(crc&0xFF)
+		tmp ^= tmp << 4
+		crc = (crc >> 8) ^ (uint16(tmp) << 8) ^ (uint16(tmp) << 3) ^ (uint16(tmp) >> 4)
+	}
+
+	for _, b := range buf {
+		accumulate(b)
+	}
+	accumulate(crcExtra)
+
+	return crc
+}
+
+func radToDeg(v float64) float64 {
+	return v * 180 / math.Pi
+}
+
+// normalizeDegrees converts an angle into the range [-180, 180].
+func normalizeDegrees(v float64) float64 {
+	v = math.Mod(v, 360)
+	if v > 180 {
+		v -= 360
+	} else if v < -180 {
+		v += 360
+	}
+	return v
+}
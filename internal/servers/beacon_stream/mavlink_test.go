@@ -0,0 +1,118 @@
+package beacon_stream
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func mavlinkFrameV1(msgID byte, payload []byte) []byte {
+	buf := []byte{mavlinkV1Magic, byte(len(payload)), 0, 1, 1, msgID}
+	buf = append(buf, payload...)
+	return binary.LittleEndian.AppendUint16(buf, mavlinkCRC(buf[1:], mavlinkCRCExtras[uint32(msgID)]))
+}
+
+func mavlinkFrameV2(msgID uint32, payload []byte) []byte {
+	// remove trailing zeros
+	for len(payload) > 1 && payload[len(payload)-1] == 0 {
+		payload = payload[:len(payload)-1]
+	}
+
+	buf := []byte{mavlinkV2Magic, byte(len(payload)), 0, 0, 0, 1, 1, byte(msgID), byte(msgID >> 8), byte(msgID >> 16)}
+	buf = append(buf, payload...)
+	return binary.LittleEndian.AppendUint16(buf, mavlinkCRC(buf[1:], mavlinkCRCExtras[msgID]))
+}
+
+func mavlinkAttitudePayload(roll, pitch, yaw float32) []byte {
+	buf := make([]byte, 28)
+	binary.LittleEndian.PutUint32(buf[0:], 1000)
+	binary.LittleEndian.PutUint32(buf[4:], math.Float32bits(roll))
+	binary.LittleEndian.PutUint32(buf[8:], math.Float32bits(pitch))
+	binary.LittleEndian.PutUint32(buf[12:], math.Float32bits(yaw))
+	return buf
+}
+
+func mavlinkGlobalPositionPayload(lat, lon, alt int32, vx, vy, vz int16) []byte {
+	buf := make([]byte, 28)
+	binary.LittleEndian.PutUint32(buf[0:], 1000)
+	binary.LittleEndian.PutUint32(buf[4:], uint32(lat))
+	binary.LittleEndian.PutUint32(buf[8:], uint32(lon))
+	binary.LittleEndian.PutUint32(buf[12:], uint32(alt))
+	binary.LittleEndian.PutUint16(buf[20:], uint16(vx))
+	binary.LittleEndian.PutUint16(buf[22:], uint16(vy))
+	binary.LittleEndian.PutUint16(buf[24:], uint16(vz))
+	binary.LittleEndian.PutUint16(buf[26:], math.MaxUint16)
+	return buf
+}
+
+func TestMAVLinkCRC(t *testing.T) {
+	// check value of CRC-16/MCRF4XX
+	require.Equal(t, uint16(0x6F91), mavlinkCRC([]byte("12345678"), '9'))
+}
+
+func TestMAVLinkDecoder(t *testing.T) {
+	d := &mavlinkDecoder{}
+
+	var buf []byte
+	buf = append(buf, 0x01, 0x02) // garbage
+	buf = append(buf, mavlinkFrameV1(mavlinkMsgIDAttitude,
+		mavlinkAttitudePayload(0.1, -0.2, float32(1.5*math.Pi)))...)
+	buf = append(buf, mavlinkFrameV1(0, make([]byte, 9))...) // HEARTBEAT, not supported
+	buf = append(buf, mavlinkFrameV2(mavlinkMsgIDGlobalPos,
+		mavlinkGlobalPositionPayload(481173000, 115166666, 545400, 300, 400, -100))...)
+
+	// split the stream into two chunks
+	pkts1, errs1 := decodeAll(d, buf[:20])
+	pkts2, errs2 := decodeAll(d, buf[20:])
+	require.Empty(t, errs1)
+	require.Empty(t, errs2)
+	pkts := append(pkts1, pkts2...) //nolint:gocritic
+	require.Len(t, pkts, 3)
+
+	att := pkts[0].(AttitudePacket)
+	require.Equal(t, "attitude", att.Type)
+	require.InDelta(t, -90, att.Values[0], 0.0001)
+	require.InDelta(t, -11.459156, att.Values[1], 0.0001)
+	require.InDelta(t, 5.729578, att.Values[2], 0.0001)
+	require.Greater(t, att.Timestamp, int64(0))
+
+	pos := pkts[1].(PositionPacket)
+	require.Equal(t, "position", pos.Type)
+	require.InDelta(t, 48.1173, pos.Latitude, 0.0000001)
+	require.InDelta(t, 11.5166666, pos.Longitude, 0.0000001)
+	require.InDelta(t, 545.4, *pos.Altitude, 0.0000001)
+
+	vel := pkts[2].(VelocityPacket)
+	require.Equal(t, "velocity", vel.Type)
+	require.InDelta(t, 5, vel.GroundSpeed, 0.0000001)
+	require.InDelta(t, 53.130102, *vel.Course, 0.000001)
+	require.InDelta(t, 1, *vel.VerticalSpeed, 0.0000001)
+}
+
+func TestMAVLinkDecoderIncreasingTimestamps(t *testing.T) {
+	d := &mavlinkDecoder{}
+
+	frame := mavlinkFrameV2(mavlinkMsgIDAttitude, mavlinkAttitudePayload(0, 0, 0))
+
+	pkts, errs := decodeAll(d, append(append([]byte{}, frame...), frame...))
+	require.Empty(t, errs)
+	require.Len(t, pkts, 2)
+	require.Equal(t, pkts[0].(AttitudePacket).Timestamp+1, pkts[1].(AttitudePacket).Timestamp)
+}
+
+func TestMAVLinkDecoderChecksumMismatch(t *testing.T) {
+	d := &mavlinkDecoder{}
+
+	frame := mavlinkFrameV1(mavlinkMsgIDAttitude, mavlinkAttitudePayload(0, 0, 0))
+	frame[10] ^= 0xFF
+
+	// the decoder resynchronizes on the next frame
+	buf := append(frame, mavlinkFrameV1(mavlinkMsgIDAttitude, mavlinkAttitudePayload(0, 0, 0))...) //nolint:gocritic
+
+	pkts, errs := decodeAll(d, buf)
+	require.Len(t, pkts, 1)
+	require.Len(t, errs, 1)
+	require.EqualError(t, errs[0], "invalid MAVLink frame: checksum mismatch")
+}
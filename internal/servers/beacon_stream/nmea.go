@@ -0,0 +1,352 @@
+package beacon_stream
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	knotsToMetersPerSecond = 1852.0 / 3600.0
+)
+
+// nmeaDecoder decodes NMEA 0183 sentences, one per line.
+// GGA, RMC and VTG sentences are converted into position and velocity packets;
+// other sentences are ignored.
+type nmeaDecoder struct {
+	// time of the last RMC sentence, the only one that contains the date.
+	lastRMC time.Time
+}
+
+func (d *nmeaDecoder) decode(buf []byte, _ time.Time, cb func(Packet, error)) {
+	for _, line := range bytes.Split(buf, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		pkts, err := d.decodeSentence(string(line))
+		if err != nil {
+			cb(nil, fmt.Errorf("invalid NMEA sentence: %w", err))
+			continue
+		}
+
+		for _, pkt := range pkts {
+			cb(validatePacket(pkt))
+		}
+	}
+}
+
+func (d *nmeaDecoder) decodeSentence(s string) ([]Packet, error) {
+	if !strings.HasPrefix(s, "$") {
+		return nil, fmt.Errorf("sentence doesn't start with '$'")
+	}
+	s = s[1:]
+
+	if i := strings.IndexByte(s, '*'); i >= 0 {
+		sum, err := strconv.ParseUint(s[i+1:], 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid checksum '%s'", s[i+1:])
+		}
+
+		s = s[:i]
+
+		if nmeaChecksum(s) != byte(sum) {
+			return nil, fmt.Errorf("checksum mismatch")
+		}
+	}
+
+	fields := strings.Split(s, ",")
+
+	// skip proprietary sentences
+	if len(fields[0]) != 5 {
+		return nil, nil
+	}
+
+	switch fields[0][2:] {
+	case "GGA":
+		return d.decodeGGA(fields)
+
+	case "RMC":
+		return d.decodeRMC(fields)
+
+	case "VTG":
+		return decodeVTG(fields)
+	}
+
+	return nil, nil
+}
+
+func (d *nmeaDecoder) decodeGGA(fields []string) ([]Packet, error) {
+	if len(fields) < 10 {
+		return nil, fmt.Errorf("GGA: not enough fields")
+	}
+
+	fixQuality, err := parseNMEAInt(fields[6])
+	if err != nil {
+		return nil, fmt.Errorf("GGA: invalid fix quality: %w", err)
+	}
+
+	// no fix
+	if fixQuality == 0 {
+		return nil, nil
+	}
+
+	lat, err := parseNMEACoordinate(fields[2], fields[3], 2)
+	if err != nil {
+		return nil, fmt.Errorf("GGA: invalid latitude: %w", err)
+	}
+
+	lon, err := parseNMEACoordinate(fields[4], fields[5], 3)
+	if err != nil {
+		return nil, fmt.Errorf("GGA: invalid longitude: %w", err)
+	}
+
+	satellites, err := parseNMEAInt(fields[7])
+	if err != nil {
+		return nil, fmt.Errorf("GGA: invalid number of satellites: %w", err)
+	}
+
+	hdop, err := parseNMEAFloat(fields[8])
+	if err != nil {
+		return nil, fmt.Errorf("GGA: invalid HDOP: %w", err)
+	}
+
+	pkt := PositionPacket{
+		Type:       "position",
+		Latitude:   lat,
+		Longitude:  lon,
+		FixQuality: fixQuality,
+		Satellites: satellites,
+		HDOP:       hdop,
+	}
+
+	if fields[9] != "" {
+		var alt float64
+		alt, err = strconv.ParseFloat(fields[9], 64)
+		if err != nil {
+			return nil, fmt.Errorf("GGA: invalid altitude: %w", err)
+		}
+		pkt.Altitude = &alt
+	}
+
+	// GGA contains the time of day only; the date is taken from the last RMC.
+	if !d.lastRMC.IsZero() {
+		var tod time.Duration
+		tod, err = parseNMEATime(fields[1])
+		if err == nil {
+			t := d.lastRMC.Truncate(24 * time.Hour).Add(tod)
+
+			// the day changed after the last RMC
+			if t.Before(d.lastRMC.Add(-12 * time.Hour)) {
+				t = t.Add(24 * time.Hour)
+			}
+
+			pkt.Timestamp = t.UnixMilli()
+		}
+	}
+
+	return []Packet{pkt}, nil
+}
+
+func (d *nmeaDecoder) decodeRMC(fields []string) ([]Packet, error) {
+	if len(fields) < 10 {
+		return nil, fmt.Errorf("RMC: not enough fields")
+	}
+
+	// data is not valid
+	if fields[2] != "A" {
+		return nil, nil
+	}
+
+	tod, err := parseNMEATime(fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("RMC: invalid time: %w", err)
+	}
+
+	date, err := time.Parse("020106", fields[9])
+	if err != nil {
+		return nil, fmt.Errorf("RMC: invalid date: %w", err)
+	}
+
+	lat, err := parseNMEACoordinate(fields[3], fields[4], 2)
+	if err != nil {
+		return nil, fmt.Errorf("RMC: invalid latitude: %w", err)
+	}
+
+	lon, err := parseNMEACoordinate(fields[5], fields[6], 3)
+	if err != nil {
+		return nil, fmt.Errorf("RMC: invalid longitude: %w", err)
+	}
+
+	speed, err := parseNMEAFloat(fields[7])
+	if err != nil {
+		return nil, fmt.Errorf("RMC: invalid speed: %w", err)
+	}
+
+	course, err := parseNMEACourse(fields[8])
+	if err != nil {
+		return nil, fmt.Errorf("RMC: invalid course: %w", err)
+	}
+
+	d.lastRMC = date.Add(tod)
+	ts := d.lastRMC.UnixMilli()
+
+	return []Packet{
+		PositionPacket{
+			Type:      "position",
+			Latitude:  lat,
+			Longitude: lon,
+			Timestamp: ts,
+		},
+		VelocityPacket{
+			Type:        "velocity",
+			GroundSpeed: speed * knotsToMetersPerSecond,
+			Course:      course,
+			Timestamp:   ts,
+		},
+	}, nil
+}
+
+func decodeVTG(fields []string) ([]Packet, error) {
+	if len(fields) < 9 {
+		return nil, fmt.Errorf("VTG: not enough fields")
+	}
+
+	// data is not valid
+	if len(fields) >= 10 && fields[9] == "N" {
+		return nil, nil
+	}
+
+	course, err := parseNMEACourse(fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("VTG: invalid course: %w", err)
+	}
+
+	var speed float64
+
+	switch {
+	case fields[7] != "":
+		speed, err = strconv.ParseFloat(fields[7], 64)
+		if err != nil {
+			return nil, fmt.Errorf("VTG: invalid speed: %w", err)
+		}
+		speed /= 3.6
+
+	case fields[5] != "":
+		speed, err = strconv.ParseFloat(fields[5], 64)
+		if err != nil {
+			return nil, fmt.Errorf("VTG: invalid speed: %w", err)
+		}
+		speed *= knotsToMetersPerSecond
+
+	default:
+		return nil, nil
+	}
+
+	return []Packet{
+		VelocityPacket{
+			Type:        "velocity",
+			GroundSpeed: speed,
+			Course:      course,
+		},
+	}, nil
+}
+
+func nmeaChecksum(s string) byte {
+	var sum byte
+	for i := 0; i < len(s); i++ {
+		sum ^= s[i]
+	}
+	return sum
+}
+
+// parseNMEACoordinate parses a coordinate in the (d)ddmm.mmmm format.
+func parseNMEACoordinate(v string, hemisphere string, degDigits int) (float64, error) {
+	if len(v) < degDigits+2 {
+		return 0, fmt.Errorf("invalid value '%s'", v)
+	}
+
+	deg, err := strconv.ParseUint(v[:degDigits], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	minutes, err := strconv.ParseFloat(v[degDigits:], 64)
+	if err != nil {
+		return 0, err
+	}
+
+	if minutes < 0 || minutes >= 60 {
+		return 0, fmt.Errorf("invalid minutes: %v", minutes)
+	}
+
+	ret := float64(deg) + minutes/60
+
+	switch hemisphere {
+	case "N", "E":
+	case "S", "W":
+		ret = -ret
+	default:
+		return 0, fmt.Errorf("invalid hemisphere '%s'", hemisphere)
+	}
+
+	return ret, nil
+}
+
+// parseNMEATime parses a time of day in the hhmmss(.sss) format.
+func parseNMEATime(v string) (time.Duration, error) {
+	if len(v) < 6 {
+		return 0, fmt.Errorf("invalid value '%s'", v)
+	}
+
+	h, err := strconv.ParseUint(v[:2], 10, 64)
+	if err != nil || h > 23 {
+		return 0, fmt.Errorf("invalid value '%s'", v)
+	}
+
+	m, err := strconv.ParseUint(v[2:4], 10, 64)
+	if err != nil || m > 59 {
+		return 0, fmt.Errorf("invalid value '%s'", v)
+	}
+
+	s, err := strconv.ParseFloat(v[4:], 64)
+	if err != nil || s < 0 || s >= 61 {
+		return 0, fmt.Errorf("invalid value '%s'", v)
+	}
+
+	return time.Duration(h)*time.Hour +
+		time.Duration(m)*time.Minute +
+		time.Duration(math.Round(s*1000))*time.Millisecond, nil
+}
+
+func parseNMEACourse(v string) (*float64, error) {
+	if v == "" {
+		return nil, nil
+	}
+
+	course, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	course = math.Mod(course, 360)
+	return &course, nil
+}
+
+func parseNMEAInt(v string) (int, error) {
+	if v == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(v)
+}
+
+func parseNMEAFloat(v string) (float64, error) {
+	if v == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(v, 64)
+}
@@ -0,0 +1,132 @@
+package beacon_stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func decodeAll(d upstreamDecoder, buf []byte) ([]Packet, []error) {
+	var pkts []Packet
+	var errs []error
+
+	d.decode(buf, time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), func(pkt Packet, err error) {
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			pkts = append(pkts, pkt)
+		}
+	})
+
+	return pkts, errs
+}
+
+func TestNMEADecoder(t *testing.T) {
+	d := &nmeaDecoder{}
+
+	pkts, errs := decodeAll(d, []byte(
+		"$GPRMC,123519,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W*6A\r\n"+
+			"$GPGGA,123520,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*4D\r\n"+
+			"$GPVTG,054.7,T,034.4,M,005.5,N,010.2,K*48\r\n"+
+			"$GPGSV,3,1,11,03,03,111,00,04,15,270,00,06,01,010,00,13,06,292,00*74\r\n"))
+	require.Empty(t, errs)
+	require.Len(t, pkts, 4)
+
+	rmcTime := time.Date(1994, 3, 23, 12, 35, 19, 0, time.UTC).UnixMilli()
+
+	pos := pkts[0].(PositionPacket)
+	require.Equal(t, "position", pos.Type)
+	require.InDelta(t, 48.1173, pos.Latitude, 0.000001)
+	require.InDelta(t, 11.516666, pos.Longitude, 0.000001)
+	require.Equal(t, rmcTime, pos.Timestamp)
+
+	vel := pkts[1].(VelocityPacket)
+	require.Equal(t, "velocity", vel.Type)
+	require.InDelta(t, 11.523555, vel.GroundSpeed, 0.000001)
+	require.InDelta(t, 84.4, *vel.Course, 0.000001)
+	require.Equal(t, rmcTime, vel.Timestamp)
+
+	pos = pkts[2].(PositionPacket)
+	require.InDelta(t, 48.1173, pos.Latitude, 0.000001)
+	require.InDelta(t, 545.4, *pos.Altitude, 0.000001)
+	require.Equal(t, 1, pos.FixQuality)
+	require.Equal(t, 8, pos.Satellites)
+	require.Equal(t, 0.9, pos.HDOP)
+	require.Equal(t, rmcTime+1000, pos.Timestamp)
+
+	vel = pkts[3].(VelocityPacket)
+	require.InDelta(t, 2.833333, vel.GroundSpeed, 0.000001)
+	require.InDelta(t, 54.7, *vel.Course, 0.000001)
+	require.Zero(t, vel.Timestamp)
+}
+
+func TestNMEADecoderDayChange(t *testing.T) {
+	d := &nmeaDecoder{}
+
+	pkts, errs := decodeAll(d, []byte(
+		"$GPRMC,235959,A,4807.038,S,01131.000,W,0,,230394,,*2E\n"+
+			"$GPGGA,000001,4807.038,S,01131.000,W,1,08,0.9,545.4,M,46.9,M,,*44\n"))
+	require.Empty(t, errs)
+	require.Len(t, pkts, 3)
+
+	require.Nil(t, pkts[1].(VelocityPacket).Course)
+
+	pos := pkts[2].(PositionPacket)
+	require.InDelta(t, -48.1173, pos.Latitude, 0.000001)
+	require.InDelta(t, -11.516666, pos.Longitude, 0.000001)
+	require.Equal(t, time.Date(1994, 3, 24, 0, 0, 1, 0, time.UTC).UnixMilli(), pos.Timestamp)
+}
+
+func TestNMEADecoderSkip(t *testing.T) {
+	d := &nmeaDecoder{}
+
+	// no fix, invalid data, proprietary sentence
+	pkts, errs := decodeAll(d, []byte(
+		"$GPGGA,123520,,,,,0,00,,,M,,M,,*61\n"+
+			"$GPRMC,123519,V,,,,,,,230394,,*33\n"+
+			"$PUBX,00,123519*12\n"))
+	require.Empty(t, errs)
+	require.Empty(t, pkts)
+}
+
+func TestNMEADecoderErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		in   string
+		err  string
+	}{
+		{
+			"no prefix",
+			"GPGGA,123520",
+			"invalid NMEA sentence: sentence doesn't start with '$'",
+		},
+		{
+			"checksum mismatch",
+			"$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*48",
+			"invalid NMEA sentence: checksum mismatch",
+		},
+		{
+			"not enough fields",
+			"$GPGGA,123519,4807.038,N",
+			"invalid NMEA sentence: GGA: not enough fields",
+		},
+		{
+			"invalid hemisphere",
+			"$GPGGA,123519,4807.038,X,01131.000,E,1,08,0.9,545.4,M,46.9,M,,",
+			"invalid NMEA sentence: GGA: invalid latitude: invalid hemisphere 'X'",
+		},
+		{
+			"out of range",
+			"$GPGGA,123519,9107.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,",
+			"invalid packet of type 'position': latitude out of range: 91.1173",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			pkts, errs := decodeAll(&nmeaDecoder{}, []byte(ca.in))
+			require.Empty(t, pkts)
+			require.Len(t, errs, 1)
+			require.EqualError(t, errs[0], ca.err)
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package beacon_stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+)
+
+// Packet is an interface that all packet types implement
+type Packet interface {
+	GetType() string
+	Validate() error
+}
+
+// TimestampedPacket is implemented by packets that carry a timestamp.
+// Timestamps of packets of the same type must be strictly increasing.
+type TimestampedPacket interface {
+	Packet
+	GetTimestamp() int64
+}
+
+// CommonPacket is used to determine the type of incoming JSON data
+type CommonPacket struct {
+	Type string `json:"type"`
+}
+
+// AttitudePacket represents a packet of type "attitude"
+type AttitudePacket struct {
+	Type      string     `json:"type"`
+	Values    [3]float64 `json:"values"` // yaw, pitch and roll, in degrees
+	Timestamp int64      `json:"timestamp"`
+}
+
+// GetType returns the packet type for AttitudePacket.
+func (a AttitudePacket) GetType() string {
+	return a.Type
+}
+
+// GetTimestamp returns the timestamp of AttitudePacket.
+func (a AttitudePacket) GetTimestamp() int64 {
+	return a.Timestamp
+}
+
+// Validate checks whether AttitudePacket is valid.
+func (a AttitudePacket) Validate() error {
+	yaw, pitch, roll := a.Values[0], a.Values[1], a.Values[2]
+
+	if !isFinite(yaw) || yaw < -180 || yaw > 180 {
+		return fmt.Errorf("yaw out of range: %v", yaw)
+	}
+
+	if !isFinite(pitch) || pitch < -90 || pitch > 90 {
+		return fmt.Errorf("pitch out of range: %v", pitch)
+	}
+
+	if !isFinite(roll) || roll < -180 || roll > 180 {
+		return fmt.Errorf("roll out of range: %v", roll)
+	}
+
+	if a.Timestamp <= 0 {
+		return fmt.Errorf("invalid timestamp: %d", a.Timestamp)
+	}
+
+	return nil
+}
+
+// MarkerPacket represents a packet of type "marker"
+type MarkerPacket struct {
+	Type     string  `json:"type"`
+	MarkerID int     `json:"markerId"`
+	AngleX   float64 `json:"angle_x"` // in radians
+	AngleY   float64 `json:"angle_y"` // in radians
+	Distance float64 `json:"distance"`
+}
+
+// GetType returns the packet type for MarkerPacket
+func (m MarkerPacket) GetType() string {
+	return m.Type
+}
+
+// Validate checks whether MarkerPacket is valid.
+func (m MarkerPacket) Validate() error {
+	if m.MarkerID < 0 {
+		return fmt.Errorf("invalid marker ID: %d", m.MarkerID)
+	}
+
+	if !isFinite(m.AngleX) || m.AngleX < -math.Pi || m.AngleX > math.Pi {
+		return fmt.Errorf("angle_x out of range: %v", m.AngleX)
+	}
+
+	if !isFinite(m.AngleY) || m.AngleY < -math.Pi || m.AngleY > math.Pi {
+		return fmt.Errorf("angle_y out of range: %v", m.AngleY)
+	}
+
+	if !isFinite(m.Distance) || m.Distance < 0 {
+		return fmt.Errorf("distance out of range: %v", m.Distance)
+	}
+
+	return nil
+}
+
+// PositionPacket represents a packet of type "position".
+// It is the normalized form of NMEA GGA and RMC sentences and of
+// MAVLink GLOBAL_POSITION_INT messages.
+// Since many sentences can describe the same instant, Timestamp is
+// not required to be increasing.
+type PositionPacket struct {
+	Type       string   `json:"type"`
+	Latitude   float64  `json:"latitude"`           // in degrees
+	Longitude  float64  `json:"longitude"`          // in degrees
+	Altitude   *float64 `json:"altitude,omitempty"` // above mean sea level, in meters
+	FixQuality int      `json:"fixQuality,omitempty"`
+	Satellites int      `json:"satellites,omitempty"`
+	HDOP       float64  `json:"hdop,omitempty"`
+	Timestamp  int64    `json:"timestamp,omitempty"` // in milliseconds
+}
+
+// GetType returns the packet type for PositionPacket.
+func (p PositionPacket) GetType() string {
+	return p.Type
+}
+
+// Validate checks whether PositionPacket is valid.
+func (p PositionPacket) Validate() error {
+	if !isFinite(p.Latitude) || p.Latitude < -90 || p.Latitude > 90 {
+		return fmt.Errorf("latitude out of range: %v", p.Latitude)
+	}
+
+	if !isFinite(p.Longitude) || p.Longitude < -180 || p.Longitude > 180 {
+		return fmt.Errorf("longitude out of range: %v", p.Longitude)
+	}
+
+	if p.Altitude != nil && !isFinite(*p.Altitude) {
+		return fmt.Errorf("altitude out of range: %v", *p.Altitude)
+	}
+
+	if p.FixQuality < 0 || p.Satellites < 0 || !isFinite(p.HDOP) || p.HDOP < 0 {
+		return fmt.Errorf("invalid fix")
+	}
+
+	if p.Timestamp < 0 {
+		return fmt.Errorf("invalid timestamp: %d", p.Timestamp)
+	}
+
+	return nil
+}
+
+// VelocityPacket represents a packet of type "velocity".
+// It is the normalized form of NMEA RMC and VTG sentences and of
+// MAVLink GLOBAL_POSITION_INT messages.
+type VelocityPacket struct {
+	Type          string   `json:"type"`
+	GroundSpeed   float64  `json:"groundSpeed"`             // in meters per second
+	Course        *float64 `json:"course,omitempty"`        // over ground, in degrees from true north
+	VerticalSpeed *float64 `json:"verticalSpeed,omitempty"` // positive upwards, in meters per second
+	Timestamp     int64    `json:"timestamp,omitempty"`     // in milliseconds
+}
+
+// GetType returns the packet type for VelocityPacket.
+func (v VelocityPacket) GetType() string {
+	return v.Type
+}
+
+// Validate checks whether VelocityPacket is valid.
+func (v VelocityPacket) Validate() error {
+	if !isFinite(v.GroundSpeed) || v.GroundSpeed < 0 {
+		return fmt.Errorf("ground speed out of range: %v", v.GroundSpeed)
+	}
+
+	if v.Course != nil && (!isFinite(*v.Course) || *v.Course < 0 || *v.Course >= 360) {
+		return fmt.Errorf("course out of range: %v", *v.Course)
+	}
+
+	if v.VerticalSpeed != nil && !isFinite(*v.VerticalSpeed) {
+		return fmt.Errorf("vertical speed out of range: %v", *v.VerticalSpeed)
+	}
+
+	if v.Timestamp < 0 {
+		return fmt.Errorf("invalid timestamp: %d", v.Timestamp)
+	}
+
+	return nil
+}
+
+// PacketWrapper is a wrapper that holds any type of Packet
+type PacketWrapper struct {
+	Packet Packet
+}
+
+// PacketDecoder decodes the JSON representation of a packet of a specific type.
+type PacketDecoder func(buf []byte) (Packet, error)
+
+// JSONPacketDecoder returns a PacketDecoder that unmarshals packets into T.
+func JSONPacketDecoder[T Packet]() PacketDecoder {
+	return func(buf []byte) (Packet, error) {
+		var pkt T
+		err := json.Unmarshal(buf, &pkt)
+		if err != nil {
+			return nil, err
+		}
+		return pkt, nil
+	}
+}
+
+var (
+	packetDecodersMutex sync.RWMutex
+	packetDecoders      = map[string]PacketDecoder{
+		"attitude": JSONPacketDecoder[AttitudePacket](),
+		"marker":   JSONPacketDecoder[MarkerPacket](),
+		"position": JSONPacketDecoder[PositionPacket](),
+		"velocity": JSONPacketDecoder[VelocityPacket](),
+	}
+)
+
+// RegisterPacketType registers a packet type.
+// Packets whose "type" field is equal to typ are decoded with dec.
+func RegisterPacketType(typ string, dec PacketDecoder) {
+	packetDecodersMutex.Lock()
+	defer packetDecodersMutex.Unlock()
+	packetDecoders[typ] = dec
+}
+
+// DecodePacket decodes and validates a packet.
+func DecodePacket(buf []byte) (Packet, error) {
+	var common CommonPacket
+	err := json.Unmarshal(buf, &common)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	if common.Type == "" {
+		return nil, fmt.Errorf("packet type is missing")
+	}
+
+	packetDecodersMutex.RLock()
+	dec, ok := packetDecoders[common.Type]
+	packetDecodersMutex.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unsupported packet type '%s'", common.Type)
+	}
+
+	pkt, err := dec(buf)
+	if err != nil {
+		return nil, fmt.Errorf("invalid packet of type '%s': %w", common.Type, err)
+	}
+
+	err = pkt.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid packet of type '%s': %w", common.Type, err)
+	}
+
+	return pkt, nil
+}
+
+func isFinite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
@@ -0,0 +1,164 @@
+package beacon_stream
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodePacket(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		in   string
+		pkt  Packet
+	}{
+		{
+			"attitude",
+			`{"type":"attitude","values":[10.5,-20,179],"timestamp":1234}`,
+			AttitudePacket{
+				Type:      "attitude",
+				Values:    [3]float64{10.5, -20, 179},
+				Timestamp: 1234,
+			},
+		},
+		{
+			"marker",
+			`{"type":"marker","markerId":500,"angle_x":0.17,"angle_y":-0.039,"distance":2.5}`,
+			MarkerPacket{
+				Type:     "marker",
+				MarkerID: 500,
+				AngleX:   0.17,
+				AngleY:   -0.039,
+				Distance: 2.5,
+			},
+		},
+		{
+			"position",
+			`{"type":"position","latitude":48.1173,"longitude":11.5167,"timestamp":1234}`,
+			PositionPacket{
+				Type:      "position",
+				Latitude:  48.1173,
+				Longitude: 11.5167,
+				Timestamp: 1234,
+			},
+		},
+		{
+			"unknown fields",
+			`{"type":"attitude","values":[1,2,3],"timestamp":1,"extra":true}`,
+			AttitudePacket{
+				Type:      "attitude",
+				Values:    [3]float64{1, 2, 3},
+				Timestamp: 1,
+			},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			pkt, err := DecodePacket([]byte(ca.in))
+			require.NoError(t, err)
+			require.Equal(t, ca.pkt, pkt)
+		})
+	}
+}
+
+func TestDecodePacketErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		in   string
+		err  string
+	}{
+		{
+			"invalid json",
+			`{"type":`,
+			"invalid JSON: unexpected end of JSON input",
+		},
+		{
+			"missing type",
+			`{"values":[1,2,3]}`,
+			"packet type is missing",
+		},
+		{
+			"unsupported type",
+			`{"type":"unknown"}`,
+			"unsupported packet type 'unknown'",
+		},
+		{
+			"invalid field type",
+			`{"type":"attitude","values":"abc","timestamp":1}`,
+			"invalid packet of type 'attitude': json: cannot unmarshal string into Go struct field " +
+				"AttitudePacket.values of type [3]float64",
+		},
+		{
+			"yaw out of range",
+			`{"type":"attitude","values":[181,0,0],"timestamp":1}`,
+			"invalid packet of type 'attitude': yaw out of range: 181",
+		},
+		{
+			"pitch out of range",
+			`{"type":"attitude","values":[0,-91,0],"timestamp":1}`,
+			"invalid packet of type 'attitude': pitch out of range: -91",
+		},
+		{
+			"roll out of range",
+			`{"type":"attitude","values":[0,0,200],"timestamp":1}`,
+			"invalid packet of type 'attitude': roll out of range: 200",
+		},
+		{
+			"missing timestamp",
+			`{"type":"attitude","values":[0,0,0]}`,
+			"invalid packet of type 'attitude': invalid timestamp: 0",
+		},
+		{
+			"negative marker id",
+			`{"type":"marker","markerId":-1,"angle_x":0,"angle_y":0,"distance":0}`,
+			"invalid packet of type 'marker': invalid marker ID: -1",
+		},
+		{
+			"angle out of range",
+			`{"type":"marker","markerId":1,"angle_x":4,"angle_y":0,"distance":0}`,
+			"invalid packet of type 'marker': angle_x out of range: 4",
+		},
+		{
+			"negative distance",
+			`{"type":"marker","markerId":1,"angle_x":0,"angle_y":0,"distance":-1}`,
+			"invalid packet of type 'marker': distance out of range: -1",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			_, err := DecodePacket([]byte(ca.in))
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
+
+type testPacket struct {
+	Type  string `json:"type"`
+	Value int    `json:"value"`
+}
+
+func (p testPacket) GetType() string {
+	return p.Type
+}
+
+func (p testPacket) Validate() error {
+	if p.Value > 10 {
+		return fmt.Errorf("value too big")
+	}
+	return nil
+}
+
+func TestRegisterPacketType(t *testing.T) {
+	RegisterPacketType("test", JSONPacketDecoder[testPacket]())
+	defer func() {
+		packetDecodersMutex.Lock()
+		delete(packetDecoders, "test")
+		packetDecodersMutex.Unlock()
+	}()
+
+	pkt, err := DecodePacket([]byte(`{"type":"test","value":5}`))
+	require.NoError(t, err)
+	require.Equal(t, testPacket{Type: "test", Value: 5}, pkt)
+
+	_, err = DecodePacket([]byte(`{"type":"test","value":11}`))
+	require.EqualError(t, err, "invalid packet of type 'test': value too big")
+}
@@ -0,0 +1,135 @@
+package beacon_stream
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+
+	"github.com/bluenviron/mediamtx/internal/logger"
+)
+
+// publisher is a device that connects to the server and pushes telemetry,
+// through WebSocket or TCP.
+type publisher struct {
+	wsConn     *websocket.Conn
+	tcpConn    net.Conn
+	tcpReader  *bufio.Reader
+	hub        *Hub
+	remoteAddr string
+	pathName   string
+	user       string
+	parentCtx  context.Context
+	wg         *sync.WaitGroup
+	parent     logger.Writer
+
+	ctx        context.Context
+	ctxCancel  func()
+	uuid       uuid.UUID
+	writeMutex sync.Mutex
+}
+
+func (p *publisher) initialize() {
+	p.ctx, p.ctxCancel = context.WithCancel(p.parentCtx)
+	p.uuid = uuid.New()
+}
+
+func (p *publisher) start() {
+	if p.user != "" {
+		p.Log(logger.Info, "opened by %s (user '%s'), publishing telemetry to path '%s'",
+			p.remoteAddr, p.user, p.pathName)
+	} else {
+		p.Log(logger.Info, "opened by %s, publishing telemetry to path '%s'", p.remoteAddr, p.pathName)
+	}
+
+	p.wg.Add(1)
+	go p.run()
+}
+
+// Close implements HubPublisher.
+func (p *publisher) Close() {
+	p.ctxCancel()
+}
+
+// Log implements logger.Writer.
+func (p *publisher) Log(level logger.Level, format string, args ...interface{}) {
+	id := hex.EncodeToString(p.uuid[:4])
+	p.parent.Log(level, "[publisher %v] "+format, append([]interface{}{id}, args...)...)
+}
+
+// WriteCommand implements HubPublisher.
+func (p *publisher) WriteCommand(cmd []byte) error {
+	p.writeMutex.Lock()
+	defer p.writeMutex.Unlock()
+
+	switch {
+	case p.wsConn != nil:
+		return p.wsConn.WriteMessage(websocket.TextMessage, cmd)
+
+	case p.tcpConn != nil:
+		_, err := p.tcpConn.Write(append(cmd, '\n'))
+		return err
+	}
+
+	return ErrUpstreamNotConnected
+}
+
+func (p *publisher) close() error {
+	if p.wsConn != nil {
+		return p.wsConn.Close()
+	}
+	return p.tcpConn.Close()
+}
+
+func (p *publisher) run() {
+	defer p.wg.Done()
+
+	// close the connection when the publisher is closed or replaced,
+	// in order to unblock the reader.
+	go func() {
+		<-p.ctx.Done()
+		p.close() //nolint:errcheck
+	}()
+
+	err := p.runInner()
+
+	p.ctxCancel()
+
+	p.hub.RemovePublisher(p)
+
+	p.Log(logger.Info, "closed: %v", err)
+}
+
+func (p *publisher) runInner() error {
+	err := p.runReader()
+
+	select {
+	case <-p.ctx.Done():
+		return fmt.Errorf("terminated")
+	default:
+	}
+
+	return err
+}
+
+func (p *publisher) runReader() error {
+	if p.wsConn != nil {
+		for {
+			_, msgBytes, err := p.wsConn.ReadMessage()
+			if err != nil {
+				return err
+			}
+
+			p.hub.Publish(p, p.remoteAddr, msgBytes)
+		}
+	}
+
+	return readTCP(p.tcpReader, p.hub.format(), func(buf []byte) {
+		p.hub.Publish(p, p.remoteAddr, buf)
+	})
+}
@@ -0,0 +1,42 @@
+package beacon_stream
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket that allows up to rate events per second,
+// with bursts of up to rate events.
+type rateLimiter struct {
+	rate int
+
+	mutex  sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func (l *rateLimiter) allow(now time.Time) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.rate <= 0 {
+		return false
+	}
+
+	if l.last.IsZero() {
+		l.tokens = float64(l.rate)
+	} else {
+		l.tokens += now.Sub(l.last).Seconds() * float64(l.rate)
+		if l.tokens > float64(l.rate) {
+			l.tokens = float64(l.rate)
+		}
+	}
+	l.last = now
+
+	if l.tokens < 1 {
+		return false
+	}
+
+	l.tokens--
+	return true
+}
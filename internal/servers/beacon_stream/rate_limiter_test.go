@@ -0,0 +1,32 @@
+package beacon_stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l := &rateLimiter{rate: 2}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	require.True(t, l.allow(now))
+	require.True(t, l.allow(now))
+	require.False(t, l.allow(now))
+
+	now = now.Add(500 * time.Millisecond)
+	require.True(t, l.allow(now))
+	require.False(t, l.allow(now))
+
+	// tokens do not accumulate over the burst size
+	now = now.Add(10 * time.Second)
+	require.True(t, l.allow(now))
+	require.True(t, l.allow(now))
+	require.False(t, l.allow(now))
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	l := &rateLimiter{rate: 0}
+	require.False(t, l.allow(time.Now()))
+}
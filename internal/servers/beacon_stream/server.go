@@ -1,18 +1,33 @@
+// Package beacon_stream contains the GPS server.
 package beacon_stream
 
 import (
-	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"io"
 	"net"
 	"net/http"
+	"net/url"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
-	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
+	"github.com/pion/ice/v2"
 	"github.com/pion/webrtc/v3"
+
+	"github.com/bluenviron/mediamtx/internal/auth"
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/protocols/httpp"
+	"github.com/bluenviron/mediamtx/internal/protocols/whip"
+	"github.com/bluenviron/mediamtx/internal/restrictnetwork"
 )
 
 // Message defines the structure for signaling messages
@@ -29,69 +44,59 @@ type GPSData struct {
 	Distance float64 `json:"distance"`
 }
 
-// Packet is an interface that all packet types implement
-type Packet interface {
-	GetType() string
+// ErrSessionNotFound is returned when a session is not found.
+var ErrSessionNotFound = errors.New("session not found")
+
+type serverAPISessionsListRes struct {
+	data *defs.APIBeaconSessionList
+	err  error
 }
 
-// CommonPacket is used to determine the type of incoming JSON data
-type CommonPacket struct {
-	Type string `json:"type"`
+type serverAPISessionsListReq struct {
+	res chan serverAPISessionsListRes
 }
 
-// AttitudePacket represents a packet of type "attitude"
-type AttitudePacket struct {
-	Type      string     `json:"type"`
-	Values    [3]float64 `json:"values"`
-	Timestamp int64      `json:"timestamp"`
+type serverAPISessionsGetRes struct {
+	data *defs.APIBeaconSession
+	err  error
 }
 
-// GetType returns the packet type for AttitudePacket.
-func (a AttitudePacket) GetType() string {
-	return a.Type
+type serverAPISessionsGetReq struct {
+	uuid uuid.UUID
+	res  chan serverAPISessionsGetRes
 }
 
-// MarkerPacket represents a packet of type "marker"
-type MarkerPacket struct {
-	Type     string  `json:"type"`
-	MarkerID int     `json:"markerId"`
-	AngleX   float64 `json:"angle_x"`
-	AngleY   float64 `json:"angle_y"`
-	Distance float64 `json:"distance"`
+type serverAPISessionsKickRes struct {
+	err error
 }
 
-// GetType returns the packet type for MarkerPacket
-func (m MarkerPacket) GetType() string {
-	return m.Type
+type serverAPISessionsKickReq struct {
+	uuid uuid.UUID
+	res  chan serverAPISessionsKickRes
 }
 
-// PacketWrapper is a wrapper that holds any type of Packet
-type PacketWrapper struct {
-	Packet Packet
+type serverAddSessionCandidatesRes struct {
+	se  *session
+	err error
 }
 
-var (
-	upgrader = websocket.Upgrader{
-		WriteBufferSize: 1024,
-		// Allow all origins for testing
-		CheckOrigin: func(r *http.Request) bool {
-			return true
-		},
-	}
-	dataChannels []*webrtc.DataChannel
-	dcMux        sync.Mutex
-	once         sync.Once // * Ensures broadcastGPSData starts only once
-)
+type serverAddSessionCandidatesReq struct {
+	secret uuid.UUID
+	res    chan serverAddSessionCandidatesRes
+}
 
-// * Client represents a connected WebSocket client
-type Client struct {
-	conn *websocket.Conn
-	mu   sync.Mutex
+type serverDeleteSessionReq struct {
+	secret uuid.UUID
+	res    chan error
+}
 
-	ICEServers *conf.WebRTCICEServers
-	conf       *conf.GPSConfig
+type serverAuthManager interface {
+	Authenticate(req *auth.Request) error
+}
 
-	rawDataLog bool
+// PathManager returns the telemetry hub of a path.
+type PathManager interface {
+	BeaconHub(pathName string) (*Hub, error)
 }
 
 func parseICEServers(config conf.WebRTCICEServers) []webrtc.ICEServer {
@@ -103,11 +108,8 @@ func parseICEServers(config conf.WebRTCICEServers) []webrtc.ICEServer {
 			URLs: []string{server.URL},
 		}
 
-		if server.Username != "" {
+		if server.Username != "" || server.Password != "" {
 			iceServer.Username = server.Username
-		}
-
-		if server.Password != "" {
 			iceServer.Credential = server.Password
 			iceServer.CredentialType = webrtc.ICECredentialTypePassword
 		}
@@ -118,317 +120,701 @@ func parseICEServers(config conf.WebRTCICEServers) []webrtc.ICEServer {
 	return iceServers
 }
 
-func ICEHandler(w http.ResponseWriter, r *http.Request, ICEServers *conf.WebRTCICEServers) {
-	w.Header().Set("Content-Type", "application/json")
-	mappedServers := make([]map[string]interface{}, len(*ICEServers))
+// Server is the GPS server.
+// It performs the signaling of WebRTC sessions that receive the telemetry of a path
+// and receives telemetry from devices, through WebSocket or TCP.
+type Server struct {
+	Address               string
+	TCPAddress            string
+	Encryption            bool
+	ServerKey             string
+	ServerCert            string
+	AllowOrigin           string
+	TrustedProxies        conf.IPNetworks
+	ReadTimeout           conf.StringDuration
+	ICEServers            conf.WebRTCICEServers
+	ICEUDPMux             ice.UDPMux // shared with the WebRTC server
+	ICETCPMux             ice.TCPMux // shared with the WebRTC server
+	HandshakeTimeout      conf.StringDuration
+	IPsFromInterfaces     bool
+	IPsFromInterfacesList []string
+	AdditionalHosts       []string
+	AuthManager           serverAuthManager
+	PathManager           PathManager
+	Parent                logger.Writer
+
+	ctx         context.Context
+	ctxCancel   func()
+	wg          sync.WaitGroup
+	upgrader    *websocket.Upgrader
+	httpServer  *httpp.Server
+	tcpListener net.Listener
+	sessions    map[*session]struct{}
+
+	// in
+	chNewSession      chan *session
+	chCloseSession    chan *session
+	chAPISessionsList chan serverAPISessionsListReq
+	chAPISessionsGet  chan serverAPISessionsGetReq
+	chAPISessionsKick chan serverAPISessionsKickReq
+	chAddCandidates   chan serverAddSessionCandidatesReq
+	chDeleteSession   chan serverDeleteSessionReq
+}
 
-	for i, server := range *ICEServers {
-		mapped := map[string]interface{}{
-			"urls": server.URL,
-		}
+// Initialize initializes Server.
+func (s *Server) Initialize() error {
+	s.ctx, s.ctxCancel = context.WithCancel(context.Background())
 
-		mapped["username"] = server.Username
-		mapped["credential"] = server.Password
-		mappedServers[i] = mapped
+	s.sessions = make(map[*session]struct{})
+	s.chNewSession = make(chan *session)
+	s.chCloseSession = make(chan *session)
+	s.chAPISessionsList = make(chan serverAPISessionsListReq)
+	s.chAPISessionsGet = make(chan serverAPISessionsGetReq)
+	s.chAPISessionsKick = make(chan serverAPISessionsKickReq)
+	s.chAddCandidates = make(chan serverAddSessionCandidatesReq)
+	s.chDeleteSession = make(chan serverDeleteSessionReq)
+
+	s.upgrader = &websocket.Upgrader{
+		WriteBufferSize: 1024,
+		CheckOrigin:     s.checkOrigin,
 	}
 
-	jsonResponse, err := json.Marshal(mappedServers)
+	router := gin.New()
+	router.SetTrustedProxies(s.TrustedProxies.ToTrustedProxies()) //nolint:errcheck
+
+	router.Use(s.middlewareOrigin)
+
+	router.GET("/ice", s.onICE)
+	router.GET("/state", s.onState)
+	router.GET("/gps-ws", s.onWebSocket)
+	router.GET("/gps-publish", s.onPublish)
+	router.OPTIONS("/whep", s.onWHEPOptions)
+	router.POST("/whep", s.onWHEPPost)
+	router.PATCH("/whep/:secret", s.onWHEPPatch)
+	router.DELETE("/whep/:secret", s.onWHEPDelete)
+
+	network, address := restrictnetwork.Restrict("tcp", s.Address)
+
+	s.httpServer = &httpp.Server{
+		Network:     network,
+		Address:     address,
+		ReadTimeout: time.Duration(s.ReadTimeout),
+		Encryption:  s.Encryption,
+		ServerCert:  s.ServerCert,
+		ServerKey:   s.ServerKey,
+		Handler:     router,
+		Parent:      s,
+	}
+	err := s.httpServer.Initialize()
 	if err != nil {
-		http.Error(w, "Unable to marshal ICE servers", http.StatusInternalServerError)
-		return
+		s.ctxCancel()
+		return err
 	}
 
-	w.Write(jsonResponse)
-}
+	s.Log(logger.Info, "listener opened on "+address)
 
-func EnableCORS(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+	if s.TCPAddress != "" {
+		network, address = restrictnetwork.Restrict("tcp", s.TCPAddress)
 
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
+		s.tcpListener, err = net.Listen(network, address)
+		if err != nil {
+			s.httpServer.Close()
+			s.ctxCancel()
+			return err
 		}
 
-		next.ServeHTTP(w, r)
-	})
-}
+		s.Log(logger.Info, "TCP listener opened on "+address)
 
-func HandleBeaconStreamWebSocket(w http.ResponseWriter, r *http.Request, conf *conf.GPSConfig, ICEServers *conf.WebRTCICEServers, rawDataLog bool) {
-	conn, err := upgrader.Upgrade(w, r, nil)
-	if err != nil {
-		log.Printf("WebSocket upgrade error: %v", err)
-		return
+		s.wg.Add(1)
+		go s.runTCPListener()
 	}
-	defer conn.Close()
 
-	client := &Client{conn: conn, conf: conf, ICEServers: ICEServers, rawDataLog: rawDataLog}
-	handleSignaling(client)
+	s.wg.Add(1)
+	go s.run()
+
+	return nil
 }
 
-func handleSignaling(client *Client) {
-	api := webrtc.NewAPI()
-	config := webrtc.Configuration{
-		ICEServers: parseICEServers(*client.ICEServers),
-	}
+// Close closes Server.
+func (s *Server) Close() {
+	s.Log(logger.Info, "listener is closing")
+	s.ctxCancel()
+	s.wg.Wait()
+}
 
-	peerConnection, err := api.NewPeerConnection(config)
-	if err != nil {
-		log.Printf("Failed to create PeerConnection: %v", err)
-		return
-	}
+// Log implements logger.Writer.
+func (s *Server) Log(level logger.Level, format string, args ...interface{}) {
+	s.Parent.Log(level, "[GPS] "+format, args...)
+}
 
-	dataChannel, err := peerConnection.CreateDataChannel("data", nil)
-	if err != nil {
-		log.Printf("Failed to create DataChannel: %v", err)
-		return
-	}
+func (s *Server) run() {
+	defer s.wg.Done()
 
-	dataChannel.OnOpen(func() {
-		log.Printf("DataChannel opened for client")
-		addDataChannel(dataChannel)
-
-		// * Start the broadcaster only once
-		once.Do(func() {
-			switch client.conf.Protocol {
-			case "ws":
-				go broadcastGPSDataByWebsocket(fmt.Sprintf("%s://%s:%d", client.conf.Protocol, client.conf.IPAddress, client.conf.Port))
-			case "tcp":
-				go broadcastGPSDataByTCP(fmt.Sprintf("%s:%d", client.conf.IPAddress, client.conf.Port))
-			case "udp":
-				go broadcastGPSDataByUDP(fmt.Sprintf("%s:%d", client.conf.IPAddress, client.conf.Port), client.rawDataLog)
-			default:
-				log.Printf("No data source found")
-			}
-		})
-	})
+outer:
+	for {
+		select {
+		case se := <-s.chNewSession:
+			se.initialize()
+			s.sessions[se] = struct{}{}
 
-	dataChannel.OnClose(func() {
-		log.Printf("DataChannel closed for client")
-		removeDataChannel(dataChannel)
-	})
+		case se := <-s.chCloseSession:
+			delete(s.sessions, se)
 
-	peerConnection.OnICECandidate(func(c *webrtc.ICECandidate) {
-		if c == nil {
-			return
-		}
-		candidateJSON, err := json.Marshal(c.ToJSON())
-		if err != nil {
-			log.Printf("Failed to marshal ICE candidate: %v", err)
-			return
-		}
-		message := Message{Candidate: string(candidateJSON)}
-		sendMessage(client, message)
-	})
+		case req := <-s.chAPISessionsList:
+			data := &defs.APIBeaconSessionList{
+				Items: []*defs.APIBeaconSession{},
+			}
 
-	for {
-		_, msgBytes, err := client.conn.ReadMessage()
-		if err != nil {
-			log.Printf("WebSocket read error: %v", err)
-			return
-		}
+			for se := range s.sessions {
+				data.Items = append(data.Items, se.apiItem())
+			}
 
-		var msg Message
-		if err := json.Unmarshal(msgBytes, &msg); err != nil {
-			log.Printf("Failed to unmarshal message: %v", err)
-			continue
-		}
+			sort.Slice(data.Items, func(i, j int) bool {
+				return data.Items[i].Created.Before(data.Items[j].Created)
+			})
 
-		if msg.SDP != "" {
-			var sdp webrtc.SessionDescription
-			if err := json.Unmarshal([]byte(msg.SDP), &sdp); err != nil {
-				log.Printf("Failed to unmarshal SDP: %v", err)
+			req.res <- serverAPISessionsListRes{data: data}
+
+		case req := <-s.chAPISessionsGet:
+			se := s.findSessionByUUID(req.uuid)
+			if se == nil {
+				req.res <- serverAPISessionsGetRes{err: ErrSessionNotFound}
 				continue
 			}
 
-			if err := peerConnection.SetRemoteDescription(sdp); err != nil {
-				log.Printf("Failed to set remote description: %v", err)
+			req.res <- serverAPISessionsGetRes{data: se.apiItem()}
+
+		case req := <-s.chAPISessionsKick:
+			se := s.findSessionByUUID(req.uuid)
+			if se == nil {
+				req.res <- serverAPISessionsKickRes{err: ErrSessionNotFound}
 				continue
 			}
 
-			if sdp.Type == webrtc.SDPTypeOffer {
-				answer, err := peerConnection.CreateAnswer(nil)
-				if err != nil {
-					log.Printf("Failed to create answer: %v", err)
-					continue
-				}
-
-				if err := peerConnection.SetLocalDescription(answer); err != nil {
-					log.Printf("Failed to set local description: %v", err)
-					continue
-				}
-
-				gatherComplete := webrtc.GatheringCompletePromise(peerConnection)
-				<-gatherComplete
-
-				localDesc := peerConnection.LocalDescription()
-				localSDP, err := json.Marshal(localDesc)
-				if err != nil {
-					log.Printf("Failed to marshal local description: %v", err)
-					continue
-				}
-
-				response := Message{SDP: string(localSDP)}
-				sendMessage(client, response)
-			}
-		}
+			delete(s.sessions, se)
+			se.Close()
 
-		if msg.Candidate != "" {
-			var candidate webrtc.ICECandidateInit
-			if err := json.Unmarshal([]byte(msg.Candidate), &candidate); err != nil {
-				log.Printf("Failed to unmarshal ICE candidate: %v", err)
+			req.res <- serverAPISessionsKickRes{}
+
+		case req := <-s.chAddCandidates:
+			se := s.findSessionBySecret(req.secret)
+			if se == nil {
+				req.res <- serverAddSessionCandidatesRes{err: ErrSessionNotFound}
 				continue
 			}
 
-			if err := peerConnection.AddICECandidate(candidate); err != nil {
-				log.Printf("Failed to add ICE candidate: %v", err)
+			req.res <- serverAddSessionCandidatesRes{se: se}
+
+		case req := <-s.chDeleteSession:
+			se := s.findSessionBySecret(req.secret)
+			if se == nil {
+				req.res <- ErrSessionNotFound
 				continue
 			}
+
+			delete(s.sessions, se)
+			se.Close()
+
+			req.res <- nil
+
+		case <-s.ctx.Done():
+			break outer
 		}
 	}
-}
 
-func addDataChannel(dc *webrtc.DataChannel) {
-	dcMux.Lock()
-	defer dcMux.Unlock()
-	dataChannels = append(dataChannels, dc)
+	s.ctxCancel()
+
+	if s.tcpListener != nil {
+		s.tcpListener.Close()
+	}
+
+	s.httpServer.Close()
 }
 
-func removeDataChannel(dc *webrtc.DataChannel) {
-	dcMux.Lock()
-	defer dcMux.Unlock()
-	for i, channel := range dataChannels {
-		if channel == dc {
-			dataChannels = append(dataChannels[:i], dataChannels[i+1:]...)
-			break
+func (s *Server) findSessionByUUID(uuid uuid.UUID) *session {
+	for se := range s.sessions {
+		if se.uuid == uuid {
+			return se
 		}
 	}
+	return nil
 }
 
-func broadcastGPSDataByWebsocket(serverUrl string) {
-	for {
-		log.Printf("Connecting to external WebSocket server at %s", serverUrl)
-		c, _, err := websocket.DefaultDialer.Dial(serverUrl, nil)
-		if err != nil {
-			log.Printf("Failed to connect to external WebSocket server: %v", err)
-			time.Sleep(5 * time.Second)
-			continue
+// findSessionBySecret returns a session created with WHEP-style signaling.
+func (s *Server) findSessionBySecret(secret uuid.UUID) *session {
+	for se := range s.sessions {
+		if se.conn == nil && se.secret == secret {
+			return se
 		}
+	}
+	return nil
+}
 
-		log.Printf("Connected to external WebSocket server")
+func (s *Server) middlewareOrigin(ctx *gin.Context) {
+	ctx.Header("Access-Control-Allow-Origin", s.AllowOrigin)
+	ctx.Header("Access-Control-Allow-Credentials", "true")
 
-		for {
-			_, msgBytes, err := c.ReadMessage()
-			if err != nil {
-				log.Printf("Error reading from external WebSocket: %v", err)
-				c.Close()
-				break
-			}
+	// preflight requests
+	if ctx.Request.Method == http.MethodOptions &&
+		ctx.Request.Header.Get("Access-Control-Request-Method") != "" {
+		ctx.Header("Access-Control-Allow-Methods", "OPTIONS, GET, POST, PATCH, DELETE")
+		ctx.Header("Access-Control-Allow-Headers", "Authorization, Content-Type, If-Match")
+		ctx.AbortWithStatus(http.StatusNoContent)
+		return
+	}
+}
 
-			broadcastRawDataToDataChannels(msgBytes)
+// checkOrigin allows WebSocket connections from the origins allowed by AllowOrigin
+// and from the origin of the server itself.
+func (s *Server) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || s.AllowOrigin == "*" || origin == s.AllowOrigin {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+func (s *Server) doAuth(ctx *gin.Context, action conf.AuthAction, pathName string) (string, bool) {
+	req := &auth.Request{
+		IP:          net.ParseIP(ctx.ClientIP()),
+		Action:      action,
+		Path:        pathName,
+		HTTPRequest: ctx.Request,
+	}
+
+	err := s.AuthManager.Authenticate(req)
+	if err != nil {
+		if err.(*auth.Error).AskCredentials { //nolint:errorlint
+			ctx.Header("WWW-Authenticate", `Basic realm="mediamtx"`)
+			ctx.Writer.WriteHeader(http.StatusUnauthorized)
+			return "", false
 		}
+
+		s.Log(logger.Info, "connection %v failed to authenticate: %v",
+			httpp.RemoteAddr(ctx), err.(*auth.Error).Message) //nolint:errorlint
+
+		// wait some seconds to mitigate brute force attacks
+		<-time.After(auth.PauseAfterError)
+
+		ctx.Writer.WriteHeader(http.StatusUnauthorized)
+		return "", false
 	}
+
+	return req.User, true
 }
 
-// Connects to a TCP server and expects the same gps data
-//
-// For dev context: Connection to the tcp server can be tested by running the nc_tcp_server_test.sh file and should see output like
-//
-//	DataChannel opened for client
-//	Connecting to TCP server at 0.0.0.0:13370
-//	Connected to TCP server at 0.0.0.0:13370
-func broadcastGPSDataByTCP(serverUrl string) {
-	for {
-		log.Printf("Connecting to TCP server at %s", serverUrl)
-		conn, err := net.Dial("tcp", serverUrl)
-		if err != nil {
-			log.Printf("Failed to connect to TCP server: %v", err)
-			time.Sleep(5 * time.Second)
-			continue
+// canCommand returns whether the client is allowed to send commands to the upstream.
+// Unlike doAuth, it never asks for credentials, since commands are optional.
+func (s *Server) canCommand(ctx *gin.Context, pathName string) bool {
+	err := s.AuthManager.Authenticate(&auth.Request{
+		IP:          net.ParseIP(ctx.ClientIP()),
+		Action:      conf.AuthActionCommand,
+		Path:        pathName,
+		HTTPRequest: ctx.Request,
+	})
+	return err == nil
+}
+
+func (s *Server) onICE(ctx *gin.Context) {
+	mappedServers := make([]map[string]interface{}, len(s.ICEServers))
+
+	for i, server := range s.ICEServers {
+		mappedServers[i] = map[string]interface{}{
+			"urls":       server.URL,
+			"username":   server.Username,
+			"credential": server.Password,
 		}
-		log.Printf("Connected to TCP server at %s", serverUrl)
-		reader := bufio.NewReader(conn)
-		for {
-			line, err := reader.ReadBytes('\n')
-			if err != nil {
-				log.Printf("Error reading from TCP server: %v", err)
-				conn.Close()
-				break
-			}
+	}
+
+	ctx.JSON(http.StatusOK, mappedServers)
+}
+
+type stateEntry struct {
+	NTP    time.Time       `json:"ntp"`
+	Packet json.RawMessage `json:"packet"`
+}
+
+// onState returns the last packet received of each type by the path
+// passed in the "path" query parameter.
+func (s *Server) onState(ctx *gin.Context) {
+	pathName := ctx.Query("path")
+	if pathName == "" {
+		ctx.String(http.StatusBadRequest, "missing 'path' query parameter")
+		return
+	}
 
-			broadcastRawDataToDataChannels(line)
+	_, ok := s.doAuth(ctx, conf.AuthActionTelemetry, pathName)
+	if !ok {
+		return
+	}
+
+	hub, err := s.PathManager.BeaconHub(pathName)
+	if err != nil {
+		ctx.String(http.StatusNotFound, err.Error())
+		return
+	}
+
+	out := make(map[string]stateEntry)
+
+	for typ, rpkt := range hub.LatestPackets() {
+		out[typ] = stateEntry{
+			NTP:    rpkt.NTP,
+			Packet: rpkt.Data,
 		}
 	}
+
+	ctx.JSON(http.StatusOK, out)
+}
+
+// onWebSocket handles the signaling of a client that wants to
+// receive the telemetry of the path passed in the "path" query parameter.
+func (s *Server) onWebSocket(ctx *gin.Context) {
+	pathName := ctx.Query("path")
+	if pathName == "" {
+		ctx.String(http.StatusBadRequest, "missing 'path' query parameter")
+		return
+	}
+
+	user, ok := s.doAuth(ctx, conf.AuthActionTelemetry, pathName)
+	if !ok {
+		return
+	}
+
+	hub, err := s.PathManager.BeaconHub(pathName)
+	if err != nil {
+		ctx.String(http.StatusNotFound, err.Error())
+		return
+	}
+
+	conn, err := s.upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		s.Log(logger.Warn, "WebSocket upgrade error: %v", err)
+		return
+	}
+
+	se := &session{
+		conn:                  conn,
+		hub:                   hub,
+		iceServers:            s.ICEServers,
+		iceUDPMux:             s.ICEUDPMux,
+		iceTCPMux:             s.ICETCPMux,
+		handshakeTimeout:      s.HandshakeTimeout,
+		ipsFromInterfaces:     s.IPsFromInterfaces,
+		ipsFromInterfacesList: s.IPsFromInterfacesList,
+		additionalHosts:       s.AdditionalHosts,
+		remoteAddr:            httpp.RemoteAddr(ctx),
+		pathName:              pathName,
+		user:                  user,
+		canCommand:            hub.Conf.MaxCommandRate > 0 && s.canCommand(ctx, pathName),
+		parentCtx:             s.ctx,
+		wg:                    &s.wg,
+		parent:                s,
+	}
+
+	// the HTTP handler returns immediately; the session is closed
+	// when the client disconnects or the server is closed.
+	select {
+	case s.chNewSession <- se:
+	case <-s.ctx.Done():
+		conn.Close() //nolint:errcheck
+	}
 }
 
-// Connects to a UDP server and expects the same gps data
-//
-// For dev context: Connection to the udp server can be tested by running the nc_udp_server_test.sh file and should see output like
-// In UDP communication, there's no concept of a persistent connection like there is in TCP; instead, you send and receive messages to and from ports.
-// net.ListenUDP function binds to a local address and port to receive UDP packets
-//
-//	DataChannel opened for client
-//	Connecting to UDP server at 0.0.0.0:13370
-//	Connected to UDP server at 0.0.0.0:13370
-func broadcastGPSDataByUDP(serverUrl string, rawDataLog bool) {
-	udpAddr, err := net.ResolveUDPAddr("udp", serverUrl)
+// onPublish handles a device that wants to publish telemetry
+// to the path passed in the "path" query parameter.
+func (s *Server) onPublish(ctx *gin.Context) {
+	pathName := ctx.Query("path")
+	if pathName == "" {
+		ctx.String(http.StatusBadRequest, "missing 'path' query parameter")
+		return
+	}
+
+	user, ok := s.doAuth(ctx, conf.AuthActionPublish, pathName)
+	if !ok {
+		return
+	}
+
+	hub, err := s.PathManager.BeaconHub(pathName)
 	if err != nil {
-		log.Printf("Failed to resolve UDP address: %v", err)
+		ctx.String(http.StatusNotFound, err.Error())
 		return
 	}
 
-	conn, err := net.ListenUDP("udp", udpAddr)
+	pub := &publisher{
+		hub:        hub,
+		remoteAddr: httpp.RemoteAddr(ctx),
+		pathName:   pathName,
+		user:       user,
+		parentCtx:  s.ctx,
+		wg:         &s.wg,
+		parent:     s,
+	}
+	pub.initialize()
+
+	// the connection is upgraded before being added to the hub,
+	// in order not to replace an existing publisher with a failed connection.
+	conn, err := s.upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
 	if err != nil {
-		log.Printf("Failed to listen on UDP address: %v", err)
+		s.Log(logger.Warn, "WebSocket upgrade error: %v", err)
 		return
 	}
-	defer conn.Close()
 
-	log.Printf("Listening on UDP address %s", serverUrl)
+	pub.wsConn = conn
 
-	buf := make([]byte, 4096)
-	for {
-		n, addr, err := conn.ReadFromUDP(buf)
-		if err != nil {
-			log.Printf("Error reading from UDP: %v", err)
-			time.Sleep(5 * time.Second)
-			continue
-		}
+	err = hub.AddPublisher(pub)
+	if err != nil {
+		conn.WriteControl(websocket.CloseMessage, //nolint:errcheck
+			websocket.FormatCloseMessage(websocket.ClosePolicyViolation, err.Error()),
+			time.Now().Add(time.Duration(s.ReadTimeout)))
+		conn.Close() //nolint:errcheck
+		return
+	}
 
-		if rawDataLog {
-			rawData := buf[:n]
-			log.Printf("Received raw data from %s: %s", addr.String(), string(rawData))
-		}
+	pub.start()
+}
+
+// onWHEPOptions returns the ICE servers through Link headers.
+func (s *Server) onWHEPOptions(ctx *gin.Context) {
+	pathName := ctx.Query("path")
+	if pathName == "" {
+		ctx.String(http.StatusBadRequest, "missing 'path' query parameter")
+		return
+	}
 
-		broadcastRawDataToDataChannels(buf[:n])
+	_, ok := s.doAuth(ctx, conf.AuthActionTelemetry, pathName)
+	if !ok {
+		return
 	}
+
+	ctx.Header("Access-Control-Allow-Methods", "OPTIONS, GET, POST, PATCH, DELETE")
+	ctx.Header("Access-Control-Allow-Headers", "Authorization, Content-Type, If-Match")
+	ctx.Header("Access-Control-Expose-Headers", "Link")
+	ctx.Writer.Header()["Link"] = whip.LinkHeaderMarshal(parseICEServers(s.ICEServers))
+	ctx.Writer.WriteHeader(http.StatusNoContent)
 }
 
-// * Sends the raw JSON data to all connected WebRTC DataChannels.
-func broadcastRawDataToDataChannels(data []byte) {
-	dcMux.Lock()
-	defer dcMux.Unlock()
-	for _, dc := range dataChannels {
-		if dc.ReadyState() == webrtc.DataChannelStateOpen {
-			if err := dc.Send(data); err != nil {
-				log.Printf("Failed to send data to DataChannel: %v", err)
-			}
+// onWHEPPost creates a data-only session that receives the telemetry
+// of the path passed in the "path" query parameter, with WHEP-style signaling.
+func (s *Server) onWHEPPost(ctx *gin.Context) {
+	contentType := httpp.ParseContentType(ctx.Request.Header.Get("Content-Type"))
+	if contentType != "application/sdp" {
+		ctx.String(http.StatusBadRequest, "invalid Content-Type")
+		return
+	}
+
+	pathName := ctx.Query("path")
+	if pathName == "" {
+		ctx.String(http.StatusBadRequest, "missing 'path' query parameter")
+		return
+	}
+
+	user, ok := s.doAuth(ctx, conf.AuthActionTelemetry, pathName)
+	if !ok {
+		return
+	}
+
+	hub, err := s.PathManager.BeaconHub(pathName)
+	if err != nil {
+		ctx.String(http.StatusNotFound, err.Error())
+		return
+	}
+
+	offer, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		return
+	}
+
+	se := &session{
+		whepOffer:             offer,
+		whepAnswer:            make(chan sessionWHEPAnswer, 1),
+		hub:                   hub,
+		iceServers:            s.ICEServers,
+		iceUDPMux:             s.ICEUDPMux,
+		iceTCPMux:             s.ICETCPMux,
+		handshakeTimeout:      s.HandshakeTimeout,
+		ipsFromInterfaces:     s.IPsFromInterfaces,
+		ipsFromInterfacesList: s.IPsFromInterfacesList,
+		additionalHosts:       s.AdditionalHosts,
+		remoteAddr:            httpp.RemoteAddr(ctx),
+		pathName:              pathName,
+		user:                  user,
+		canCommand:            hub.Conf.MaxCommandRate > 0 && s.canCommand(ctx, pathName),
+		parentCtx:             s.ctx,
+		wg:                    &s.wg,
+		parent:                s,
+	}
+
+	select {
+	case s.chNewSession <- se:
+	case <-s.ctx.Done():
+		ctx.String(http.StatusInternalServerError, "terminated")
+		return
+	}
+
+	res := <-se.whepAnswer
+	if res.err != nil {
+		ctx.String(http.StatusBadRequest, res.err.Error())
+		return
+	}
+
+	ctx.Header("Content-Type", "application/sdp")
+	ctx.Header("Access-Control-Expose-Headers", "ETag, ID, Accept-Patch, Link, Location")
+	ctx.Header("ETag", "*")
+	ctx.Header("ID", se.uuid.String())
+	ctx.Header("Accept-Patch", "application/trickle-ice-sdpfrag")
+	ctx.Writer.Header()["Link"] = whip.LinkHeaderMarshal(parseICEServers(s.ICEServers))
+	ctx.Header("Location", "/whep/"+se.secret.String())
+	ctx.Writer.WriteHeader(http.StatusCreated)
+	ctx.Writer.Write(res.answer)
+}
+
+// onWHEPPatch adds remote candidates to a session.
+func (s *Server) onWHEPPatch(ctx *gin.Context) {
+	secret, err := uuid.Parse(ctx.Param("secret"))
+	if err != nil {
+		ctx.String(http.StatusBadRequest, "invalid secret")
+		return
+	}
+
+	contentType := httpp.ParseContentType(ctx.Request.Header.Get("Content-Type"))
+	if contentType != "application/trickle-ice-sdpfrag" {
+		ctx.String(http.StatusBadRequest, "invalid Content-Type")
+		return
+	}
+
+	byts, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		return
+	}
+
+	candidates, err := whip.ICEFragmentUnmarshal(byts)
+	if err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	req := serverAddSessionCandidatesReq{
+		secret: secret,
+		res:    make(chan serverAddSessionCandidatesRes),
+	}
+
+	var res serverAddSessionCandidatesRes
+
+	select {
+	case s.chAddCandidates <- req:
+		res = <-req.res
+	case <-s.ctx.Done():
+		res.err = fmt.Errorf("terminated")
+	}
+
+	if res.err == nil {
+		res.err = res.se.addRemoteCandidates(candidates)
+	}
+
+	if res.err != nil {
+		if errors.Is(res.err, ErrSessionNotFound) {
+			ctx.String(http.StatusNotFound, res.err.Error())
+		} else {
+			ctx.String(http.StatusBadRequest, res.err.Error())
 		}
+		return
 	}
+
+	ctx.Writer.WriteHeader(http.StatusNoContent)
 }
 
-func sendMessage(client *Client, msg Message) {
-	client.mu.Lock()
-	defer client.mu.Unlock()
+// onWHEPDelete closes a session.
+func (s *Server) onWHEPDelete(ctx *gin.Context) {
+	secret, err := uuid.Parse(ctx.Param("secret"))
+	if err != nil {
+		ctx.String(http.StatusBadRequest, "invalid secret")
+		return
+	}
+
+	req := serverDeleteSessionReq{
+		secret: secret,
+		res:    make(chan error),
+	}
+
+	select {
+	case s.chDeleteSession <- req:
+		err = <-req.res
+	case <-s.ctx.Done():
+		err = fmt.Errorf("terminated")
+	}
 
-	msgBytes, err := json.Marshal(msg)
 	if err != nil {
-		log.Printf("Failed to marshal message: %v", err)
+		if errors.Is(err, ErrSessionNotFound) {
+			ctx.String(http.StatusNotFound, err.Error())
+		} else {
+			ctx.String(http.StatusInternalServerError, err.Error())
+		}
 		return
 	}
 
-	if err := client.conn.WriteMessage(websocket.TextMessage, msgBytes); err != nil {
-		log.Printf("WebSocket write error: %v", err)
+	ctx.Writer.WriteHeader(http.StatusOK)
+}
+
+// closeSession is called by session.
+func (s *Server) closeSession(se *session) {
+	select {
+	case s.chCloseSession <- se:
+	case <-s.ctx.Done():
+	}
+}
+
+// APISessionsList is called by api.
+func (s *Server) APISessionsList() (*defs.APIBeaconSessionList, error) {
+	req := serverAPISessionsListReq{
+		res: make(chan serverAPISessionsListRes),
+	}
+
+	select {
+	case s.chAPISessionsList <- req:
+		res := <-req.res
+		return res.data, res.err
+
+	case <-s.ctx.Done():
+		return nil, fmt.Errorf("terminated")
+	}
+}
+
+// APISessionsGet is called by api.
+func (s *Server) APISessionsGet(uuid uuid.UUID) (*defs.APIBeaconSession, error) {
+	req := serverAPISessionsGetReq{
+		uuid: uuid,
+		res:  make(chan serverAPISessionsGetRes),
+	}
+
+	select {
+	case s.chAPISessionsGet <- req:
+		res := <-req.res
+		return res.data, res.err
+
+	case <-s.ctx.Done():
+		return nil, fmt.Errorf("terminated")
+	}
+}
+
+// APISessionsKick is called by api.
+func (s *Server) APISessionsKick(uuid uuid.UUID) error {
+	req := serverAPISessionsKickReq{
+		uuid: uuid,
+		res:  make(chan serverAPISessionsKickRes),
+	}
+
+	select {
+	case s.chAPISessionsKick <- req:
+		res := <-req.res
+		return res.err
+
+	case <-s.ctx.Done():
+		return fmt.Errorf("terminated")
 	}
 }
@@ -0,0 +1,720 @@
+package beacon_stream
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/auth"
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/test"
+)
+
+type dummyPathManager struct {
+	hub *Hub
+}
+
+func (pm *dummyPathManager) BeaconHub(pathName string) (*Hub, error) {
+	if pathName != "mypath" {
+		return nil, fmt.Errorf("path '%s' has no GPS source", pathName)
+	}
+	return pm.hub, nil
+}
+
+type dummyAuthManager struct {
+	fnc func(req *auth.Request) error
+}
+
+func (m *dummyAuthManager) Authenticate(req *auth.Request) error {
+	return m.fnc(req)
+}
+
+func TestServerICE(t *testing.T) {
+	s := &Server{
+		Address:     "127.0.0.1:8080",
+		AllowOrigin: "http://example.com",
+		ReadTimeout: conf.StringDuration(10 * time.Second),
+		ICEServers: conf.WebRTCICEServers{{
+			URL:      "turn:myturn:3478",
+			Username: "myuser",
+			Password: "mypass",
+		}},
+		AuthManager: test.NilAuthManager,
+		PathManager: &dummyPathManager{},
+		Parent:      test.NilLogger,
+	}
+	err := s.Initialize()
+	require.NoError(t, err)
+	defer s.Close()
+
+	res, err := http.Get("http://localhost:8080/ice")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, "http://example.com", res.Header.Get("Access-Control-Allow-Origin"))
+
+	var out []map[string]interface{}
+	err = json.NewDecoder(res.Body).Decode(&out)
+	require.NoError(t, err)
+
+	require.Equal(t, []map[string]interface{}{{
+		"urls":       "turn:myturn:3478",
+		"username":   "myuser",
+		"credential": "mypass",
+	}}, out)
+}
+
+func TestServerPathNotFound(t *testing.T) {
+	s := &Server{
+		Address:     "127.0.0.1:8080",
+		ReadTimeout: conf.StringDuration(10 * time.Second),
+		AuthManager: test.NilAuthManager,
+		PathManager: &dummyPathManager{},
+		Parent:      test.NilLogger,
+	}
+	err := s.Initialize()
+	require.NoError(t, err)
+	defer s.Close()
+
+	_, res, err := websocket.DefaultDialer.Dial("ws://localhost:8080/gps-ws?path=otherpath", nil)
+	require.Error(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestServerCloseSessions(t *testing.T) {
+	h := &Hub{
+		Conf: conf.GPSConfig{
+			Protocol:  "udp",
+			IPAddress: "127.0.0.1",
+			Port:      9132,
+		},
+		Parent: test.NilLogger,
+	}
+	h.Initialize()
+	defer h.Close()
+
+	s := &Server{
+		Address:     "127.0.0.1:8080",
+		ReadTimeout: conf.StringDuration(10 * time.Second),
+		AuthManager: test.NilAuthManager,
+		PathManager: &dummyPathManager{hub: h},
+		Parent:      test.NilLogger,
+	}
+	err := s.Initialize()
+	require.NoError(t, err)
+
+	c, res, err := websocket.DefaultDialer.Dial("ws://localhost:8080/gps-ws?path=mypath", nil)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	defer c.Close()
+
+	s.Close()
+
+	// the server closes the WebSocket connection of active sessions.
+	c.SetReadDeadline(time.Now().Add(2 * time.Second)) //nolint:errcheck
+	_, _, err = c.ReadMessage()
+	require.Error(t, err)
+
+	var netErr net.Error
+	require.False(t, errors.As(err, &netErr) && netErr.Timeout())
+}
+
+func TestServerAuth(t *testing.T) {
+	s := &Server{
+		Address:     "127.0.0.1:8080",
+		ReadTimeout: conf.StringDuration(10 * time.Second),
+		AuthManager: &dummyAuthManager{
+			fnc: func(req *auth.Request) error {
+				require.Equal(t, conf.AuthActionTelemetry, req.Action)
+				require.Equal(t, "mypath", req.Path)
+
+				user, pass, ok := req.HTTPRequest.BasicAuth()
+				if !ok {
+					return &auth.Error{AskCredentials: true}
+				}
+
+				if user != "myuser" || pass != "mypass" {
+					return &auth.Error{Message: "wrong credentials"}
+				}
+
+				return nil
+			},
+		},
+		PathManager: &dummyPathManager{},
+		Parent:      test.NilLogger,
+	}
+	err := s.Initialize()
+	require.NoError(t, err)
+	defer s.Close()
+
+	_, res, err := websocket.DefaultDialer.Dial("ws://localhost:8080/gps-ws?path=mypath", nil)
+	require.Error(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	require.Equal(t, `Basic realm="mediamtx"`, res.Header.Get("WWW-Authenticate"))
+}
+
+func TestServerCheckOrigin(t *testing.T) {
+	for _, ca := range []struct {
+		name        string
+		allowOrigin string
+		origin      string
+		allowed     bool
+	}{
+		{
+			"no origin",
+			"http://example.com",
+			"",
+			true,
+		},
+		{
+			"any",
+			"*",
+			"http://other.com",
+			true,
+		},
+		{
+			"allowed",
+			"http://example.com",
+			"http://example.com",
+			true,
+		},
+		{
+			"same origin",
+			"http://example.com",
+			"http://localhost:8080",
+			true,
+		},
+		{
+			"not allowed",
+			"http://example.com",
+			"http://other.com",
+			false,
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			s := &Server{AllowOrigin: ca.allowOrigin}
+
+			req, err := http.NewRequest(http.MethodGet, "http://localhost:8080/gps-ws", nil)
+			require.NoError(t, err)
+
+			if ca.origin != "" {
+				req.Header.Set("Origin", ca.origin)
+			}
+
+			require.Equal(t, ca.allowed, s.checkOrigin(req))
+		})
+	}
+}
+
+func TestServerAPISessions(t *testing.T) {
+	h := &Hub{
+		Conf: conf.GPSConfig{
+			Protocol:  "udp",
+			IPAddress: "127.0.0.1",
+			Port:      9132,
+		},
+		Parent: test.NilLogger,
+	}
+	h.Initialize()
+	defer h.Close()
+
+	s := &Server{
+		Address:     "127.0.0.1:8080",
+		ReadTimeout: conf.StringDuration(10 * time.Second),
+		AuthManager: &dummyAuthManager{
+			fnc: func(req *auth.Request) error {
+				req.User = "myuser"
+				return nil
+			},
+		},
+		PathManager: &dummyPathManager{hub: h},
+		Parent:      test.NilLogger,
+	}
+	err := s.Initialize()
+	require.NoError(t, err)
+	defer s.Close()
+
+	c, res, err := websocket.DefaultDialer.Dial("ws://localhost:8080/gps-ws?path=mypath", nil)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	defer c.Close()
+
+	var list *defs.APIBeaconSessionList
+
+	require.Eventually(t, func() bool {
+		list, err = s.APISessionsList()
+		require.NoError(t, err)
+		return len(list.Items) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	item := list.Items[0]
+	require.Equal(t, "mypath", item.Path)
+	require.Equal(t, "myuser", item.User)
+	require.Equal(t, "connecting", item.DataChannelState)
+
+	item2, err := s.APISessionsGet(item.ID)
+	require.NoError(t, err)
+	require.Equal(t, item.ID, item2.ID)
+
+	_, err = s.APISessionsGet(uuid.New())
+	require.ErrorIs(t, err, ErrSessionNotFound)
+
+	err = s.APISessionsKick(item.ID)
+	require.NoError(t, err)
+
+	c.SetReadDeadline(time.Now().Add(2 * time.Second)) //nolint:errcheck
+	_, _, err = c.ReadMessage()
+	require.Error(t, err)
+
+	var netErr net.Error
+	require.False(t, errors.As(err, &netErr) && netErr.Timeout())
+
+	list, err = s.APISessionsList()
+	require.NoError(t, err)
+	require.Empty(t, list.Items)
+}
+
+func TestServerPublish(t *testing.T) {
+	for _, ca := range []string{"websocket", "tcp"} {
+		t.Run(ca, func(t *testing.T) {
+			h := &Hub{
+				Conf: conf.GPSConfig{
+					Protocol: "publisher",
+				},
+				Parent: test.NilLogger,
+			}
+			h.Initialize()
+			defer h.Close()
+
+			received := make(chan *ReceivedPacket, 1)
+			h.AddReader(t, func(rpkt *ReceivedPacket) {
+				received <- rpkt
+			})
+
+			s := &Server{
+				Address:     "127.0.0.1:8080",
+				TCPAddress:  "127.0.0.1:8081",
+				ReadTimeout: conf.StringDuration(10 * time.Second),
+				AuthManager: &dummyAuthManager{
+					fnc: func(req *auth.Request) error {
+						require.Equal(t, conf.AuthActionPublish, req.Action)
+						require.Equal(t, "mypath", req.Path)
+
+						if ca == "tcp" {
+							require.Equal(t, "myuser", req.User)
+							require.Equal(t, "mypass", req.Pass)
+						}
+
+						return nil
+					},
+				},
+				PathManager: &dummyPathManager{hub: h},
+				Parent:      test.NilLogger,
+			}
+			err := s.Initialize()
+			require.NoError(t, err)
+			defer s.Close()
+
+			pkt := []byte(`{"type":"attitude","values":[1,2,3],"timestamp":1}`)
+
+			if ca == "websocket" {
+				c, res, err2 := websocket.DefaultDialer.Dial("ws://localhost:8080/gps-publish?path=mypath", nil)
+				require.NoError(t, err2)
+				defer res.Body.Close()
+				defer c.Close()
+
+				err = c.WriteMessage(websocket.TextMessage, pkt)
+				require.NoError(t, err)
+			} else {
+				nconn, err2 := net.Dial("tcp", "127.0.0.1:8081")
+				require.NoError(t, err2)
+				defer nconn.Close()
+
+				_, err = nconn.Write([]byte("/mypath?user=myuser&pass=mypass\n"))
+				require.NoError(t, err)
+
+				line, err2 := bufio.NewReader(nconn).ReadString('\n')
+				require.NoError(t, err2)
+				require.Equal(t, "OK\n", line)
+
+				_, err = nconn.Write(append(pkt, '\n'))
+				require.NoError(t, err)
+			}
+
+			rpkt := <-received
+			require.Equal(t, pkt, rpkt.Data)
+			require.True(t, h.UpstreamConnected())
+		})
+	}
+}
+
+func TestServerPublishTCPErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name      string
+		handshake string
+		err       string
+	}{
+		{
+			"missing path",
+			"/\n",
+			"ERR invalid handshake: path is missing\n",
+		},
+		{
+			"path not found",
+			"/otherpath\n",
+			"ERR path 'otherpath' has no GPS source\n",
+		},
+		{
+			"already publishing",
+			"/mypath\n",
+			"ERR someone is already publishing telemetry to the path\n",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			h := &Hub{
+				Conf: conf.GPSConfig{
+					Protocol: "publisher",
+				},
+				Parent: test.NilLogger,
+			}
+			h.Initialize()
+			defer h.Close()
+
+			err := h.AddPublisher(&dummyPublisher{})
+			require.NoError(t, err)
+
+			s := &Server{
+				Address:     "127.0.0.1:8080",
+				TCPAddress:  "127.0.0.1:8081",
+				ReadTimeout: conf.StringDuration(10 * time.Second),
+				AuthManager: test.NilAuthManager,
+				PathManager: &dummyPathManager{hub: h},
+				Parent:      test.NilLogger,
+			}
+			err = s.Initialize()
+			require.NoError(t, err)
+			defer s.Close()
+
+			nconn, err := net.Dial("tcp", "127.0.0.1:8081")
+			require.NoError(t, err)
+			defer nconn.Close()
+
+			_, err = nconn.Write([]byte(ca.handshake))
+			require.NoError(t, err)
+
+			line, err := bufio.NewReader(nconn).ReadString('\n')
+			require.NoError(t, err)
+			require.Equal(t, ca.err, line)
+		})
+	}
+}
+
+func TestServerPublishWebSocketAlreadyPublishing(t *testing.T) {
+	h := &Hub{
+		Conf: conf.GPSConfig{
+			Protocol: "publisher",
+		},
+		Parent: test.NilLogger,
+	}
+	h.Initialize()
+	defer h.Close()
+
+	pub := &dummyPublisher{}
+	err := h.AddPublisher(pub)
+	require.NoError(t, err)
+
+	s := &Server{
+		Address:     "127.0.0.1:8080",
+		ReadTimeout: conf.StringDuration(10 * time.Second),
+		AuthManager: test.NilAuthManager,
+		PathManager: &dummyPathManager{hub: h},
+		Parent:      test.NilLogger,
+	}
+	err = s.Initialize()
+	require.NoError(t, err)
+	defer s.Close()
+
+	c, res, err := websocket.DefaultDialer.Dial("ws://localhost:8080/gps-publish?path=mypath", nil)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	defer c.Close()
+
+	_, _, err = c.ReadMessage()
+	var cerr *websocket.CloseError
+	require.ErrorAs(t, err, &cerr)
+	require.Equal(t, websocket.ClosePolicyViolation, cerr.Code)
+	require.Equal(t, "someone is already publishing telemetry to the path", cerr.Text)
+
+	// the existing publisher is not replaced.
+	require.False(t, pub.closed)
+}
+
+func TestServerState(t *testing.T) {
+	h := &Hub{
+		Conf: conf.GPSConfig{
+			Protocol: "publisher",
+		},
+		Parent: test.NilLogger,
+	}
+	h.Initialize()
+	defer h.Close()
+
+	pub := &dummyPublisher{}
+	err := h.AddPublisher(pub)
+	require.NoError(t, err)
+
+	h.Publish(pub, "pub", []byte(`{"type":"attitude","values":[1,2,3],"timestamp":1}`))
+	h.Publish(pub, "pub", []byte(`{"type":"attitude","values":[4,5,6],"timestamp":2}`))
+	h.Publish(pub, "pub", []byte(`{"type":"marker","markerId":1,"angle_x":0,"angle_y":0,"distance":1}`))
+
+	s := &Server{
+		Address:     "127.0.0.1:8080",
+		ReadTimeout: conf.StringDuration(10 * time.Second),
+		AuthManager: test.NilAuthManager,
+		PathManager: &dummyPathManager{hub: h},
+		Parent:      test.NilLogger,
+	}
+	err = s.Initialize()
+	require.NoError(t, err)
+	defer s.Close()
+
+	res, err := http.Get("http://localhost:8080/state?path=mypath")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	var out map[string]struct {
+		NTP    time.Time       `json:"ntp"`
+		Packet json.RawMessage `json:"packet"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&out)
+	require.NoError(t, err)
+
+	require.Len(t, out, 2)
+	require.JSONEq(t, `{"type":"attitude","values":[4,5,6],"timestamp":2}`, string(out["attitude"].Packet))
+	require.JSONEq(t, `{"type":"marker","markerId":1,"angle_x":0,"angle_y":0,"distance":1}`,
+		string(out["marker"].Packet))
+	require.False(t, out["attitude"].NTP.IsZero())
+}
+
+func TestServerWHEP(t *testing.T) {
+	h := &Hub{
+		Conf: conf.GPSConfig{
+			Protocol:       "publisher",
+			WriteQueueSize: 10,
+			HistorySize:    10,
+		},
+		Parent: test.NilLogger,
+	}
+	h.Initialize()
+	defer h.Close()
+
+	pub := &dummyPublisher{}
+	err := h.AddPublisher(pub)
+	require.NoError(t, err)
+
+	h.Publish(pub, "pub", []byte(`{"type":"attitude","values":[1,2,3],"timestamp":1}`))
+
+	// ICE/UDP mux of the WebRTC server
+	udpMuxLn, err := net.ListenPacket("udp", "127.0.0.1:8887")
+	require.NoError(t, err)
+	defer udpMuxLn.Close()
+
+	s := &Server{
+		Address:          "127.0.0.1:8080",
+		ReadTimeout:      conf.StringDuration(10 * time.Second),
+		ICEUDPMux:        webrtc.NewICEUDPMux(nil, udpMuxLn),
+		HandshakeTimeout: conf.StringDuration(10 * time.Second),
+		ICEServers: conf.WebRTCICEServers{{
+			URL:      "turn:myturn:3478",
+			Username: "myuser",
+			Password: "mypass",
+		}},
+		AuthManager: &dummyAuthManager{
+			fnc: func(req *auth.Request) error {
+				require.Equal(t, conf.AuthActionTelemetry, req.Action)
+				require.Equal(t, "mypath", req.Path)
+				return nil
+			},
+		},
+		PathManager: &dummyPathManager{hub: h},
+		Parent:      test.NilLogger,
+	}
+	err = s.Initialize()
+	require.NoError(t, err)
+	defer s.Close()
+
+	tr := &http.Transport{}
+	defer tr.CloseIdleConnections()
+	hc := &http.Client{Transport: tr}
+
+	settingEngine := webrtc.SettingEngine{}
+	settingEngine.SetICEUDPRandom(true)
+	settingEngine.SetIncludeLoopbackCandidate(true)
+	settingEngine.SetNetworkTypes([]webrtc.NetworkType{webrtc.NetworkTypeUDP4})
+
+	pc, err := webrtc.NewAPI(webrtc.WithSettingEngine(settingEngine)).NewPeerConnection(webrtc.Configuration{})
+	require.NoError(t, err)
+	defer pc.Close() //nolint:errcheck
+
+	received := make(chan []byte, 1)
+
+	pc.OnDataChannel(func(dc *webrtc.DataChannel) {
+		if dc.Label() == sessionDataChannelLabel {
+			dc.OnMessage(func(msg webrtc.DataChannelMessage) {
+				received <- msg.Data
+			})
+		}
+	})
+
+	// a data channel is needed to generate the application section of the offer
+	_, err = pc.CreateDataChannel("client", nil)
+	require.NoError(t, err)
+
+	offer, err := pc.CreateOffer(nil)
+	require.NoError(t, err)
+
+	gatheringComplete := webrtc.GatheringCompletePromise(pc)
+
+	err = pc.SetLocalDescription(offer)
+	require.NoError(t, err)
+
+	<-gatheringComplete
+
+	res, err := hc.Post("http://localhost:8080/whep?path=mypath", "application/sdp",
+		bytes.NewReader([]byte(pc.LocalDescription().SDP)))
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	require.Equal(t, "application/sdp", res.Header.Get("Content-Type"))
+	require.Equal(t, "application/trickle-ice-sdpfrag", res.Header.Get("Accept-Patch"))
+	require.Equal(t, []string{
+		`<turn:myturn:3478>; rel="ice-server"; username="myuser"; credential="mypass"; credential-type="password"`,
+	}, res.Header["Link"])
+
+	location := res.Header.Get("Location")
+	require.Regexp(t, "^/whep/", location)
+
+	answer, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(answer), "127.0.0.1 8887 typ host")
+
+	err = pc.SetRemoteDescription(webrtc.SessionDescription{
+		Type: webrtc.SDPTypeAnswer,
+		SDP:  string(answer),
+	})
+	require.NoError(t, err)
+
+	select {
+	case data := <-received:
+		require.Equal(t, []byte(`{"type":"attitude","values":[1,2,3],"timestamp":1}`), data)
+	case <-time.After(5 * time.Second):
+		t.Errorf("timed out")
+	}
+
+	req, err := http.NewRequest(http.MethodPatch, "http://localhost:8080"+location, bytes.NewReader([]byte(
+		"a=ice-ufrag:abcd\r\n"+
+			"a=ice-pwd:efgh\r\n"+
+			"m=application 9 UDP/DTLS/SCTP webrtc-datachannel\r\n"+
+			"a=mid:0\r\n"+
+			"a=candidate:3628911098 1 udp 2130706431 127.0.0.1 61688 typ host\r\n")))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/trickle-ice-sdpfrag")
+
+	res2, err := hc.Do(req)
+	require.NoError(t, err)
+	defer res2.Body.Close()
+
+	require.Equal(t, http.StatusNoContent, res2.StatusCode)
+
+	list, err := s.APISessionsList()
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1)
+
+	for _, ca := range []struct {
+		location string
+		status   int
+	}{
+		{location, http.StatusOK},
+		{location, http.StatusNotFound},
+		{"/whep/" + uuid.New().String(), http.StatusNotFound},
+	} {
+		req, err = http.NewRequest(http.MethodDelete, "http://localhost:8080"+ca.location, nil)
+		require.NoError(t, err)
+
+		res3, err := hc.Do(req)
+		require.NoError(t, err)
+		res3.Body.Close()
+
+		require.Equal(t, ca.status, res3.StatusCode)
+	}
+
+	require.Eventually(t, func() bool {
+		list, err = s.APISessionsList()
+		require.NoError(t, err)
+		return len(list.Items) == 0
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestServerWHEPInvalidOffer(t *testing.T) {
+	h := &Hub{
+		Conf: conf.GPSConfig{
+			Protocol: "publisher",
+		},
+		Parent: test.NilLogger,
+	}
+	h.Initialize()
+	defer h.Close()
+
+	s := &Server{
+		Address:     "127.0.0.1:8080",
+		ReadTimeout: conf.StringDuration(10 * time.Second),
+		AuthManager: test.NilAuthManager,
+		PathManager: &dummyPathManager{hub: h},
+		Parent:      test.NilLogger,
+	}
+	err := s.Initialize()
+	require.NoError(t, err)
+	defer s.Close()
+
+	tr := &http.Transport{}
+	defer tr.CloseIdleConnections()
+	hc := &http.Client{Transport: tr}
+
+	res, err := hc.Post("http://localhost:8080/whep?path=mypath", "text/plain", nil)
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	res, err = hc.Post("http://localhost:8080/whep?path=mypath", "application/sdp",
+		bytes.NewReader([]byte("invalid")))
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	require.Eventually(t, func() bool {
+		list, err2 := s.APISessionsList()
+		require.NoError(t, err2)
+		return len(list.Items) == 0
+	}, 2*time.Second, 10*time.Millisecond)
+}
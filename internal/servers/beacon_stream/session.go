@@ -0,0 +1,388 @@
+package beacon_stream
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/pion/ice/v2"
+	pwebrtc "github.com/pion/webrtc/v3"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/protocols/webrtc"
+)
+
+const (
+	sessionDataChannelLabel = "data"
+	sessionMaxCommandSize   = 4096
+)
+
+type sessionParent interface {
+	logger.Writer
+	closeSession(se *session)
+}
+
+type sessionWHEPAnswer struct {
+	answer []byte
+	err    error
+}
+
+type sessionAddCandidatesReq struct {
+	candidates []*pwebrtc.ICECandidateInit
+	res        chan error
+}
+
+// session is a client that receives the telemetry of a path.
+// Signaling is performed through WebSocket (conn) or through WHEP-style HTTP requests (whepOffer).
+type session struct {
+	conn                  *websocket.Conn
+	whepOffer             []byte
+	whepAnswer            chan sessionWHEPAnswer
+	hub                   *Hub
+	iceServers            conf.WebRTCICEServers
+	iceUDPMux             ice.UDPMux
+	iceTCPMux             ice.TCPMux
+	handshakeTimeout      conf.StringDuration
+	ipsFromInterfaces     bool
+	ipsFromInterfacesList []string
+	additionalHosts       []string
+	remoteAddr            string
+	pathName              string
+	user                  string
+	canCommand            bool
+	parentCtx             context.Context
+	wg                    *sync.WaitGroup
+	parent                sessionParent
+
+	ctx              context.Context
+	ctxCancel        func()
+	uuid             uuid.UUID
+	secret           uuid.UUID
+	created          time.Time
+	commandLimiter   *rateLimiter
+	commandErrLogger logger.Writer
+	writeMutex       sync.Mutex
+	mutex            sync.RWMutex
+	pc               *webrtc.PeerConnection
+	dc               *pwebrtc.DataChannel
+
+	// in
+	chAddCandidates chan sessionAddCandidatesReq
+}
+
+func (s *session) initialize() {
+	s.ctx, s.ctxCancel = context.WithCancel(s.parentCtx)
+	s.uuid = uuid.New()
+	s.secret = uuid.New()
+	s.created = time.Now()
+	s.chAddCandidates = make(chan sessionAddCandidatesReq)
+	s.commandLimiter = &rateLimiter{rate: s.hub.Conf.MaxCommandRate}
+	s.commandErrLogger = logger.NewLimitedLogger(s)
+
+	if s.user != "" {
+		s.Log(logger.Info, "created by %s (user '%s'), reading telemetry of path '%s'", s.remoteAddr, s.user, s.pathName)
+	} else {
+		s.Log(logger.Info, "created by %s, reading telemetry of path '%s'", s.remoteAddr, s.pathName)
+	}
+
+	s.wg.Add(1)
+	go s.run()
+}
+
+// Close closes the session.
+func (s *session) Close() {
+	s.ctxCancel()
+}
+
+// Log implements logger.Writer.
+func (s *session) Log(level logger.Level, format string, args ...interface{}) {
+	id := hex.EncodeToString(s.uuid[:4])
+	s.parent.Log(level, "[session %v] "+format, append([]interface{}{id}, args...)...)
+}
+
+func (s *session) run() {
+	defer s.wg.Done()
+	defer s.ctxCancel()
+
+	// close the WebSocket connection when the server is closing,
+	// in order to unblock the reader.
+	if s.conn != nil {
+		go func() {
+			<-s.ctx.Done()
+			s.conn.Close()
+		}()
+	}
+
+	err := s.runInner()
+
+	s.ctxCancel()
+
+	// unblock the HTTP handler if the session was closed before producing an answer.
+	if s.conn == nil {
+		select {
+		case s.whepAnswer <- sessionWHEPAnswer{err: err}:
+		default:
+		}
+	}
+
+	s.parent.closeSession(s)
+
+	s.Log(logger.Info, "closed: %v", err)
+}
+
+func (s *session) runInner() error {
+	pc := &webrtc.PeerConnection{
+		ICEServers:            parseICEServers(s.iceServers),
+		ICEUDPMux:             s.iceUDPMux,
+		ICETCPMux:             s.iceTCPMux,
+		HandshakeTimeout:      s.handshakeTimeout,
+		IPsFromInterfaces:     s.ipsFromInterfaces,
+		IPsFromInterfacesList: s.ipsFromInterfacesList,
+		AdditionalHosts:       s.additionalHosts,
+		DataOnly:              true,
+		Log:                   s,
+	}
+	err := pc.Start()
+	if err != nil {
+		return err
+	}
+	defer pc.Close()
+
+	dataChannel, err := pc.CreateDataChannel(sessionDataChannelLabel, s.hub.DataChannelInit())
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	s.pc = pc
+	s.dc = dataChannel
+	s.mutex.Unlock()
+
+	s.hub.AttachDataChannel(dataChannel)
+	defer s.hub.DetachDataChannel(dataChannel)
+
+	dataChannel.OnMessage(func(msg pwebrtc.DataChannelMessage) {
+		s.onCommand(msg.Data)
+	})
+
+	if s.conn != nil {
+		return s.runWebSocket(pc)
+	}
+	return s.runWHEP(pc)
+}
+
+func (s *session) runWebSocket(pc *webrtc.PeerConnection) error {
+	for {
+		_, msgBytes, err := s.conn.ReadMessage()
+		if err != nil {
+			select {
+			case <-s.ctx.Done():
+				return fmt.Errorf("terminated")
+			default:
+			}
+			return err
+		}
+
+		var msg Message
+		if err := json.Unmarshal(msgBytes, &msg); err != nil {
+			s.Log(logger.Warn, "failed to unmarshal message: %v", err)
+			continue
+		}
+
+		if msg.SDP != "" {
+			s.handleSDP(pc, msg.SDP)
+		}
+
+		if msg.Candidate != "" {
+			var candidate pwebrtc.ICECandidateInit
+			if err := json.Unmarshal([]byte(msg.Candidate), &candidate); err != nil {
+				s.Log(logger.Warn, "failed to unmarshal ICE candidate: %v", err)
+				continue
+			}
+
+			if err := pc.AddRemoteCandidate(&candidate); err != nil {
+				s.Log(logger.Warn, "failed to add ICE candidate: %v", err)
+				continue
+			}
+		}
+	}
+}
+
+func (s *session) runWHEP(pc *webrtc.PeerConnection) error {
+	// since the server can't send candidates to the client,
+	// the answer contains all local candidates.
+	answer, err := pc.CreateFullAnswer(s.ctx, &pwebrtc.SessionDescription{
+		Type: pwebrtc.SDPTypeOffer,
+		SDP:  string(s.whepOffer),
+	})
+	if err != nil {
+		return err
+	}
+
+	s.whepAnswer <- sessionWHEPAnswer{answer: []byte(answer.SDP)}
+
+	go s.readRemoteCandidates(pc)
+
+	err = pc.WaitUntilConnected(s.ctx)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-pc.Disconnected():
+		return fmt.Errorf("peer connection closed")
+
+	case <-s.ctx.Done():
+		return fmt.Errorf("terminated")
+	}
+}
+
+func (s *session) readRemoteCandidates(pc *webrtc.PeerConnection) {
+	for {
+		select {
+		case req := <-s.chAddCandidates:
+			req.res <- addCandidates(pc, req.candidates)
+
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+func addCandidates(pc *webrtc.PeerConnection, candidates []*pwebrtc.ICECandidateInit) error {
+	for _, candidate := range candidates {
+		err := pc.AddRemoteCandidate(candidate)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addRemoteCandidates adds candidates sent by the client through a PATCH request.
+func (s *session) addRemoteCandidates(candidates []*pwebrtc.ICECandidateInit) error {
+	req := sessionAddCandidatesReq{
+		candidates: candidates,
+		res:        make(chan error),
+	}
+
+	select {
+	case s.chAddCandidates <- req:
+		return <-req.res
+	case <-s.ctx.Done():
+		return fmt.Errorf("terminated")
+	}
+}
+
+// onCommand is called when the client sends a message through the data channel.
+// Messages are JSON commands that are forwarded to the upstream.
+func (s *session) onCommand(cmd []byte) {
+	if !s.canCommand {
+		s.commandErrLogger.Log(logger.Warn, "command discarded: user is not allowed to send commands")
+		return
+	}
+
+	if !s.commandLimiter.allow(time.Now()) {
+		s.commandErrLogger.Log(logger.Warn, "command discarded: rate limit exceeded")
+		return
+	}
+
+	if len(cmd) > sessionMaxCommandSize {
+		s.commandErrLogger.Log(logger.Warn, "command discarded: size (%d) is greater than maximum allowed (%d)",
+			len(cmd), sessionMaxCommandSize)
+		return
+	}
+
+	if !json.Valid(cmd) {
+		s.commandErrLogger.Log(logger.Warn, "command discarded: not valid JSON")
+		return
+	}
+
+	err := s.hub.WriteCommand(cmd)
+	if err != nil {
+		s.commandErrLogger.Log(logger.Warn, "unable to forward command: %v", err)
+	}
+}
+
+func (s *session) handleSDP(pc *webrtc.PeerConnection, rawSDP string) {
+	var sdp pwebrtc.SessionDescription
+	if err := json.Unmarshal([]byte(rawSDP), &sdp); err != nil {
+		s.Log(logger.Warn, "failed to unmarshal SDP: %v", err)
+		return
+	}
+
+	if sdp.Type != pwebrtc.SDPTypeOffer {
+		if err := pc.SetAnswer(&sdp); err != nil {
+			s.Log(logger.Warn, "failed to set remote description: %v", err)
+		}
+		return
+	}
+
+	answer, err := pc.CreateFullAnswer(s.ctx, &sdp)
+	if err != nil {
+		s.Log(logger.Warn, "failed to create answer: %v", err)
+		return
+	}
+
+	localSDP, err := json.Marshal(answer)
+	if err != nil {
+		s.Log(logger.Warn, "failed to marshal local description: %v", err)
+		return
+	}
+
+	s.sendMessage(Message{SDP: string(localSDP)})
+}
+
+func (s *session) sendMessage(msg Message) {
+	s.writeMutex.Lock()
+	defer s.writeMutex.Unlock()
+
+	msgBytes, err := json.Marshal(msg)
+	if err != nil {
+		s.Log(logger.Warn, "failed to marshal message: %v", err)
+		return
+	}
+
+	if err := s.conn.WriteMessage(websocket.TextMessage, msgBytes); err != nil {
+		s.Log(logger.Warn, "WebSocket write error: %v", err)
+	}
+}
+
+func (s *session) apiItem() *defs.APIBeaconSession {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	dataChannelState := pwebrtc.DataChannelStateConnecting.String()
+	messagesSent := uint64(0)
+	messagesDropped := uint64(0)
+	bytesSent := uint64(0)
+
+	if s.pc != nil {
+		dataChannelState = s.dc.ReadyState().String()
+		messagesDropped = s.hub.MessagesDropped(s.dc)
+
+		if dcs, ok := s.pc.DataChannelStats(sessionDataChannelLabel); ok {
+			messagesSent = uint64(dcs.MessagesSent)
+			bytesSent = dcs.BytesSent
+		}
+	}
+
+	return &defs.APIBeaconSession{
+		ID:               s.uuid,
+		Created:          s.created,
+		RemoteAddr:       s.remoteAddr,
+		Path:             s.pathName,
+		User:             s.user,
+		DataChannelState: dataChannelState,
+		MessagesSent:     messagesSent,
+		MessagesDropped:  messagesDropped,
+		BytesSent:        bytesSent,
+	}
+}
@@ -0,0 +1,158 @@
+package beacon_stream
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bluenviron/mediamtx/internal/auth"
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/logger"
+)
+
+func (s *Server) runTCPListener() {
+	defer s.wg.Done()
+
+	for {
+		nconn, err := s.tcpListener.Accept()
+		if err != nil {
+			select {
+			case <-s.ctx.Done():
+			default:
+				s.Log(logger.Error, "TCP listener: %v", err)
+			}
+			return
+		}
+
+		s.wg.Add(1)
+		go s.handleTCPConn(nconn)
+	}
+}
+
+// parseTCPHandshake parses the first line sent by a TCP publisher, that is an URL in the format
+// [tcp://host:port]/path[?user=user&pass=pass].
+func parseTCPHandshake(line string) (string, string, string, string, error) {
+	u, err := url.Parse(strings.TrimSpace(line))
+	if err != nil {
+		return "", "", "", "", fmt.Errorf("invalid handshake: %w", err)
+	}
+
+	pathName := strings.TrimPrefix(u.Path, "/")
+	if pathName == "" {
+		return "", "", "", "", fmt.Errorf("invalid handshake: path is missing")
+	}
+
+	q := u.Query()
+	user := q.Get("user")
+	pass := q.Get("pass")
+
+	if u.User != nil {
+		user = u.User.Username()
+		pass, _ = u.User.Password()
+	}
+
+	return pathName, user, pass, u.RawQuery, nil
+}
+
+// handleTCPConn handles a device that connects through TCP.
+// The device sends a handshake line that contains the path it wants to publish to,
+// and the server replies with "OK" or "ERR <reason>".
+func (s *Server) handleTCPConn(nconn net.Conn) {
+	defer s.wg.Done()
+
+	remoteAddr := nconn.RemoteAddr().String()
+
+	// close the connection during the handshake when the server is closing.
+	handshakeDone := make(chan struct{})
+	go func() {
+		select {
+		case <-s.ctx.Done():
+			nconn.Close()
+		case <-handshakeDone:
+		}
+	}()
+
+	pub, err := s.doTCPHandshake(nconn)
+
+	close(handshakeDone)
+
+	if err != nil {
+		s.Log(logger.Info, "TCP connection %v rejected: %v", remoteAddr, err)
+		nconn.Write([]byte("ERR " + err.Error() + "\n")) //nolint:errcheck
+		nconn.Close()
+		return
+	}
+
+	pub.start()
+}
+
+func (s *Server) doTCPHandshake(nconn net.Conn) (*publisher, error) {
+	nconn.SetDeadline(time.Now().Add(time.Duration(s.ReadTimeout))) //nolint:errcheck
+
+	reader := bufio.NewReader(nconn)
+
+	line, err := reader.ReadSlice('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	pathName, user, pass, query, err := parseTCPHandshake(string(line))
+	if err != nil {
+		return nil, err
+	}
+
+	ip := nconn.RemoteAddr().(*net.TCPAddr).IP
+
+	req := &auth.Request{
+		User:   user,
+		Pass:   pass,
+		IP:     ip,
+		Action: conf.AuthActionPublish,
+		Path:   pathName,
+		Query:  query,
+	}
+
+	err = s.AuthManager.Authenticate(req)
+	if err != nil {
+		// wait some seconds to mitigate brute force attacks
+		<-time.After(auth.PauseAfterError)
+
+		return nil, fmt.Errorf("authentication failed")
+	}
+
+	hub, err := s.PathManager.BeaconHub(pathName)
+	if err != nil {
+		return nil, err
+	}
+
+	pub := &publisher{
+		tcpConn:    nconn,
+		tcpReader:  reader,
+		hub:        hub,
+		remoteAddr: nconn.RemoteAddr().String(),
+		pathName:   pathName,
+		user:       req.User,
+		parentCtx:  s.ctx,
+		wg:         &s.wg,
+		parent:     s,
+	}
+	pub.initialize()
+
+	err = hub.AddPublisher(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = nconn.Write([]byte("OK\n"))
+	if err != nil {
+		hub.RemovePublisher(pub)
+		return nil, err
+	}
+
+	nconn.SetDeadline(time.Time{}) //nolint:errcheck
+
+	return pub, nil
+}
@@ -0,0 +1,56 @@
+package beacon_stream
+
+import (
+	"math"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+)
+
+// equatorial radius of the WGS84 ellipsoid, in meters.
+const earthRadius = 6378137.0
+
+func degToRad(v float64) float64 {
+	return v * math.Pi / 180
+}
+
+// markerToCamera converts the observation of a marker into coordinates in the camera frame:
+// meters to the right of the camera and in front of it, on the horizontal plane.
+// angle_x is positive to the right, angle_y is positive upwards.
+// pitch is the tilt of the camera, in degrees.
+func markerToCamera(m MarkerPacket, pitch float64) [2]float64 {
+	x := m.Distance * math.Cos(m.AngleY) * math.Sin(m.AngleX)
+	y := m.Distance * math.Cos(m.AngleY) * math.Cos(m.AngleX)
+	z := m.Distance * math.Sin(m.AngleY)
+
+	// compensate the tilt of the camera, in order to obtain the horizontal distance.
+	p := degToRad(pitch)
+	forward := y*math.Cos(p) - z*math.Sin(p)
+
+	return [2]float64{x, forward}
+}
+
+// cameraToWGS84 converts coordinates in the camera frame into latitude and longitude.
+// Distances are small, therefore an equirectangular projection is used.
+func cameraToWGS84(pose *conf.GPSCameraPose, pt [2]float64) [2]float64 {
+	h := degToRad(pose.Heading)
+	east := pt[0]*math.Cos(h) + pt[1]*math.Sin(h)
+	north := -pt[0]*math.Sin(h) + pt[1]*math.Cos(h)
+
+	lat := pose.Latitude + radToDeg(north/earthRadius)
+	lon := pose.Longitude + radToDeg(east/(earthRadius*math.Cos(degToRad(pose.Latitude))))
+
+	return [2]float64{lat, lon}
+}
+
+// wgs84ToCamera converts latitude and longitude into coordinates in the camera frame.
+// It is the inverse of cameraToWGS84.
+func wgs84ToCamera(pose *conf.GPSCameraPose, pt [2]float64) [2]float64 {
+	north := degToRad(pt[0]-pose.Latitude) * earthRadius
+	east := degToRad(pt[1]-pose.Longitude) * earthRadius * math.Cos(degToRad(pose.Latitude))
+
+	h := degToRad(pose.Heading)
+	x := east*math.Cos(h) - north*math.Sin(h)
+	y := east*math.Sin(h) + north*math.Cos(h)
+
+	return [2]float64{x, y}
+}
@@ -120,6 +120,11 @@ func (m *muxer) run() {
 }
 
 func (m *muxer) runInner() error {
+	offset, err := defs.ReaderOffset(m.query)
+	if err != nil {
+		return err
+	}
+
 	path, stream, err := m.pathManager.AddReader(defs.PathAddReaderReq{
 		Author: m,
 		AccessRequest: defs.PathAccessRequest{
@@ -148,6 +153,7 @@ func (m *muxer) runInner() error {
 		directory:       m.directory,
 		pathName:        m.pathName,
 		stream:          stream,
+		offset:          offset,
 		bytesSent:       m.bytesSent,
 		parent:          m,
 	}
@@ -205,6 +211,7 @@ func (m *muxer) runInner() error {
 				directory:       m.directory,
 				pathName:        m.pathName,
 				stream:          stream,
+				offset:          offset,
 				bytesSent:       m.bytesSent,
 				parent:          m,
 			}
@@ -23,6 +23,7 @@ type muxerInstance struct {
 	directory       string
 	pathName        string
 	stream          *stream.Stream
+	offset          time.Duration
 	bytesSent       *uint64
 	parent          logger.Writer
 
@@ -48,21 +49,22 @@ func (mi *muxerInstance) initialize() error {
 		},
 	}
 
-	err := hls.FromStream(mi.stream, mi, mi.hmuxer)
+	// the muxer is the reader of the stream, since it is the reader of the path.
+	err := hls.FromStream(mi.stream, mi.parent, mi.hmuxer)
 	if err != nil {
 		return err
 	}
 
 	err = mi.hmuxer.Start()
 	if err != nil {
-		mi.stream.RemoveReader(mi)
+		mi.stream.RemoveReader(mi.parent)
 		return err
 	}
 
 	mi.Log(logger.Info, "is converting into HLS, %s",
-		defs.FormatsInfo(mi.stream.ReaderFormats(mi)))
+		defs.FormatsInfo(mi.stream.ReaderFormats(mi.parent)))
 
-	mi.stream.StartReader(mi)
+	mi.stream.StartReaderWithOffset(mi.parent, mi.offset)
 
 	return nil
 }
@@ -73,7 +75,7 @@ func (mi *muxerInstance) Log(level logger.Level, format string, args ...interfac
 }
 
 func (mi *muxerInstance) close() {
-	mi.stream.RemoveReader(mi)
+	mi.stream.RemoveReader(mi.parent)
 	mi.hmuxer.Close()
 	if mi.hmuxer.Directory != "" {
 		os.Remove(mi.hmuxer.Directory)
@@ -81,7 +83,7 @@ func (mi *muxerInstance) close() {
 }
 
 func (mi *muxerInstance) errorChan() chan error {
-	return mi.stream.ReaderError(mi)
+	return mi.stream.ReaderError(mi.parent)
 }
 
 func (mi *muxerInstance) handleRequest(ctx *gin.Context) {
@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
 	"sort"
 	"sync"
 
@@ -58,6 +59,24 @@ type serverParent interface {
 	logger.Writer
 }
 
+// muxerKey returns the key of a muxer.
+// Readers that select different tracks or offsets are served by different muxers.
+func muxerKey(pathName string, query string) string {
+	q, _ := url.ParseQuery(query)
+
+	v := make(url.Values)
+	for _, key := range []string{"tracks", "offset"} {
+		if val := q.Get(key); val != "" {
+			v.Set(key, val)
+		}
+	}
+
+	if len(v) == 0 {
+		return pathName
+	}
+	return pathName + "?" + v.Encode()
+}
+
 // Server is a HLS server.
 type Server struct {
 	Address         string
@@ -165,19 +184,21 @@ outer:
 			}
 
 		case req := <-s.chGetMuxer:
-			mux, ok := s.muxers[req.path]
+			key := muxerKey(req.path, req.query)
+			mux, ok := s.muxers[key]
 			switch {
 			case ok:
 				req.res <- serverGetMuxerRes{muxer: mux}
-			case s.AlwaysRemux && !req.sourceOnDemand:
+			case s.AlwaysRemux && !req.sourceOnDemand && key == req.path:
 				req.res <- serverGetMuxerRes{err: fmt.Errorf("muxer is waiting to be created")}
 			default:
 				req.res <- serverGetMuxerRes{muxer: s.createMuxer(req.path, req.remoteAddr, req.query)}
 			}
 
 		case c := <-s.chCloseMuxer:
-			if c2, ok := s.muxers[c.PathName()]; ok && c2 == c {
-				delete(s.muxers, c.PathName())
+			key := muxerKey(c.PathName(), c.query)
+			if c2, ok := s.muxers[key]; ok && c2 == c {
+				delete(s.muxers, key)
 			}
 
 		case req := <-s.chAPIMuxerList:
@@ -234,7 +255,7 @@ func (s *Server) createMuxer(pathName string, remoteAddr string, query string) *
 		closeAfter:      s.MuxerCloseAfter,
 	}
 	r.initialize()
-	s.muxers[pathName] = r
+	s.muxers[muxerKey(pathName, query)] = r
 	return r
 }
 
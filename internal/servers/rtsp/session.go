@@ -11,6 +11,7 @@ import (
 	"github.com/bluenviron/gortsplib/v4"
 	rtspauth "github.com/bluenviron/gortsplib/v4/pkg/auth"
 	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/headers"
 	"github.com/google/uuid"
 	"github.com/pion/rtp"
 
@@ -21,8 +22,45 @@ import (
 	"github.com/bluenviron/mediamtx/internal/hooks"
 	"github.com/bluenviron/mediamtx/internal/logger"
 	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/unit"
 )
 
+// rangeOffset returns the offset from the live stream requested with the Range header
+// of a request. Only absolute times (clock=) are supported.
+func rangeOffset(req *base.Request) (time.Duration, bool, error) {
+	v, ok := req.Header["Range"]
+	if !ok {
+		return 0, false, nil
+	}
+
+	var h headers.Range
+	err := h.Unmarshal(v)
+	if err != nil {
+		return 0, false, err
+	}
+
+	utc, ok := h.Value.(*headers.RangeUTC)
+	if !ok {
+		return 0, false, nil
+	}
+
+	return min(time.Until(utc.Start), 0), true, nil
+}
+
+// requestedOffset returns the offset from the live stream requested
+// with the Range header or with the 'offset' query parameter.
+func requestedOffset(req *base.Request, query string) (time.Duration, error) {
+	offset, ok, err := rangeOffset(req)
+	if err != nil {
+		return 0, err
+	}
+	if ok {
+		return offset, nil
+	}
+
+	return defs.ReaderOffset(query)
+}
+
 type session struct {
 	isTLS           bool
 	protocols       map[conf.Protocol]struct{}
@@ -37,6 +75,9 @@ type session struct {
 	created         time.Time
 	path            defs.Path
 	stream          *stream.Stream
+	timeShiftStream *gortsplib.ServerStream
+	timeShiftOffset time.Duration
+	readerStarted   bool
 	onUnreadHook    func()
 	mutex           sync.Mutex
 	state           gortsplib.ServerSessionState
@@ -80,6 +121,12 @@ func (s *session) onClose(err error) {
 
 	switch s.rsession.State() {
 	case gortsplib.ServerSessionStatePrePlay, gortsplib.ServerSessionStatePlay:
+		if s.readerStarted {
+			s.stream.RemoveReader(s)
+		}
+		if s.timeShiftStream != nil {
+			s.timeShiftStream.Close()
+		}
 		s.path.RemoveReader(defs.PathRemoveReaderReq{Author: s})
 
 	case gortsplib.ServerSessionStatePreRecord, gortsplib.ServerSessionStateRecord:
@@ -213,6 +260,19 @@ func (s *session) onSetup(c *conn, ctx *gortsplib.ServerHandlerOnSetupCtx,
 			}, nil, err
 		}
 
+		var offset time.Duration
+		if stream.TimeShiftEnabled() && s.timeShiftStream == nil {
+			offset, err = requestedOffset(ctx.Request, ctx.Query)
+			if err != nil {
+				if s.rsession.State() == gortsplib.ServerSessionStateInitial {
+					path.RemoveReader(defs.PathRemoveReaderReq{Author: s})
+				}
+				return &base.Response{
+					StatusCode: base.StatusBadRequest,
+				}, nil, err
+			}
+		}
+
 		s.path = path
 		s.stream = stream
 
@@ -223,9 +283,22 @@ func (s *session) onSetup(c *conn, ctx *gortsplib.ServerHandlerOnSetupCtx,
 		s.mutex.Unlock()
 
 		var rstream *gortsplib.ServerStream
-		if !s.isTLS {
+		switch {
+		case s.timeShiftStream != nil:
+			rstream = s.timeShiftStream
+
+		case stream.TimeShiftEnabled():
+			// a past position of the time-shift buffer can be requested with the
+			// Range header of the PLAY request too, therefore the session has a
+			// dedicated stream, since the shared one is live.
+			s.timeShiftStream = gortsplib.NewServerStream(s.rserver, stream.Desc())
+			s.timeShiftOffset = offset
+			rstream = s.timeShiftStream
+
+		case !s.isTLS:
 			rstream = stream.RTSPStream(s.rserver)
-		} else {
+
+		default:
 			rstream = stream.RTSPSStream(s.rserver)
 		}
 
@@ -241,10 +314,19 @@ func (s *session) onSetup(c *conn, ctx *gortsplib.ServerHandlerOnSetupCtx,
 }
 
 // onPlay is called by rtspServer.
-func (s *session) onPlay(_ *gortsplib.ServerHandlerOnPlayCtx) (*base.Response, error) {
+func (s *session) onPlay(ctx *gortsplib.ServerHandlerOnPlayCtx) (*base.Response, error) {
 	h := make(base.Header)
 
 	if s.rsession.State() == gortsplib.ServerSessionStatePrePlay {
+		if s.timeShiftStream != nil {
+			err := s.startTimeShiftReader(ctx.Request)
+			if err != nil {
+				return &base.Response{
+					StatusCode: base.StatusBadRequest,
+				}, err
+			}
+		}
+
 		s.Log(logger.Info, "is reading from path '%s', with %s, %s",
 			s.path.Name(),
 			s.rsession.SetuppedTransport(),
@@ -271,6 +353,37 @@ func (s *session) onPlay(_ *gortsplib.ServerHandlerOnPlayCtx) (*base.Response, e
 	}, nil
 }
 
+// startTimeShiftReader starts reading the stream from the position
+// requested with the Range header of the PLAY request, or during SETUP.
+func (s *session) startTimeShiftReader(req *base.Request) error {
+	offset, ok, err := rangeOffset(req)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		offset = s.timeShiftOffset
+	}
+
+	for _, medi := range s.rsession.SetuppedMedias() {
+		for _, forma := range medi.Formats {
+			cmedi := medi
+
+			s.stream.AddReader(s, medi, forma, func(u unit.Unit) error {
+				for _, pkt := range u.GetRTPPackets() {
+					s.timeShiftStream.WritePacketRTPWithNTP(cmedi, pkt, u.GetNTP()) //nolint:errcheck
+				}
+				return nil
+			})
+		}
+	}
+
+	s.stream.StartReaderWithOffset(s, offset)
+	s.readerStarted = true
+
+	return nil
+}
+
 // onRecord is called by rtspServer.
 func (s *session) onRecord(_ *gortsplib.ServerHandlerOnRecordCtx) (*base.Response, error) {
 	stream, err := s.path.StartPublisher(defs.PathStartPublisherReq{
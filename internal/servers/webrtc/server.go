@@ -21,11 +21,13 @@ import (
 	"github.com/pion/logging"
 	pwebrtc "github.com/pion/webrtc/v3"
 
+	"github.com/bluenviron/mediamtx/internal/auth"
 	"github.com/bluenviron/mediamtx/internal/conf"
 	"github.com/bluenviron/mediamtx/internal/defs"
 	"github.com/bluenviron/mediamtx/internal/externalcmd"
 	"github.com/bluenviron/mediamtx/internal/logger"
 	"github.com/bluenviron/mediamtx/internal/restrictnetwork"
+	"github.com/bluenviron/mediamtx/internal/servers/beacon_stream"
 	"github.com/bluenviron/mediamtx/internal/stream"
 )
 
@@ -167,6 +169,11 @@ type serverPathManager interface {
 	FindPathConf(req defs.PathFindPathConfReq) (*conf.Path, error)
 	AddPublisher(req defs.PathAddPublisherReq) (defs.Path, error)
 	AddReader(req defs.PathAddReaderReq) (defs.Path, *stream.Stream, error)
+	BeaconHub(pathName string) (*beacon_stream.Hub, error)
+}
+
+type serverAuthManager interface {
+	Authenticate(req *auth.Request) error
 }
 
 type serverParent interface {
@@ -191,6 +198,7 @@ type Server struct {
 	HandshakeTimeout      conf.StringDuration
 	TrackGatherTimeout    conf.StringDuration
 	ExternalCmdPool       *externalcmd.Pool
+	AuthManager           serverAuthManager
 	PathManager           serverPathManager
 	Parent                serverParent
 
@@ -298,6 +306,18 @@ func (s *Server) Close() {
 	<-s.done
 }
 
+// ICEUDPMux returns the ICE/UDP mux, in order to share it with other servers.
+// It is nil when LocalUDPAddress is empty.
+func (s *Server) ICEUDPMux() ice.UDPMux {
+	return s.iceUDPMux
+}
+
+// ICETCPMux returns the ICE/TCP mux, in order to share it with other servers.
+// It is nil when LocalTCPAddress is empty.
+func (s *Server) ICETCPMux() ice.TCPMux {
+	return s.iceTCPMux
+}
+
 func (s *Server) run() {
 	defer close(s.done)
 
@@ -317,6 +337,7 @@ outer:
 				req:                   req,
 				wg:                    &wg,
 				externalCmdPool:       s.ExternalCmdPool,
+				authManager:           s.AuthManager,
 				pathManager:           s.PathManager,
 				parent:                s,
 			}
@@ -3,20 +3,25 @@ package webrtc
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"reflect"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/bluenviron/gortsplib/v4/pkg/description"
 	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/mediamtx/internal/auth"
 	"github.com/bluenviron/mediamtx/internal/conf"
 	"github.com/bluenviron/mediamtx/internal/defs"
 	"github.com/bluenviron/mediamtx/internal/externalcmd"
 	"github.com/bluenviron/mediamtx/internal/protocols/webrtc"
 	"github.com/bluenviron/mediamtx/internal/protocols/whip"
+	"github.com/bluenviron/mediamtx/internal/servers/beacon_stream"
 	"github.com/bluenviron/mediamtx/internal/stream"
 	"github.com/bluenviron/mediamtx/internal/test"
 	"github.com/bluenviron/mediamtx/internal/unit"
@@ -34,6 +39,14 @@ func checkClose(t *testing.T, closeFunc func() error) {
 	require.NoError(t, closeFunc())
 }
 
+type dummyAuthManager struct {
+	fnc func(req *auth.Request) error
+}
+
+func (m *dummyAuthManager) Authenticate(req *auth.Request) error {
+	return m.fnc(req)
+}
+
 type dummyPath struct {
 	stream        *stream.Stream
 	streamCreated chan struct{}
@@ -80,6 +93,7 @@ type dummyPathManager struct {
 	findPathConf func(req defs.PathFindPathConfReq) (*conf.Path, error)
 	addPublisher func(req defs.PathAddPublisherReq) (defs.Path, error)
 	addReader    func(req defs.PathAddReaderReq) (defs.Path, *stream.Stream, error)
+	beaconHub    func(pathName string) (*beacon_stream.Hub, error)
 }
 
 func (pm *dummyPathManager) FindPathConf(req defs.PathFindPathConfReq) (*conf.Path, error) {
@@ -94,6 +108,13 @@ func (pm *dummyPathManager) AddReader(req defs.PathAddReaderReq) (defs.Path, *st
 	return pm.addReader(req)
 }
 
+func (pm *dummyPathManager) BeaconHub(pathName string) (*beacon_stream.Hub, error) {
+	if pm.beaconHub == nil {
+		return nil, fmt.Errorf("path '%s' has no GPS source", pathName)
+	}
+	return pm.beaconHub(pathName)
+}
+
 func initializeTestServer(t *testing.T) *Server {
 	pm := &dummyPathManager{
 		findPathConf: func(_ defs.PathFindPathConfReq) (*conf.Path, error) {
@@ -612,6 +633,188 @@ func TestServerRead(t *testing.T) {
 	}
 }
 
+func TestServerReadTelemetry(t *testing.T) {
+	for _, ca := range []string{
+		"allowed",
+		"denied",
+	} {
+		t.Run(ca, func(t *testing.T) {
+			desc := &description.Session{Medias: []*description.Media{test.MediaH264}}
+
+			str, err := stream.New(
+				512,
+				1460,
+				desc,
+				true,
+				test.NilLogger,
+			)
+			require.NoError(t, err)
+
+			hub := &beacon_stream.Hub{
+				Conf: conf.GPSConfig{
+					Protocol:  "udp",
+					IPAddress: "127.0.0.1",
+					Port:      8889,
+				},
+				Parent: test.NilLogger,
+			}
+			hub.Initialize()
+			defer hub.Close()
+
+			path := &dummyPath{stream: str}
+
+			pm := &dummyPathManager{
+				findPathConf: func(_ defs.PathFindPathConfReq) (*conf.Path, error) {
+					return &conf.Path{}, nil
+				},
+				addReader: func(_ defs.PathAddReaderReq) (defs.Path, *stream.Stream, error) {
+					return path, str, nil
+				},
+				beaconHub: func(pathName string) (*beacon_stream.Hub, error) {
+					require.Equal(t, "teststream", pathName)
+					return hub, nil
+				},
+			}
+
+			s := &Server{
+				Address:               "127.0.0.1:8886",
+				Encryption:            false,
+				ServerKey:             "",
+				ServerCert:            "",
+				AllowOrigin:           "",
+				TrustedProxies:        conf.IPNetworks{},
+				ReadTimeout:           conf.StringDuration(10 * time.Second),
+				LocalUDPAddress:       "127.0.0.1:8887",
+				LocalTCPAddress:       "127.0.0.1:8887",
+				IPsFromInterfaces:     true,
+				IPsFromInterfacesList: []string{},
+				AdditionalHosts:       []string{},
+				ICEServers:            []conf.WebRTCICEServer{},
+				HandshakeTimeout:      conf.StringDuration(10 * time.Second),
+				TrackGatherTimeout:    conf.StringDuration(2 * time.Second),
+				ExternalCmdPool:       nil,
+				AuthManager: &dummyAuthManager{
+					fnc: func(req *auth.Request) error {
+						if req.Action == conf.AuthActionTelemetry && ca == "denied" {
+							return &auth.Error{Message: "not allowed"}
+						}
+						return nil
+					},
+				},
+				PathManager: pm,
+				Parent:      test.NilLogger,
+			}
+			err = s.Initialize()
+			require.NoError(t, err)
+			defer s.Close()
+
+			tr := &http.Transport{}
+			defer tr.CloseIdleConnections()
+			hc := &http.Client{Transport: tr}
+
+			settingsEngine := pwebrtc.SettingEngine{}
+			settingsEngine.SetICEUDPRandom(true)
+			settingsEngine.SetIncludeLoopbackCandidate(true)
+			settingsEngine.SetNetworkTypes([]pwebrtc.NetworkType{pwebrtc.NetworkTypeUDP4})
+
+			mediaEngine := &pwebrtc.MediaEngine{}
+			err = mediaEngine.RegisterDefaultCodecs()
+			require.NoError(t, err)
+
+			pc, err := pwebrtc.NewAPI(
+				pwebrtc.WithSettingEngine(settingsEngine),
+				pwebrtc.WithMediaEngine(mediaEngine)).
+				NewPeerConnection(pwebrtc.Configuration{})
+			require.NoError(t, err)
+			defer pc.Close() //nolint:errcheck
+
+			_, err = pc.AddTransceiverFromKind(pwebrtc.RTPCodecTypeVideo, pwebrtc.RTPTransceiverInit{
+				Direction: pwebrtc.RTPTransceiverDirectionRecvonly,
+			})
+			require.NoError(t, err)
+
+			dc, err := pc.CreateDataChannel("telemetry", nil)
+			require.NoError(t, err)
+
+			opened := make(chan struct{})
+			dc.OnOpen(func() {
+				close(opened)
+			})
+
+			closed := make(chan struct{})
+			dc.OnClose(func() {
+				close(closed)
+			})
+
+			received := make(chan []byte, 1)
+			dc.OnMessage(func(msg pwebrtc.DataChannelMessage) {
+				select {
+				case received <- msg.Data:
+				default:
+				}
+			})
+
+			offer, err := pc.CreateOffer(nil)
+			require.NoError(t, err)
+
+			gatherComplete := pwebrtc.GatheringCompletePromise(pc)
+
+			err = pc.SetLocalDescription(offer)
+			require.NoError(t, err)
+
+			<-gatherComplete
+
+			req, err := http.NewRequest(http.MethodPost,
+				"http://localhost:8886/teststream/whep", bytes.NewReader([]byte(pc.LocalDescription().SDP)))
+			require.NoError(t, err)
+
+			req.Header.Set("Content-Type", "application/sdp")
+
+			res, err := hc.Do(req)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			require.Equal(t, http.StatusCreated, res.StatusCode)
+
+			answer, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+
+			err = pc.SetRemoteDescription(pwebrtc.SessionDescription{
+				Type: pwebrtc.SDPTypeAnswer,
+				SDP:  string(answer),
+			})
+			require.NoError(t, err)
+
+			<-opened
+
+			if ca == "denied" {
+				<-closed
+				return
+			}
+
+			conn, err := net.Dial("udp", "127.0.0.1:8889")
+			require.NoError(t, err)
+			defer conn.Close()
+
+			// the hub attaches the data channel asynchronously,
+			// therefore send data until something is received.
+			for i := 1; ; i++ {
+				pkt := `{"type":"attitude","values":[1,2,3],"timestamp":` + strconv.Itoa(i) + `}`
+
+				_, err = conn.Write([]byte(pkt))
+				require.NoError(t, err)
+
+				select {
+				case data := <-received:
+					require.Regexp(t, `^\{"type":"attitude","values":\[1,2,3\],"timestamp":[0-9]+\}$`, string(data))
+					return
+				case <-time.After(100 * time.Millisecond):
+				}
+			}
+		})
+	}
+}
+
 func TestServerReadNotFound(t *testing.T) {
 	pm := &dummyPathManager{
 		findPathConf: func(_ defs.PathFindPathConfReq) (*conf.Path, error) {
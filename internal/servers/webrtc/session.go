@@ -17,6 +17,7 @@ import (
 	pwebrtc "github.com/pion/webrtc/v3"
 
 	"github.com/bluenviron/mediamtx/internal/auth"
+	"github.com/bluenviron/mediamtx/internal/conf"
 	"github.com/bluenviron/mediamtx/internal/defs"
 	"github.com/bluenviron/mediamtx/internal/externalcmd"
 	"github.com/bluenviron/mediamtx/internal/hooks"
@@ -25,6 +26,10 @@ import (
 	"github.com/bluenviron/mediamtx/internal/stream"
 )
 
+// label of the data channel that readers can open in order to receive
+// the telemetry of the path alongside the media tracks.
+const telemetryDataChannelLabel = "telemetry"
+
 func whipOffer(body []byte) *pwebrtc.SessionDescription {
 	return &pwebrtc.SessionDescription{
 		Type: pwebrtc.SDPTypeOffer,
@@ -42,6 +47,7 @@ type session struct {
 	req                   webRTCNewSessionReq
 	wg                    *sync.WaitGroup
 	externalCmdPool       *externalcmd.Pool
+	authManager           serverAuthManager
 	pathManager           serverPathManager
 	parent                *Server
 
@@ -237,15 +243,23 @@ func (s *session) runPublish() (int, error) {
 func (s *session) runRead() (int, error) {
 	ip, _, _ := net.SplitHostPort(s.req.remoteAddr)
 
+	offset, err := defs.ReaderOffset(s.req.httpRequest.URL.RawQuery)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	accessRequest := defs.PathAccessRequest{
+		Name:        s.req.pathName,
+		IP:          net.ParseIP(ip),
+		Query:       s.req.httpRequest.URL.RawQuery,
+		Proto:       auth.ProtocolWebRTC,
+		ID:          &s.uuid,
+		HTTPRequest: s.req.httpRequest,
+	}
+
 	path, stream, err := s.pathManager.AddReader(defs.PathAddReaderReq{
-		Author: s,
-		AccessRequest: defs.PathAccessRequest{
-			Name:        s.req.pathName,
-			IP:          net.ParseIP(ip),
-			Proto:       auth.ProtocolWebRTC,
-			ID:          &s.uuid,
-			HTTPRequest: s.req.httpRequest,
-		},
+		Author:        s,
+		AccessRequest: accessRequest,
 	})
 	if err != nil {
 		var terr2 defs.PathNoOnePublishingError
@@ -276,6 +290,51 @@ func (s *session) runRead() (int, error) {
 		Log:                   s,
 	}
 
+	// telemetry is optional and is enabled only when the path has a beacon source
+	// and the reader negotiates a data channel with the telemetry label.
+	hub, err := s.pathManager.BeaconHub(path.Name())
+	if err == nil {
+		// reading the stream does not imply being allowed to read telemetry,
+		// therefore the same user is checked against the telemetry action.
+		authReq := accessRequest.ToAuthRequest()
+		authReq.Action = conf.AuthActionTelemetry
+		telemetryErr := s.authManager.Authenticate(authReq)
+
+		var telemetryChannel *pwebrtc.DataChannel
+
+		pc.OnDataChannel = func(dc *pwebrtc.DataChannel) {
+			if dc.Label() != telemetryDataChannelLabel {
+				s.Log(logger.Warn, "ignoring data channel '%s'", dc.Label())
+				return
+			}
+
+			// the channel can only be closed once it is open.
+			if telemetryErr != nil {
+				s.Log(logger.Warn, "refusing data channel '%s': %v", dc.Label(), telemetryErr)
+				dc.OnOpen(func() {
+					dc.Close() //nolint:errcheck
+				})
+				return
+			}
+
+			s.mutex.Lock()
+			telemetryChannel = dc
+			s.mutex.Unlock()
+
+			hub.AttachDataChannel(dc)
+		}
+
+		defer func() {
+			s.mutex.Lock()
+			dc := telemetryChannel
+			s.mutex.Unlock()
+
+			if dc != nil {
+				hub.DetachDataChannel(dc)
+			}
+		}()
+	}
+
 	err = webrtc.FromStream(stream, s, pc)
 	if err != nil {
 		return http.StatusBadRequest, err
@@ -323,7 +382,7 @@ func (s *session) runRead() (int, error) {
 	})
 	defer onUnreadHook()
 
-	stream.StartReader(s)
+	stream.StartReaderWithOffset(s, offset)
 	defer stream.RemoveReader(s)
 
 	select {
@@ -0,0 +1,229 @@
+package slate
+
+import (
+	"time"
+
+	"github.com/bluenviron/mediacommon/pkg/codecs/h264"
+
+	"github.com/bluenviron/mediamtx/internal/formatprocessor"
+)
+
+const (
+	blackWidthMBs  = 80 // 1280 pixels
+	blackHeightMBs = 45 // 720 pixels
+	blackFrameRate = 10
+
+	// mb_type of an I_PCM macroblock.
+	mbTypeIPCM = 25
+
+	// mb_type of an intra 16x16 macroblock with DC prediction and no AC coefficients.
+	mbTypeI16x16DC = 3
+
+	opusPacketDuration = 20 * time.Millisecond
+	opusPacketsPerUnit = 5
+)
+
+// silent Opus packets (CELT, fullband, 20ms).
+var (
+	opusSilenceMono   = []byte{0xf8, 0xff, 0xfe}
+	opusSilenceStereo = []byte{0xfc, 0xff, 0xfe}
+)
+
+type bitWriter struct {
+	buf []byte
+	n   int
+}
+
+func (w *bitWriter) writeBit(v uint8) {
+	if w.n%8 == 0 {
+		w.buf = append(w.buf, 0)
+	}
+	if v != 0 {
+		w.buf[len(w.buf)-1] |= 1 << (7 - w.n%8)
+	}
+	w.n++
+}
+
+func (w *bitWriter) writeBits(v uint64, n int) {
+	for i := n - 1; i >= 0; i-- {
+		w.writeBit(uint8((v >> i) & 1))
+	}
+}
+
+func (w *bitWriter) writeByte(v byte) {
+	w.writeBits(uint64(v), 8)
+}
+
+// writeUE writes an unsigned Exp-Golomb code.
+func (w *bitWriter) writeUE(v uint32) {
+	v++
+	n := 0
+	for tmp := v; tmp > 1; tmp >>= 1 {
+		n++
+	}
+	w.writeBits(0, n)
+	w.writeBits(uint64(v), n+1)
+}
+
+// writeSE writes a signed Exp-Golomb code.
+func (w *bitWriter) writeSE(v int32) {
+	if v > 0 {
+		w.writeUE(uint32(2*v - 1))
+	} else {
+		w.writeUE(uint32(-2 * v))
+	}
+}
+
+func (w *bitWriter) align() {
+	for w.n%8 != 0 {
+		w.writeBit(0)
+	}
+}
+
+func (w *bitWriter) writeTrailingBits() {
+	w.writeBit(1)
+	w.align()
+}
+
+func marshalNALU(typ h264.NALUType, w *bitWriter) []byte {
+	return append([]byte{3<<5 | byte(typ)}, formatprocessor.EmulationPreventionAdd(w.buf)...)
+}
+
+func blackSPS() []byte {
+	w := &bitWriter{}
+	w.writeByte(66)   // profile_idc: baseline
+	w.writeByte(0xc0) // constraint_set0_flag, constraint_set1_flag
+	w.writeByte(31)   // level_idc: 3.1
+	w.writeUE(0)      // seq_parameter_set_id
+	w.writeUE(0)      // log2_max_frame_num_minus4
+	w.writeUE(2)      // pic_order_cnt_type
+	w.writeUE(1)      // max_num_ref_frames
+	w.writeBit(0)     // gaps_in_frame_num_value_allowed_flag
+	w.writeUE(blackWidthMBs - 1)
+	w.writeUE(blackHeightMBs - 1)
+	w.writeBit(1) // frame_mbs_only_flag
+	w.writeBit(1) // direct_8x8_inference_flag
+	w.writeBit(0) // frame_cropping_flag
+	w.writeBit(0) // vui_parameters_present_flag
+	w.writeTrailingBits()
+	return marshalNALU(h264.NALUTypeSPS, w)
+}
+
+func blackPPS() []byte {
+	w := &bitWriter{}
+	w.writeUE(0)      // pic_parameter_set_id
+	w.writeUE(0)      // seq_parameter_set_id
+	w.writeBit(0)     // entropy_coding_mode_flag: CAVLC
+	w.writeBit(0)     // bottom_field_pic_order_in_frame_present_flag
+	w.writeUE(0)      // num_slice_groups_minus1
+	w.writeUE(0)      // num_ref_idx_l0_default_active_minus1
+	w.writeUE(0)      // num_ref_idx_l1_default_active_minus1
+	w.writeBit(0)     // weighted_pred_flag
+	w.writeBits(0, 2) // weighted_bipred_idc
+	w.writeSE(0)      // pic_init_qp_minus26
+	w.writeSE(0)      // pic_init_qs_minus26
+	w.writeSE(0)      // chroma_qp_index_offset
+	w.writeBit(0)     // deblocking_filter_control_present_flag
+	w.writeBit(0)     // constrained_intra_pred_flag
+	w.writeBit(0)     // redundant_pic_cnt_present_flag
+	w.writeTrailingBits()
+	return marshalNALU(h264.NALUTypePPS, w)
+}
+
+// blackIDR generates a black IDR frame.
+// The first macroblock is a I_PCM macroblock that contains black samples,
+// while the others are intra 16x16 macroblocks with DC prediction and no residual,
+// that therefore copy the color of their neighbors.
+func blackIDR(idrPicID uint32) []byte {
+	w := &bitWriter{}
+
+	// slice header
+	w.writeUE(0)        // first_mb_in_slice
+	w.writeUE(7)        // slice_type: I
+	w.writeUE(0)        // pic_parameter_set_id
+	w.writeBits(0, 4)   // frame_num
+	w.writeUE(idrPicID) // idr_pic_id
+	w.writeBit(0)       // no_output_of_prior_pics_flag
+	w.writeBit(0)       // long_term_reference_flag
+	w.writeSE(0)        // slice_qp_delta
+
+	// slice data
+	for y := 0; y < blackHeightMBs; y++ {
+		for x := 0; x < blackWidthMBs; x++ {
+			if x == 0 && y == 0 {
+				w.writeUE(mbTypeIPCM)
+				w.align()
+				for i := 0; i < 256; i++ {
+					w.writeByte(16) // luma
+				}
+				for i := 0; i < 128; i++ {
+					w.writeByte(128) // chroma
+				}
+				continue
+			}
+
+			w.writeUE(mbTypeI16x16DC)
+			w.writeUE(0) // intra_chroma_pred_mode: DC
+			w.writeSE(0) // mb_qp_delta
+
+			// coeff_token of the luma DC block, with TotalCoeff = 0.
+			// its table depends on the number of coefficients of neighbors,
+			// that is 16 for the I_PCM macroblock and 0 for the others.
+			if (x == 1 && y == 0) || (x == 0 && y == 1) {
+				w.writeBits(0b000011, 6)
+			} else {
+				w.writeBit(1)
+			}
+		}
+	}
+
+	w.writeTrailingBits()
+	return marshalNALU(h264.NALUTypeIDR, w)
+}
+
+func blackContent(opusChannelCount int) *content {
+	c := &content{
+		sps:              blackSPS(),
+		pps:              blackPPS(),
+		hasVideo:         true,
+		hasAudio:         true,
+		opusChannelCount: opusChannelCount,
+		duration:         time.Second,
+	}
+
+	// consecutive IDR frames must have different IDs.
+	idrs := [][]byte{blackIDR(0), blackIDR(1)}
+
+	for i := 0; i < blackFrameRate; i++ {
+		ts := time.Duration(i) * time.Second / blackFrameRate
+		c.samples = append(c.samples, &sample{
+			video: true,
+			dts:   ts,
+			pts:   ts,
+			data:  [][]byte{c.sps, c.pps, idrs[i%2]},
+		})
+	}
+
+	silence := opusSilenceStereo
+	if opusChannelCount == 1 {
+		silence = opusSilenceMono
+	}
+
+	n := int(time.Second / (opusPacketDuration * opusPacketsPerUnit))
+	for i := 0; i < n; i++ {
+		ts := time.Duration(i) * opusPacketDuration * opusPacketsPerUnit
+		packets := make([][]byte, opusPacketsPerUnit)
+		for j := range packets {
+			packets[j] = silence
+		}
+		c.samples = append(c.samples, &sample{
+			dts:  ts,
+			pts:  ts,
+			data: packets,
+		})
+	}
+
+	c.sortSamples()
+
+	return c
+}
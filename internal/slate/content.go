@@ -0,0 +1,165 @@
+package slate
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/bluenviron/mediacommon/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/pkg/codecs/opus"
+	"github.com/bluenviron/mediacommon/pkg/formats/mpegts"
+)
+
+func multiplyAndDivide(v, m, d int64) int64 {
+	secs := v / d
+	dec := v % d
+	return (secs*m + dec*m/d)
+}
+
+// sample is a H264 access unit or a group of Opus packets.
+type sample struct {
+	video bool
+	dts   time.Duration
+	pts   time.Duration
+	data  [][]byte
+}
+
+// content is the placeholder that is played in loop.
+type content struct {
+	sps              []byte
+	pps              []byte
+	hasVideo         bool
+	hasAudio         bool
+	opusChannelCount int
+	samples          []*sample
+	duration         time.Duration
+}
+
+func (c *content) sortSamples() {
+	sort.SliceStable(c.samples, func(i, j int) bool {
+		return c.samples[i].dts < c.samples[j].dts
+	})
+}
+
+// loadFile loads the H264 and Opus tracks of a MPEG-TS file.
+func loadFile(fpath string) (*content, error) {
+	f, err := os.Open(fpath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r, err := mpegts.NewReader(bufio.NewReader(f))
+	if err != nil {
+		return nil, err
+	}
+
+	r.OnDecodeError(func(_ error) {})
+
+	c := &content{}
+	td := mpegts.NewTimeDecoder2()
+	var videoEnd time.Duration
+	var audioEnd time.Duration
+
+	for _, track := range r.Tracks() {
+		switch codec := track.Codec.(type) {
+		case *mpegts.CodecH264:
+			if c.hasVideo {
+				continue
+			}
+			c.hasVideo = true
+
+			var prevDTS time.Duration
+
+			r.OnDataH264(track, func(pts int64, dts int64, au [][]byte) error {
+				dts = td.Decode(dts)
+				pts = dts + ((pts - dts) & 0x1FFFFFFFF)
+
+				for _, nalu := range au {
+					switch h264.NALUType(nalu[0] & 0x1F) {
+					case h264.NALUTypeSPS:
+						if c.sps == nil {
+							c.sps = nalu
+						}
+
+					case h264.NALUTypePPS:
+						if c.pps == nil {
+							c.pps = nalu
+						}
+					}
+				}
+
+				s := &sample{
+					video: true,
+					dts:   time.Duration(multiplyAndDivide(dts, int64(time.Second), 90000)),
+					pts:   time.Duration(multiplyAndDivide(pts, int64(time.Second), 90000)),
+					data:  au,
+				}
+				c.samples = append(c.samples, s)
+
+				// the duration of the last frame is assumed to be equal to the one of the previous frame
+				videoEnd = s.dts + (s.dts - prevDTS)
+				prevDTS = s.dts
+				return nil
+			})
+
+		case *mpegts.CodecOpus:
+			if c.hasAudio {
+				continue
+			}
+			c.hasAudio = true
+			c.opusChannelCount = codec.ChannelCount
+
+			r.OnDataOpus(track, func(pts int64, packets [][]byte) error {
+				pts = td.Decode(pts)
+
+				s := &sample{
+					dts:  time.Duration(multiplyAndDivide(pts, int64(time.Second), 90000)),
+					data: packets,
+				}
+				s.pts = s.dts
+				c.samples = append(c.samples, s)
+
+				audioEnd = s.dts
+				for _, packet := range packets {
+					audioEnd += opus.PacketDuration(packet)
+				}
+				return nil
+			})
+		}
+	}
+
+	if !c.hasVideo && !c.hasAudio {
+		return nil, fmt.Errorf("the file doesn't contain any H264 or Opus track")
+	}
+
+	for {
+		err := r.Read()
+		if err != nil {
+			break
+		}
+	}
+
+	if len(c.samples) == 0 {
+		return nil, fmt.Errorf("the file doesn't contain any data")
+	}
+
+	if c.hasVideo && (c.sps == nil || c.pps == nil) {
+		return nil, fmt.Errorf("H264 parameters are missing from the file")
+	}
+
+	c.sortSamples()
+
+	// make timestamps start from zero
+	start := c.samples[0].dts
+	for _, s := range c.samples {
+		s.dts -= start
+		s.pts -= start
+	}
+
+	c.duration = max(videoEnd, audioEnd) - start
+
+	return c, nil
+}
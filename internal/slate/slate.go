@@ -0,0 +1,212 @@
+// Package slate contains the slate, a placeholder that is written into a stream
+// while its source is absent.
+package slate
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+// BlackSource is the source of a generated black frame plus silence.
+const BlackSource = "black"
+
+// Slate writes a placeholder into a stream.
+// The placeholder is either a generated black frame plus silence,
+// or the H264 and Opus tracks of a MPEG-TS file, played in loop.
+// Tracks of the stream that are not H264 or Opus are left empty.
+type Slate struct {
+	Source            string
+	WriteQueueSize    int
+	UDPMaxPayloadSize int
+	Stream            *stream.Stream
+	Parent            logger.Writer
+
+	content *content
+	strm    *stream.Stream
+	relay   *stream.Relay
+	medias  []*description.Media
+
+	terminate chan struct{}
+	done      chan struct{}
+}
+
+// Initialize initializes Slate.
+func (s *Slate) Initialize() error {
+	var videoFormat *format.H264
+	var audioFormat *format.Opus
+
+	for _, medi := range s.Stream.Desc().Medias {
+		for _, forma := range medi.Formats {
+			switch forma := forma.(type) {
+			case *format.H264:
+				if videoFormat == nil {
+					videoFormat = forma
+				}
+
+			case *format.Opus:
+				if audioFormat == nil {
+					audioFormat = forma
+				}
+			}
+		}
+	}
+
+	if videoFormat == nil && audioFormat == nil {
+		return fmt.Errorf("the stream doesn't contain any H264 or Opus track")
+	}
+
+	if s.Source == BlackSource {
+		channelCount := 2
+		if audioFormat != nil {
+			channelCount = audioFormat.ChannelCount
+		}
+		s.content = blackContent(channelCount)
+	} else {
+		var err error
+		s.content, err = loadFile(s.Source)
+		if err != nil {
+			return err
+		}
+	}
+
+	if videoFormat != nil && s.content.hasVideo {
+		s.medias = append(s.medias, &description.Media{
+			Type: description.MediaTypeVideo,
+			Formats: []format.Format{&format.H264{
+				PayloadTyp:        96,
+				SPS:               s.content.sps,
+				PPS:               s.content.pps,
+				PacketizationMode: 1,
+			}},
+		})
+	}
+
+	if audioFormat != nil && s.content.hasAudio {
+		s.medias = append(s.medias, &description.Media{
+			Type: description.MediaTypeAudio,
+			Formats: []format.Format{&format.Opus{
+				PayloadTyp:   111,
+				ChannelCount: s.content.opusChannelCount,
+			}},
+		})
+	}
+
+	if s.medias == nil {
+		return fmt.Errorf("the slate doesn't contain any track of the stream")
+	}
+
+	var err error
+	s.strm, err = stream.New(
+		s.WriteQueueSize,
+		s.UDPMaxPayloadSize,
+		&description.Session{Medias: s.medias},
+		true,
+		logger.NewLimitedLogger(s),
+	)
+	if err != nil {
+		return err
+	}
+
+	s.relay = &stream.Relay{
+		Source:            s.strm,
+		Destination:       s.Stream,
+		Parent:            s,
+		SkipMissingTracks: true,
+	}
+	err = s.relay.Initialize()
+	if err != nil {
+		s.strm.Close()
+		return err
+	}
+
+	s.terminate = make(chan struct{})
+	s.done = make(chan struct{})
+
+	go s.run()
+
+	return nil
+}
+
+// Close closes Slate.
+func (s *Slate) Close() {
+	close(s.terminate)
+	<-s.done
+	s.relay.Close()
+	s.strm.Close()
+}
+
+// Log implements logger.Writer.
+func (s *Slate) Log(level logger.Level, format string, args ...interface{}) {
+	s.Parent.Log(level, "[slate] "+format, args...)
+}
+
+// APISourceDescribe implements defs.Source.
+func (*Slate) APISourceDescribe() defs.APIPathSourceOrReader {
+	return defs.APIPathSourceOrReader{
+		Type: "slate",
+		ID:   "",
+	}
+}
+
+func (s *Slate) run() {
+	defer close(s.done)
+
+	start := time.Now()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	<-timer.C
+
+	for loop := time.Duration(0); ; loop++ {
+		base := loop * s.content.duration
+
+		for _, smp := range s.content.samples {
+			timer.Reset(time.Until(start.Add(base + smp.dts)))
+
+			select {
+			case <-timer.C:
+			case <-s.terminate:
+				return
+			}
+
+			s.writeSample(base+smp.pts, smp)
+		}
+	}
+}
+
+func (s *Slate) writeSample(pts time.Duration, smp *sample) {
+	for _, medi := range s.medias {
+		forma := medi.Formats[0]
+
+		switch forma.(type) {
+		case *format.H264:
+			if smp.video {
+				s.strm.WriteUnit(medi, forma, &unit.H264{
+					Base: unit.Base{
+						NTP: time.Now(),
+						PTS: multiplyAndDivide(int64(pts), int64(forma.ClockRate()), int64(time.Second)),
+					},
+					AU: smp.data,
+				})
+			}
+
+		case *format.Opus:
+			if !smp.video {
+				s.strm.WriteUnit(medi, forma, &unit.Opus{
+					Base: unit.Base{
+						NTP: time.Now(),
+						PTS: multiplyAndDivide(int64(pts), int64(forma.ClockRate()), int64(time.Second)),
+					},
+					Packets: smp.data,
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package slate
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/pkg/formats/mpegts"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/test"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+func TestBlackContent(t *testing.T) {
+	c := blackContent(2)
+
+	var sps h264.SPS
+	err := sps.Unmarshal(c.sps)
+	require.NoError(t, err)
+	require.Equal(t, 1280, sps.Width())
+	require.Equal(t, 720, sps.Height())
+
+	videoCount := 0
+	for _, s := range c.samples {
+		if s.video {
+			videoCount++
+			require.True(t, h264.IDRPresent(s.data))
+		}
+	}
+	require.Equal(t, blackFrameRate, videoCount)
+
+	// consecutive IDR frames have different IDs
+	require.NotEqual(t, blackIDR(0), blackIDR(1))
+}
+
+func newTestStream(t *testing.T, medias ...*description.Media) *stream.Stream {
+	strm, err := stream.New(
+		512,
+		1472,
+		&description.Session{Medias: medias},
+		true,
+		test.NilLogger,
+	)
+	require.NoError(t, err)
+	return strm
+}
+
+func TestSlateBlack(t *testing.T) {
+	videoMedia := test.UniqueMediaH264()
+	audioMedia := &description.Media{
+		Type: description.MediaTypeAudio,
+		Formats: []format.Format{&format.Opus{
+			PayloadTyp:   111,
+			ChannelCount: 2,
+		}},
+	}
+	otherMedia := test.UniqueMediaMPEG4Audio()
+
+	strm := newTestStream(t, videoMedia, audioMedia, otherMedia)
+	defer strm.Close()
+
+	videoRecv := make(chan *unit.H264, 10)
+	audioRecv := make(chan *unit.Opus, 10)
+
+	strm.AddReader(test.NilLogger, videoMedia, videoMedia.Formats[0], func(u unit.Unit) error {
+		select {
+		case videoRecv <- u.(*unit.H264):
+		default:
+		}
+		return nil
+	})
+	strm.AddReader(test.NilLogger, audioMedia, audioMedia.Formats[0], func(u unit.Unit) error {
+		select {
+		case audioRecv <- u.(*unit.Opus):
+		default:
+		}
+		return nil
+	})
+	strm.StartReader(test.NilLogger)
+	defer strm.RemoveReader(test.NilLogger)
+
+	s := &Slate{
+		Source:            BlackSource,
+		WriteQueueSize:    512,
+		UDPMaxPayloadSize: 1472,
+		Stream:            strm,
+		Parent:            test.NilLogger,
+	}
+	err := s.Initialize()
+	require.NoError(t, err)
+	defer s.Close()
+
+	vu := <-videoRecv
+	require.True(t, h264.IDRPresent(vu.AU))
+
+	au := <-audioRecv
+	require.Equal(t, [][]byte{
+		opusSilenceStereo,
+		opusSilenceStereo,
+		opusSilenceStereo,
+		opusSilenceStereo,
+		opusSilenceStereo,
+	}, au.Packets)
+}
+
+func TestSlateFile(t *testing.T) {
+	videoTrack := &mpegts.Track{
+		Codec: &mpegts.CodecH264{},
+	}
+	audioTrack := &mpegts.Track{
+		Codec: &mpegts.CodecOpus{ChannelCount: 2},
+	}
+
+	var buf bytes.Buffer
+	w := mpegts.NewWriter(&buf, []*mpegts.Track{videoTrack, audioTrack})
+
+	for i := 0; i < 3; i++ {
+		err := w.WriteH264(videoTrack, int64(i)*3000, int64(i)*3000, true, [][]byte{
+			test.FormatH264.SPS,
+			test.FormatH264.PPS,
+			{5, byte(i)},
+		})
+		require.NoError(t, err)
+
+		err = w.WriteOpus(audioTrack, int64(i)*3000, [][]byte{opusSilenceStereo})
+		require.NoError(t, err)
+	}
+
+	fpath, err := test.CreateTempFile(buf.Bytes())
+	require.NoError(t, err)
+	defer os.Remove(fpath)
+
+	videoMedia := test.UniqueMediaH264()
+
+	strm := newTestStream(t, videoMedia)
+	defer strm.Close()
+
+	recv := make(chan byte, 10)
+
+	strm.AddReader(test.NilLogger, videoMedia, videoMedia.Formats[0], func(u unit.Unit) error {
+		au := u.(*unit.H264).AU
+		select {
+		case recv <- au[len(au)-1][1]:
+		default:
+		}
+		return nil
+	})
+	strm.StartReader(test.NilLogger)
+	defer strm.RemoveReader(test.NilLogger)
+
+	s := &Slate{
+		Source:            fpath,
+		WriteQueueSize:    512,
+		UDPMaxPayloadSize: 1472,
+		Stream:            strm,
+		Parent:            test.NilLogger,
+	}
+	err = s.Initialize()
+	require.NoError(t, err)
+	defer s.Close()
+
+	// the file is played in loop
+	for _, v := range []byte{0, 1, 2, 0} {
+		require.Equal(t, v, <-recv)
+	}
+}
+
+func TestSlateNoTracks(t *testing.T) {
+	strm := newTestStream(t, &description.Media{
+		Type:    description.MediaTypeVideo,
+		Formats: []format.Format{&format.VP8{PayloadTyp: 96}},
+	})
+	defer strm.Close()
+
+	s := &Slate{
+		Source:            BlackSource,
+		WriteQueueSize:    512,
+		UDPMaxPayloadSize: 1472,
+		Stream:            strm,
+		Parent:            test.NilLogger,
+	}
+	err := s.Initialize()
+	require.EqualError(t, err, "the stream doesn't contain any H264 or Opus track")
+}
@@ -0,0 +1,164 @@
+// Package path contains the path static source.
+package path
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/sdp"
+
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+type sourcePathManager interface {
+	AddReader(req defs.PathAddReaderReq) (defs.Path, *stream.Stream, error)
+}
+
+// cloneDescription clones a stream description,
+// in order to avoid sharing formats between streams.
+func cloneDescription(desc *description.Session) (*description.Session, error) {
+	byts, err := desc.Marshal(false)
+	if err != nil {
+		return nil, err
+	}
+
+	var sd sdp.SessionDescription
+	err = sd.Unmarshal(byts)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret description.Session
+	err = ret.Unmarshal(&sd)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+// Source is a static source that reads from another path of the server.
+// It is attached to the stream of the other path as a reader,
+// and copies RTP packets without decoding or re-encoding them.
+type Source struct {
+	PathManager sourcePathManager
+	Parent      defs.StaticSourceParent
+
+	// Close() is called by another routine.
+	mutex     sync.Mutex
+	ctxCancel func()
+}
+
+// Log implements logger.Writer.
+func (s *Source) Log(level logger.Level, format string, args ...interface{}) {
+	s.Parent.Log(level, "[path source] "+format, args...)
+}
+
+// Close implements defs.Reader.
+// It is called by the other path when its stream is not available anymore.
+func (s *Source) Close() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.ctxCancel != nil {
+		s.ctxCancel()
+	}
+}
+
+// Run implements StaticSource.
+func (s *Source) Run(params defs.StaticSourceRunParams) error {
+	pathName := strings.TrimPrefix(params.ResolvedSource, "path://")
+
+	s.Log(logger.Debug, "reading from path '%s'", pathName)
+
+	ctx, ctxCancel := context.WithCancel(params.Context)
+	defer ctxCancel()
+
+	s.mutex.Lock()
+	s.ctxCancel = ctxCancel
+	s.mutex.Unlock()
+
+	path, strm, err := s.PathManager.AddReader(defs.PathAddReaderReq{
+		Author: s,
+		AccessRequest: defs.PathAccessRequest{
+			Name:     pathName,
+			SkipAuth: true,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	defer path.RemoveReader(defs.PathRemoveReaderReq{Author: s})
+
+	desc, err := cloneDescription(strm.Desc())
+	if err != nil {
+		return err
+	}
+
+	res := s.Parent.SetReady(defs.PathSourceStaticSetReadyReq{
+		Desc:               desc,
+		GenerateRTPPackets: false,
+	})
+	if res.Err != nil {
+		return res.Err
+	}
+
+	defer s.Parent.SetNotReady(defs.PathSourceStaticSetNotReadyReq{})
+
+	for i, medi := range strm.Desc().Medias {
+		for j, forma := range medi.Formats {
+			dstMedia := desc.Medias[i]
+			dstFormat := dstMedia.Formats[j]
+
+			strm.AddReader(s, medi, forma, func(u unit.Unit) error {
+				for _, pkt := range u.GetRTPPackets() {
+					// packets are shared with other readers, therefore they are copied.
+					cpkt := *pkt
+					res.Stream.WriteRTPPacket(dstMedia, dstFormat, &cpkt, u.GetNTP(), u.GetPTS())
+				}
+				return nil
+			})
+		}
+	}
+
+	s.Log(logger.Info, "reading from path '%s', %s",
+		pathName, defs.FormatsInfo(strm.ReaderFormats(s)))
+
+	strm.StartReader(s)
+	defer strm.RemoveReader(s)
+
+	for {
+		select {
+		case err := <-strm.ReaderError(s):
+			return err
+
+		case <-params.ReloadConf:
+
+		case <-ctx.Done():
+			if params.Context.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("path '%s' is not ready anymore", pathName)
+		}
+	}
+}
+
+// APISourceDescribe implements StaticSource.
+func (*Source) APISourceDescribe() defs.APIPathSourceOrReader {
+	return defs.APIPathSourceOrReader{
+		Type: "pathSource",
+		ID:   "",
+	}
+}
+
+// APIReaderDescribe implements defs.Reader.
+func (s *Source) APIReaderDescribe() defs.APIPathSourceOrReader {
+	return s.APISourceDescribe()
+}
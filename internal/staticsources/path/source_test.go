@@ -0,0 +1,150 @@
+package path
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/externalcmd"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/test"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+type dummyPath struct {
+	readerRemoved chan struct{}
+}
+
+func (dummyPath) Name() string {
+	return "source"
+}
+
+func (dummyPath) SafeConf() *conf.Path {
+	return &conf.Path{}
+}
+
+func (dummyPath) ExternalCmdEnv() externalcmd.Environment {
+	return externalcmd.Environment{}
+}
+
+func (dummyPath) StartPublisher(_ defs.PathStartPublisherReq) (*stream.Stream, error) {
+	return nil, nil
+}
+
+func (dummyPath) StopPublisher(_ defs.PathStopPublisherReq) {
+}
+
+func (dummyPath) RemovePublisher(_ defs.PathRemovePublisherReq) {
+}
+
+func (p *dummyPath) RemoveReader(_ defs.PathRemoveReaderReq) {
+	close(p.readerRemoved)
+}
+
+type dummyPathManager struct {
+	path   *dummyPath
+	stream *stream.Stream
+	added  chan string
+}
+
+func (pm *dummyPathManager) AddReader(req defs.PathAddReaderReq) (defs.Path, *stream.Stream, error) {
+	pm.added <- req.AccessRequest.Name
+	return pm.path, pm.stream, nil
+}
+
+func TestSource(t *testing.T) {
+	medi := test.UniqueMediaH264()
+
+	strm, err := stream.New(
+		512,
+		1472,
+		&description.Session{Medias: []*description.Media{medi}},
+		true,
+		test.NilLogger,
+	)
+	require.NoError(t, err)
+	defer strm.Close()
+
+	pm := &dummyPathManager{
+		path:   &dummyPath{readerRemoved: make(chan struct{})},
+		stream: strm,
+		added:  make(chan string, 1),
+	}
+
+	te := test.NewSourceTester(
+		func(p defs.StaticSourceParent) defs.StaticSource {
+			return &Source{
+				PathManager: pm,
+				Parent:      p,
+			}
+		},
+		"path://source",
+		&conf.Path{},
+	)
+
+	require.Equal(t, "source", <-pm.added)
+
+	strm.WaitRunningReader()
+
+	strm.WriteUnit(medi, medi.Formats[0], &unit.H264{
+		AU: [][]byte{{5, 1}},
+	})
+
+	u := <-te.Unit
+	require.Equal(t, [][]byte{
+		test.FormatH264.SPS,
+		test.FormatH264.PPS,
+		{5, 1},
+	}, u.(*unit.H264).AU)
+
+	te.Close()
+	<-pm.path.readerRemoved
+}
+
+type errorPathManager struct{}
+
+func (errorPathManager) AddReader(_ defs.PathAddReaderReq) (defs.Path, *stream.Stream, error) {
+	return nil, nil, fmt.Errorf("not found")
+}
+
+type dummyParent struct {
+	defs.StaticSourceParent
+}
+
+func (dummyParent) Log(_ logger.Level, _ string, _ ...interface{}) {
+}
+
+func TestSourceCloseDuringRun(t *testing.T) {
+	s := &Source{
+		PathManager: errorPathManager{},
+		Parent:      dummyParent{},
+	}
+
+	// the source can be closed by the other path at any time,
+	// even when it's not running.
+	s.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			s.Close()
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		err := s.Run(defs.StaticSourceRunParams{
+			Context:        context.Background(),
+			ResolvedSource: "path://source",
+		})
+		require.EqualError(t, err, "not found")
+	}
+
+	<-done
+}
@@ -0,0 +1,163 @@
+package stream
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+func timestampToDuration(ts int64, clockRate int) time.Duration {
+	return time.Duration(float64(ts) / float64(clockRate) * float64(time.Second))
+}
+
+func durationToTimestamp(d time.Duration, clockRate int) int64 {
+	return int64(math.Round(d.Seconds() * float64(clockRate)))
+}
+
+// cloneUnit performs a shallow copy of a unit.
+func cloneUnit(u unit.Unit) unit.Unit {
+	v := reflect.New(reflect.TypeOf(u).Elem())
+	v.Elem().Set(reflect.ValueOf(u).Elem())
+	return v.Interface().(unit.Unit)
+}
+
+// Relay copies units from a stream into another one.
+// Timestamps are rebased in order to continue from the last unit
+// written into the destination, therefore the destination can be fed
+// by multiple streams in sequence.
+// The destination must be created with generateRTPPackets set to true.
+type Relay struct {
+	Source      *Stream
+	Destination *Stream
+	Parent      logger.Writer
+
+	// if true, tracks of the destination that are not available in the source
+	// are skipped instead of causing an error.
+	SkipMissingTracks bool
+
+	offsetSet bool
+	offset    time.Duration
+}
+
+// Initialize initializes Relay.
+// It returns an error if the source does not contain all tracks of the destination,
+// unless SkipMissingTracks is set, or if no track can be relayed at all.
+func (r *Relay) Initialize() error {
+	used := make(map[format.Format]struct{})
+
+	type pair struct {
+		srcMedia  *description.Media
+		srcFormat format.Format
+		dstMedia  *description.Media
+		dstFormat format.Format
+	}
+	var pairs []pair
+	i := 0
+
+	for _, dstMedia := range r.Destination.desc.Medias {
+		for _, dstFormat := range dstMedia.Formats {
+			i++
+
+			srcMedia, srcFormat := findCompatibleFormat(r.Source.desc, dstMedia.Type, dstFormat, used)
+			if srcFormat == nil {
+				if r.SkipMissingTracks {
+					continue
+				}
+				return fmt.Errorf("track %d (%s) is not available in the source",
+					i, dstFormat.Codec())
+			}
+
+			used[srcFormat] = struct{}{}
+			pairs = append(pairs, pair{srcMedia, srcFormat, dstMedia, dstFormat})
+		}
+	}
+
+	if len(pairs) == 0 {
+		return fmt.Errorf("none of the tracks is available in the source")
+	}
+
+	for _, p := range pairs {
+		cp := p
+		r.Source.AddReader(r, cp.srcMedia, cp.srcFormat, func(u unit.Unit) error {
+			r.writeUnit(cp.dstMedia, cp.dstFormat, u)
+			return nil
+		})
+	}
+
+	r.Source.StartReader(r)
+
+	return nil
+}
+
+// Close closes Relay.
+func (r *Relay) Close() {
+	r.Source.RemoveReader(r)
+}
+
+// Log implements logger.Writer.
+func (r *Relay) Log(level logger.Level, format string, args ...interface{}) {
+	r.Parent.Log(level, format, args...)
+}
+
+func (r *Relay) writeUnit(dstMedia *description.Media, dstFormat format.Format, u unit.Unit) {
+	clockRate := dstFormat.ClockRate()
+
+	// the offset is computed once, on the first unit of any track,
+	// in order to preserve synchronization between tracks.
+	if !r.offsetSet {
+		r.offsetSet = true
+		if next, ok := r.Destination.nextTimestamp(time.Now()); ok {
+			r.offset = next - timestampToDuration(u.GetPTS(), clockRate)
+		}
+	}
+
+	delta := durationToTimestamp(r.offset, clockRate)
+	pts := u.GetPTS() + delta
+
+	// generic formats can't be re-encoded, therefore RTP packets are copied.
+	if _, ok := dstFormat.(*format.Generic); ok {
+		for _, pkt := range u.GetRTPPackets() {
+			cpkt := *pkt
+			cpkt.Timestamp += uint32(delta)
+			r.Destination.WriteRTPPacket(dstMedia, dstFormat, &cpkt, u.GetNTP(), pts)
+		}
+		return
+	}
+
+	cu := cloneUnit(u)
+	cu.SetPTS(pts)
+	cu.SetRTPPackets(nil)
+	r.Destination.WriteUnit(dstMedia, dstFormat, cu)
+}
+
+func findCompatibleFormat(
+	desc *description.Session,
+	mediaType description.MediaType,
+	forma format.Format,
+	used map[format.Format]struct{},
+) (*description.Media, format.Format) {
+	for _, medi := range desc.Medias {
+		if medi.Type != mediaType {
+			continue
+		}
+
+		for _, cand := range medi.Formats {
+			if _, ok := used[cand]; ok {
+				continue
+			}
+
+			if cand.Codec() == forma.Codec() && cand.ClockRate() == forma.ClockRate() {
+				return medi, cand
+			}
+		}
+	}
+
+	return nil, nil
+}
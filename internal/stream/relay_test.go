@@ -0,0 +1,134 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+type nilLogger struct{}
+
+func (nilLogger) Log(logger.Level, string, ...interface{}) {
+}
+
+func newRelayTestMedia() *description.Media {
+	return &description.Media{
+		Type: description.MediaTypeVideo,
+		Formats: []format.Format{&format.H264{
+			PayloadTyp:        96,
+			PacketizationMode: 1,
+		}},
+	}
+}
+
+func TestRelay(t *testing.T) {
+	newStream := func() (*Stream, *description.Media) {
+		medi := newRelayTestMedia()
+		s, err := New(512, 1472, &description.Session{Medias: []*description.Media{medi}}, true, &nilLogger{})
+		require.NoError(t, err)
+		return s, medi
+	}
+
+	dest, destMedia := newStream()
+	defer dest.Close()
+
+	recv := make(chan int64, 10)
+	reader := &nilLogger{}
+	dest.AddReader(reader, destMedia, destMedia.Formats[0], func(u unit.Unit) error {
+		if u.(*unit.H264).AU != nil {
+			require.NotEmpty(t, u.GetRTPPackets())
+			recv <- u.GetPTS()
+		}
+		return nil
+	})
+	dest.StartReader(reader)
+	defer dest.RemoveReader(reader)
+
+	writeAndRelay := func(pts []int64) []int64 {
+		src, srcMedia := newStream()
+		defer src.Close()
+
+		r := &Relay{
+			Source:      src,
+			Destination: dest,
+			Parent:      &nilLogger{},
+		}
+		err := r.Initialize()
+		require.NoError(t, err)
+		defer r.Close()
+
+		var ret []int64
+		for _, v := range pts {
+			src.WriteUnit(srcMedia, srcMedia.Formats[0], &unit.H264{
+				Base: unit.Base{PTS: v},
+				AU:   [][]byte{{0x05, 0x01}},
+			})
+			ret = append(ret, <-recv)
+		}
+		return ret
+	}
+
+	// the first source is relayed as is
+	out := writeAndRelay([]int64{1000, 4000})
+	require.Equal(t, []int64{1000, 4000}, out)
+
+	// the second source continues from the first one
+	out = writeAndRelay([]int64{90000 * 60, 90000*60 + 3000})
+	require.GreaterOrEqual(t, out[0], int64(4000))
+	require.Less(t, out[0], int64(4000+90000))
+	require.Equal(t, int64(3000), out[1]-out[0])
+}
+
+func TestRelayIncompatible(t *testing.T) {
+	src, err := New(512, 1472, &description.Session{Medias: []*description.Media{newRelayTestMedia()}},
+		true, &nilLogger{})
+	require.NoError(t, err)
+	defer src.Close()
+
+	dest, err := New(512, 1472, &description.Session{Medias: []*description.Media{{
+		Type:    description.MediaTypeVideo,
+		Formats: []format.Format{&format.VP8{PayloadTyp: 96}},
+	}}}, true, &nilLogger{})
+	require.NoError(t, err)
+	defer dest.Close()
+
+	r := &Relay{
+		Source:      src,
+		Destination: dest,
+		Parent:      &nilLogger{},
+	}
+	err = r.Initialize()
+	require.EqualError(t, err, "track 1 (VP8) is not available in the source")
+}
+
+func TestRelaySkipMissingTracks(t *testing.T) {
+	src, err := New(512, 1472, &description.Session{Medias: []*description.Media{newRelayTestMedia()}},
+		true, &nilLogger{})
+	require.NoError(t, err)
+	defer src.Close()
+
+	dest, err := New(512, 1472, &description.Session{Medias: []*description.Media{
+		{
+			Type:    description.MediaTypeVideo,
+			Formats: []format.Format{&format.VP8{PayloadTyp: 96}},
+		},
+		newRelayTestMedia(),
+	}}, true, &nilLogger{})
+	require.NoError(t, err)
+	defer dest.Close()
+
+	r := &Relay{
+		Source:            src,
+		Destination:       dest,
+		Parent:            &nilLogger{},
+		SkipMissingTracks: true,
+	}
+	err = r.Initialize()
+	require.NoError(t, err)
+	r.Close()
+}
@@ -0,0 +1,55 @@
+package stream
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+)
+
+func mediaMatches(medi *description.Media, pos int, selector string) bool {
+	if n, err := strconv.ParseUint(selector, 10, 31); err == nil {
+		return int(n) == pos
+	}
+
+	if strings.EqualFold(string(medi.Type), selector) {
+		return true
+	}
+
+	for _, forma := range medi.Formats {
+		if strings.EqualFold(forma.Codec(), selector) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func mediaMatchesAny(medi *description.Media, pos int, selectors []string) bool {
+	for _, selector := range selectors {
+		if mediaMatches(medi, pos, selector) {
+			return true
+		}
+	}
+	return false
+}
+
+// SelectMedias returns the medias that match at least one of the included selectors
+// (or all medias when there are no included selectors) and none of the excluded selectors.
+// A selector is a media type (video, audio, application),
+// a codec name (case insensitive) or the position of the media, starting from 1.
+func SelectMedias(medias []*description.Media, include []string, exclude []string) []*description.Media {
+	var ret []*description.Media
+
+	for i, medi := range medias {
+		if len(include) != 0 && !mediaMatchesAny(medi, i+1, include) {
+			continue
+		}
+		if mediaMatchesAny(medi, i+1, exclude) {
+			continue
+		}
+		ret = append(ret, medi)
+	}
+
+	return ret
+}
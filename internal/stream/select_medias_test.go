@@ -0,0 +1,116 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+func TestSelectMedias(t *testing.T) {
+	video1 := newRelayTestMedia()
+	audio := &description.Media{
+		Type: description.MediaTypeAudio,
+		Formats: []format.Format{&format.Opus{
+			PayloadTyp:   111,
+			ChannelCount: 2,
+		}},
+	}
+	video2 := &description.Media{
+		Type:    description.MediaTypeVideo,
+		Formats: []format.Format{&format.VP8{PayloadTyp: 97}},
+	}
+	medias := []*description.Media{video1, audio, video2}
+
+	for _, ca := range []struct {
+		name    string
+		include []string
+		exclude []string
+		res     []*description.Media
+	}{
+		{
+			"all",
+			nil,
+			nil,
+			medias,
+		},
+		{
+			"type",
+			[]string{"video"},
+			nil,
+			[]*description.Media{video1, video2},
+		},
+		{
+			"codec",
+			[]string{"h264", "OPUS"},
+			nil,
+			[]*description.Media{video1, audio},
+		},
+		{
+			"position",
+			[]string{"3"},
+			nil,
+			[]*description.Media{video2},
+		},
+		{
+			"exclude",
+			[]string{"video"},
+			[]string{"1"},
+			[]*description.Media{video2},
+		},
+		{
+			"none",
+			[]string{"application"},
+			nil,
+			nil,
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			require.Equal(t, ca.res, SelectMedias(medias, ca.include, ca.exclude))
+		})
+	}
+}
+
+func TestStreamView(t *testing.T) {
+	video := newRelayTestMedia()
+	audio := &description.Media{
+		Type: description.MediaTypeAudio,
+		Formats: []format.Format{&format.Opus{
+			PayloadTyp:   111,
+			ChannelCount: 2,
+		}},
+	}
+
+	s, err := New(512, 1472, &description.Session{Medias: []*description.Media{video, audio}}, true, &nilLogger{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.Equal(t, s, s.View([]*description.Media{video, audio}))
+
+	v := s.View([]*description.Media{audio})
+	require.Equal(t, []*description.Media{audio}, v.Desc().Medias)
+	require.Equal(t, v, s.View([]*description.Media{audio}))
+
+	recv := make(chan struct{})
+	reader := &nilLogger{}
+	v.AddReader(reader, audio, audio.Formats[0], func(_ unit.Unit) error {
+		close(recv)
+		return nil
+	})
+	v.StartReader(reader)
+	defer v.RemoveReader(reader)
+
+	s.WaitRunningReader()
+
+	s.WriteUnit(audio, audio.Formats[0], &unit.Opus{
+		Packets: [][]byte{{1, 2, 3}},
+	})
+
+	<-recv
+
+	// units of medias that are not part of the stream are discarded
+	s.WriteUnit(newRelayTestMedia(), video.Formats[0], &unit.H264{})
+}
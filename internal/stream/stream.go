@@ -2,6 +2,8 @@
 package stream
 
 import (
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -11,6 +13,7 @@ import (
 	"github.com/bluenviron/gortsplib/v4/pkg/format"
 	"github.com/pion/rtp"
 
+	"github.com/bluenviron/mediamtx/internal/formatprocessor"
 	"github.com/bluenviron/mediamtx/internal/logger"
 	"github.com/bluenviron/mediamtx/internal/unit"
 )
@@ -23,6 +26,18 @@ type Reader interface {
 // ReadFunc is the callback passed to AddReader().
 type ReadFunc func(unit.Unit) error
 
+// ReaderStats are statistics of a reader.
+type ReaderStats struct {
+	// units written to the reader.
+	UnitsDelivered uint64
+	// units discarded since the write queue was full.
+	UnitsDropped uint64
+	// units waiting in the write queue.
+	QueueDepth int
+	// maximum time spent by a unit in the write queue.
+	MaxLag time.Duration
+}
+
 // Stream is a media stream.
 // It stores tracks, readers and allows to write data to readers.
 type Stream struct {
@@ -32,10 +47,12 @@ type Stream struct {
 	bytesReceived *uint64
 	bytesSent     *uint64
 	streamMedias  map[*description.Media]*streamMedia
-	mutex         sync.RWMutex
+	mutex         *sync.RWMutex
 	rtspStream    *gortsplib.ServerStream
 	rtspsStream   *gortsplib.ServerStream
 	streamReaders map[Reader]*streamReader
+	views         map[string]*Stream
+	timeShift     *timeShiftBuffer
 
 	readerRunning chan struct{}
 }
@@ -53,10 +70,12 @@ func New(
 		desc:           desc,
 		bytesReceived:  new(uint64),
 		bytesSent:      new(uint64),
+		mutex:          &sync.RWMutex{},
 	}
 
 	s.streamMedias = make(map[*description.Media]*streamMedia)
 	s.streamReaders = make(map[Reader]*streamReader)
+	s.views = make(map[string]*Stream)
 	s.readerRunning = make(chan struct{})
 
 	for _, media := range desc.Medias {
@@ -78,6 +97,66 @@ func (s *Stream) Close() {
 	if s.rtspsStream != nil {
 		s.rtspsStream.Close()
 	}
+	for _, v := range s.views {
+		v.Close()
+	}
+}
+
+// EnableTimeShift enables the time-shift buffer,
+// that stores units received in the given period, up to the given size,
+// in order to allow readers to start from a past position of the stream.
+// It must be called before the stream is used.
+func (s *Stream) EnableTimeShift(duration time.Duration, maxSize uint64) {
+	s.timeShift = &timeShiftBuffer{
+		duration: duration,
+		maxSize:  maxSize,
+	}
+}
+
+// View returns a stream that contains only the given medias of the stream.
+// The view shares readers, statistics and data with the stream,
+// and is closed together with it.
+func (s *Stream) View(medias []*description.Media) *Stream {
+	var keys []string
+	for i, medi := range s.desc.Medias {
+		for _, medi2 := range medias {
+			if medi == medi2 {
+				keys = append(keys, strconv.Itoa(i))
+				break
+			}
+		}
+	}
+
+	if len(keys) == len(s.desc.Medias) {
+		return s
+	}
+
+	key := strings.Join(keys, ",")
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if v, ok := s.views[key]; ok {
+		return v
+	}
+
+	desc := *s.desc
+	desc.Medias = medias
+
+	v := &Stream{
+		writeQueueSize: s.writeQueueSize,
+		desc:           &desc,
+		bytesReceived:  s.bytesReceived,
+		bytesSent:      s.bytesSent,
+		streamMedias:   s.streamMedias,
+		mutex:          s.mutex,
+		streamReaders:  s.streamReaders,
+		readerRunning:  s.readerRunning,
+		timeShift:      s.timeShift,
+	}
+	s.views[key] = v
+
+	return v
 }
 
 // Desc returns the description of the stream.
@@ -102,9 +181,56 @@ func (s *Stream) BytesSent() uint64 {
 	if s.rtspsStream != nil {
 		bytesSent += s.rtspsStream.BytesSent()
 	}
+	for _, v := range s.views {
+		if v.rtspStream != nil {
+			bytesSent += v.rtspStream.BytesSent()
+		}
+		if v.rtspsStream != nil {
+			bytesSent += v.rtspsStream.BytesSent()
+		}
+	}
 	return bytesSent
 }
 
+// TrackStats returns statistics of every track, in the same order of Desc().
+func (s *Stream) TrackStats() []TrackStats {
+	now := time.Now()
+	var ret []TrackStats
+
+	for _, media := range s.desc.Medias {
+		sm := s.streamMedias[media]
+		for _, forma := range media.Formats {
+			ret = append(ret, sm.formats[forma].stats.get(now))
+		}
+	}
+
+	return ret
+}
+
+// nextTimestamp returns the timestamp that a unit received now should have
+// in order to continue the stream, or false if the stream has not received any unit.
+func (s *Stream) nextTimestamp(now time.Time) (time.Duration, bool) {
+	var ret time.Duration
+	found := false
+
+	for _, sm := range s.streamMedias {
+		for forma, sf := range sm.formats {
+			pts, t := sf.stats.lastTimestamp()
+			if t.IsZero() {
+				continue
+			}
+
+			next := timestampToDuration(pts, forma.ClockRate()) + now.Sub(t)
+			if !found || next > ret {
+				ret = next
+				found = true
+			}
+		}
+	}
+
+	return ret, found
+}
+
 // RTSPStream returns the RTSP stream.
 func (s *Stream) RTSPStream(server *gortsplib.Server) *gortsplib.ServerStream {
 	s.mutex.Lock()
@@ -137,6 +263,7 @@ func (s *Stream) AddReader(reader Reader, medi *description.Media, forma format.
 	if !ok {
 		sr = &streamReader{
 			queueSize: s.writeQueueSize,
+			bytesSent: s.bytesSent,
 			parent:    reader,
 		}
 		sr.initialize()
@@ -165,6 +292,10 @@ func (s *Stream) RemoveReader(reader Reader) {
 
 	delete(s.streamReaders, reader)
 
+	if sr.timeShift != nil {
+		sr.timeShift.close()
+	}
+
 	sr.stop()
 }
 
@@ -191,6 +322,55 @@ func (s *Stream) StartReader(reader Reader) {
 	}
 }
 
+// StartReaderWithOffset starts a reader from a past position of the stream,
+// stored in the time-shift buffer. offset is negative and relative to the current time.
+// Units are sent faster than real time until the reader catches up with the live stream.
+// When the time-shift buffer is disabled, the reader starts from the live stream.
+// Used by all protocols except RTSP.
+func (s *Stream) StartReaderWithOffset(reader Reader, offset time.Duration) {
+	if s.timeShift == nil || offset >= 0 {
+		s.StartReader(reader)
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	sr := s.streamReaders[reader]
+
+	sr.start()
+
+	r := &timeShiftReader{
+		s:   s,
+		sr:  sr,
+		cbs: make(map[*streamFormat]ReadFunc),
+	}
+
+	for _, sm := range s.streamMedias {
+		for _, sf := range sm.formats {
+			if cb, ok := sf.pausedReaders[sr]; ok {
+				r.cbs[sf] = cb
+			}
+		}
+	}
+
+	r.initialize(offset)
+	sr.timeShift = r
+
+	go r.run()
+
+	select {
+	case <-s.readerRunning:
+	default:
+		close(s.readerRunning)
+	}
+}
+
+// TimeShiftEnabled returns whether the time-shift buffer is enabled.
+func (s *Stream) TimeShiftEnabled() bool {
+	return s.timeShift != nil
+}
+
 // ReaderError returns whenever there's an error.
 func (s *Stream) ReaderError(reader Reader) chan error {
 	sr := s.streamReaders[reader]
@@ -218,14 +398,31 @@ func (s *Stream) ReaderFormats(reader Reader) []format.Format {
 	return formats
 }
 
+// ReaderStats returns statistics of a reader.
+// It returns false if the reader is not reading the stream.
+func (s *Stream) ReaderStats(reader Reader) (ReaderStats, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	sr, ok := s.streamReaders[reader]
+	if !ok {
+		return ReaderStats{}, false
+	}
+	return sr.stats(), true
+}
+
 // WaitRunningReader waits for a running reader.
 func (s *Stream) WaitRunningReader() {
 	<-s.readerRunning
 }
 
 // WriteUnit writes a Unit.
+// Units of medias that are not part of the stream are discarded.
 func (s *Stream) WriteUnit(medi *description.Media, forma format.Format, u unit.Unit) {
-	sm := s.streamMedias[medi]
+	sm, ok := s.streamMedias[medi]
+	if !ok {
+		return
+	}
 	sf := sm.formats[forma]
 
 	s.mutex.RLock()
@@ -235,6 +432,7 @@ func (s *Stream) WriteUnit(medi *description.Media, forma format.Format, u unit.
 }
 
 // WriteRTPPacket writes a RTP packet.
+// Packets of medias that are not part of the stream are discarded.
 func (s *Stream) WriteRTPPacket(
 	medi *description.Media,
 	forma format.Format,
@@ -242,7 +440,10 @@ func (s *Stream) WriteRTPPacket(
 	ntp time.Time,
 	pts int64,
 ) {
-	sm := s.streamMedias[medi]
+	sm, ok := s.streamMedias[medi]
+	if !ok {
+		return
+	}
 	sf := sm.formats[forma]
 
 	s.mutex.RLock()
@@ -250,3 +451,44 @@ func (s *Stream) WriteRTPPacket(
 
 	sf.writeRTPPacket(s, medi, pkt, ntp, pts)
 }
+
+// WriteMetadata embeds metadata into all H264 and H265 formats of the stream.
+// Metadata is attached to the next access unit, inside a user data unregistered SEI NAL unit.
+func (s *Stream) WriteMetadata(payload []byte) {
+	for _, sm := range s.streamMedias {
+		for _, sf := range sm.formats {
+			if inj, ok := sf.proc.(formatprocessor.SEIInjector); ok {
+				inj.InjectSEI(payload)
+			}
+		}
+	}
+}
+
+func (s *Stream) writeRTSP(medi *description.Media, u unit.Unit) {
+	if s.rtspStream == nil && s.rtspsStream == nil {
+		return
+	}
+
+	found := false
+	for _, medi2 := range s.desc.Medias {
+		if medi2 == medi {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return
+	}
+
+	if s.rtspStream != nil {
+		for _, pkt := range u.GetRTPPackets() {
+			s.rtspStream.WritePacketRTPWithNTP(medi, pkt, u.GetNTP()) //nolint:errcheck
+		}
+	}
+
+	if s.rtspsStream != nil {
+		for _, pkt := range u.GetRTPPackets() {
+			s.rtspsStream.WritePacketRTPWithNTP(medi, pkt, u.GetNTP()) //nolint:errcheck
+		}
+	}
+}
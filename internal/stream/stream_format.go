@@ -24,10 +24,12 @@ func unitSize(u unit.Unit) uint64 {
 type streamFormat struct {
 	udpMaxPayloadSize  int
 	format             format.Format
+	mediaType          description.MediaType
 	generateRTPPackets bool
 	decodeErrLogger    logger.Writer
 
 	proc           formatprocessor.Processor
+	stats          *streamFormatStats
 	pausedReaders  map[*streamReader]ReadFunc
 	runningReaders map[*streamReader]ReadFunc
 }
@@ -35,6 +37,10 @@ type streamFormat struct {
 func (sf *streamFormat) initialize() error {
 	sf.pausedReaders = make(map[*streamReader]ReadFunc)
 	sf.runningReaders = make(map[*streamReader]ReadFunc)
+	sf.stats = &streamFormatStats{
+		format:    sf.format,
+		mediaType: sf.mediaType,
+	}
 
 	var err error
 	sf.proc, err = formatprocessor.New(sf.udpMaxPayloadSize, sf.format, sf.generateRTPPackets)
@@ -78,7 +84,9 @@ func (sf *streamFormat) writeRTPPacket(
 	ntp time.Time,
 	pts int64,
 ) {
-	hasNonRTSPReaders := len(sf.pausedReaders) > 0 || len(sf.runningReaders) > 0
+	// units stored in the time-shift buffer are decoded, since they can be sent to any reader.
+	hasNonRTSPReaders := len(sf.pausedReaders) > 0 || len(sf.runningReaders) > 0 ||
+		s.timeShift != nil
 
 	u, err := sf.proc.ProcessRTPPacket(pkt, ntp, pts, hasNonRTSPReaders)
 	if err != nil {
@@ -94,23 +102,27 @@ func (sf *streamFormat) writeUnitInner(s *Stream, medi *description.Media, u uni
 
 	atomic.AddUint64(s.bytesReceived, size)
 
-	if s.rtspStream != nil {
-		for _, pkt := range u.GetRTPPackets() {
-			s.rtspStream.WritePacketRTPWithNTP(medi, pkt, u.GetNTP()) //nolint:errcheck
-		}
+	now := time.Now()
+
+	sf.stats.onUnit(u, size, now)
+
+	if s.timeShift != nil {
+		_, isKeyFrame := unitFrameInfo(u)
+		s.timeShift.push(&timeShiftEntry{
+			sf:       sf,
+			u:        u,
+			size:     size,
+			t:        now,
+			keyFrame: isKeyFrame,
+		})
 	}
 
-	if s.rtspsStream != nil {
-		for _, pkt := range u.GetRTPPackets() {
-			s.rtspsStream.WritePacketRTPWithNTP(medi, pkt, u.GetNTP()) //nolint:errcheck
-		}
+	s.writeRTSP(medi, u)
+	for _, v := range s.views {
+		v.writeRTSP(medi, u)
 	}
 
 	for sr, cb := range sf.runningReaders {
-		ccb := cb
-		sr.push(func() error {
-			atomic.AddUint64(s.bytesSent, size)
-			return ccb(u)
-		})
+		sr.push(cb, u, size)
 	}
 }
@@ -0,0 +1,605 @@
+package stream
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/mediacommon/pkg/codecs/av1"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h265"
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+const (
+	// period in which bitrate and frame rate are computed.
+	statsWindow = 5 * time.Second
+)
+
+// TrackStats are statistics of a track.
+type TrackStats struct {
+	Codec string
+	// bits per second.
+	Bitrate float64
+	// available with video tracks only.
+	Video *VideoTrackStats
+	// available with audio tracks only.
+	Audio *AudioTrackStats
+}
+
+// VideoTrackStats are statistics of a video track.
+type VideoTrackStats struct {
+	FrameRate float64
+	// frames between the last two key frames.
+	GOPLength int
+	// time between the last two key frames.
+	KeyFrameInterval time.Duration
+	// zero when no key frame has been received.
+	LastKeyFrameTime time.Time
+	// available with H264, H265 and AV1 only.
+	Width   int
+	Height  int
+	Profile string
+}
+
+// AudioTrackStats are statistics of an audio track.
+type AudioTrackStats struct {
+	SampleRate   int
+	ChannelCount int
+}
+
+type statsSample struct {
+	t       time.Time
+	size    uint64
+	isFrame bool
+}
+
+// streamFormatStats computes rolling statistics of a format.
+type streamFormatStats struct {
+	format    format.Format
+	mediaType description.MediaType
+
+	mutex            sync.Mutex
+	firstSample      time.Time
+	lastSample       time.Time
+	lastPTS          int64
+	maxTimestampJump time.Duration
+	h264DTSExtractor *h264.DTSExtractor2
+	h265DTSExtractor *h265.DTSExtractor2
+	lastDTS          int64
+	lastDTSFilled    bool
+	frameDuration    time.Duration
+	frameDurationSet bool
+	samples          []statsSample
+	framesSinceKey   int
+	gopLength        int
+	keyFrameInterval time.Duration
+	lastKeyFrame     time.Time
+	av1SeqHeader     *av1.SequenceHeader
+	// whether the frame being received through RTP packets is a key frame.
+	rtpKeyFrame bool
+}
+
+func (st *streamFormatStats) onUnit(u unit.Unit, size uint64, now time.Time) {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	// video is not necessarily decoded, therefore frames are detected
+	// through RTP packets when they are available.
+	var isFrame, isKeyFrame bool
+	if pkts := u.GetRTPPackets(); st.mediaType == description.MediaTypeVideo && len(pkts) != 0 {
+		isFrame, isKeyFrame = st.rtpFrameInfo(pkts)
+	} else {
+		isFrame, isKeyFrame = unitFrameInfo(u)
+	}
+
+	if st.firstSample.IsZero() {
+		st.firstSample = now
+	}
+
+	// timestamps of video are compared once per frame.
+	if isFrame || st.mediaType != description.MediaTypeVideo {
+		if dts, ok := st.unitDTS(u, isKeyFrame); ok {
+			st.updateTimestampJump(dts)
+		}
+	}
+
+	st.lastSample = now
+	st.lastPTS = u.GetPTS()
+
+	st.samples = append(st.samples, statsSample{
+		t:       now,
+		size:    size,
+		isFrame: isFrame,
+	})
+	st.trimSamples(now)
+
+	if isKeyFrame {
+		if !st.lastKeyFrame.IsZero() {
+			st.gopLength = st.framesSinceKey
+			st.keyFrameInterval = now.Sub(st.lastKeyFrame)
+		}
+		st.lastKeyFrame = now
+		st.framesSinceKey = 0
+
+		if tu, ok := u.(*unit.AV1); ok && tu.TU != nil {
+			if sh := av1FindSequenceHeader(tu.TU); sh != nil {
+				st.av1SeqHeader = sh
+			}
+		}
+	}
+
+	if isFrame {
+		st.framesSinceKey++
+	}
+}
+
+// unitDTS returns the decode timestamp of a unit.
+// When frames are decoded, it is computed from the PTS, that is not monotonic
+// when B-frames are used. Otherwise, the PTS is used.
+// It must be called with mutex locked.
+func (st *streamFormatStats) unitDTS(u unit.Unit, isKeyFrame bool) (int64, bool) {
+	switch tu := u.(type) {
+	case *unit.H264:
+		if tu.AU == nil {
+			break
+		}
+
+		if st.h264DTSExtractor == nil {
+			if !isKeyFrame {
+				return 0, false
+			}
+			st.h264DTSExtractor = h264.NewDTSExtractor2()
+		}
+
+		dts, err := st.h264DTSExtractor.Extract(tu.AU, tu.PTS)
+		if err != nil {
+			st.h264DTSExtractor = nil
+			return 0, false
+		}
+		return dts, true
+
+	case *unit.H265:
+		if tu.AU == nil {
+			break
+		}
+
+		if st.h265DTSExtractor == nil {
+			if !isKeyFrame {
+				return 0, false
+			}
+			st.h265DTSExtractor = h265.NewDTSExtractor2()
+		}
+
+		dts, err := st.h265DTSExtractor.Extract(tu.AU, tu.PTS)
+		if err != nil {
+			st.h265DTSExtractor = nil
+			return 0, false
+		}
+		return dts, true
+	}
+
+	return u.GetPTS(), true
+}
+
+// updateTimestampJump compares the progression of decode timestamps
+// with the expected frame duration, that is the previous progression.
+// The reception time is not taken into account, since some sources
+// (i.e. HLS) deliver units in bursts.
+// It must be called with mutex locked.
+func (st *streamFormatStats) updateTimestampJump(dts int64) {
+	if st.lastDTSFilled {
+		delta := timestampToDuration(dts-st.lastDTS, st.format.ClockRate())
+
+		if st.frameDurationSet {
+			jump := delta - st.frameDuration
+			if jump < 0 {
+				jump = -jump
+			}
+			if jump > st.maxTimestampJump {
+				st.maxTimestampJump = jump
+			}
+		}
+
+		st.frameDuration = delta
+		st.frameDurationSet = true
+	}
+
+	st.lastDTS = dts
+	st.lastDTSFilled = true
+}
+
+// trimSamples removes samples that are outside the window.
+// It must be called with mutex locked.
+func (st *streamFormatStats) trimSamples(now time.Time) {
+	minT := now.Add(-statsWindow)
+	i := 0
+	for i < len(st.samples) && st.samples[i].t.Before(minT) {
+		i++
+	}
+	st.samples = st.samples[i:]
+}
+
+func (st *streamFormatStats) get(now time.Time) TrackStats {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	st.trimSamples(now)
+
+	ret := TrackStats{
+		Codec: st.format.Codec(),
+	}
+
+	// during the first seconds, the window is shorter
+	window := statsWindow
+	if !st.firstSample.IsZero() && now.Sub(st.firstSample) < window {
+		window = now.Sub(st.firstSample)
+		if window < time.Second {
+			window = time.Second
+		}
+	}
+
+	var bytes uint64
+	var frames int
+	for _, s := range st.samples {
+		bytes += s.size
+		if s.isFrame {
+			frames++
+		}
+	}
+
+	ret.Bitrate = float64(bytes*8) / window.Seconds()
+
+	switch st.mediaType {
+	case description.MediaTypeVideo:
+		ret.Video = &VideoTrackStats{
+			FrameRate:        float64(frames) / window.Seconds(),
+			GOPLength:        st.gopLength,
+			KeyFrameInterval: st.keyFrameInterval,
+			LastKeyFrameTime: st.lastKeyFrame,
+		}
+		st.fillVideoParams(ret.Video)
+
+	case description.MediaTypeAudio:
+		ret.Audio = &AudioTrackStats{
+			SampleRate:   st.format.ClockRate(),
+			ChannelCount: audioChannelCount(st.format),
+		}
+	}
+
+	return ret
+}
+
+// lastData returns the time of the last unit.
+func (st *streamFormatStats) lastData() time.Time {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	return st.lastSample
+}
+
+// lastTimestamp returns the PTS and the reception time of the last unit.
+func (st *streamFormatStats) lastTimestamp() (int64, time.Time) {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	return st.lastPTS, st.lastSample
+}
+
+// lastKeyFrameTime returns the time of the last key frame.
+func (st *streamFormatStats) lastKeyFrameTime() time.Time {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	return st.lastKeyFrame
+}
+
+// popMaxTimestampJump returns the biggest timestamp jump since the last call.
+func (st *streamFormatStats) popMaxTimestampJump() time.Duration {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	ret := st.maxTimestampJump
+	st.maxTimestampJump = 0
+	return ret
+}
+
+// hasKeyFrames checks whether key frames of the format can be detected.
+func (st *streamFormatStats) hasKeyFrames() bool {
+	switch st.format.(type) {
+	case *format.H264, *format.H265, *format.AV1:
+		return true
+	}
+	return false
+}
+
+// fillVideoParams fills resolution and profile.
+// It must be called with mutex locked.
+func (st *streamFormatStats) fillVideoParams(v *VideoTrackStats) {
+	switch forma := st.format.(type) {
+	case *format.H264:
+		sps, _ := forma.SafeParams()
+		if sps == nil {
+			return
+		}
+
+		var s h264.SPS
+		if s.Unmarshal(sps) != nil {
+			return
+		}
+
+		v.Width = s.Width()
+		v.Height = s.Height()
+		v.Profile = h264ProfileName(s.ProfileIdc)
+
+	case *format.H265:
+		_, sps, _ := forma.SafeParams()
+		if sps == nil {
+			return
+		}
+
+		var s h265.SPS
+		if s.Unmarshal(sps) != nil {
+			return
+		}
+
+		v.Width = s.Width()
+		v.Height = s.Height()
+		v.Profile = h265ProfileName(s.ProfileTierLevel.GeneralProfileIdc)
+
+	case *format.AV1:
+		if st.av1SeqHeader == nil {
+			return
+		}
+
+		v.Width = st.av1SeqHeader.Width()
+		v.Height = st.av1SeqHeader.Height()
+		v.Profile = av1ProfileName(st.av1SeqHeader.SeqProfile)
+	}
+}
+
+// unitFrameInfo returns whether a unit contains a frame and whether the frame is a key frame.
+// Units produced from RTP packets do not contain a frame until the frame is complete.
+func unitFrameInfo(u unit.Unit) (bool, bool) {
+	switch tu := u.(type) {
+	case *unit.H264:
+		return tu.AU != nil, tu.AU != nil && h264.IDRPresent(tu.AU)
+
+	case *unit.H265:
+		return tu.AU != nil, tu.AU != nil && h265.IsRandomAccess(tu.AU)
+
+	case *unit.AV1:
+		if tu.TU == nil {
+			return false, false
+		}
+		isKey, err := av1.ContainsKeyFrame(tu.TU)
+		return true, err == nil && isKey
+
+	case *unit.VP8:
+		return tu.Frame != nil, false
+
+	case *unit.VP9:
+		return tu.Frame != nil, false
+
+	case *unit.MJPEG:
+		return tu.Frame != nil, false
+
+	case *unit.MPEG1Video:
+		return tu.Frame != nil, false
+
+	case *unit.MPEG4Video:
+		return tu.Frame != nil, false
+	}
+
+	return false, false
+}
+
+// rtpFrameInfo returns whether the RTP packets of a unit complete a frame
+// and whether the frame is a key frame.
+// It must be called with mutex locked.
+func (st *streamFormatStats) rtpFrameInfo(pkts []*rtp.Packet) (bool, bool) {
+	isFrame := false
+	isKeyFrame := false
+
+	for _, pkt := range pkts {
+		if rtpKeyFrameStart(st.format, pkt.Payload) {
+			st.rtpKeyFrame = true
+
+			// the sequence header is sent at the beginning of a coded video sequence.
+			if _, ok := st.format.(*format.AV1); ok {
+				if sh := rtpAV1FindSequenceHeader(pkt.Payload); sh != nil {
+					st.av1SeqHeader = sh
+				}
+			}
+		}
+
+		// the marker bit is set on the last packet of a frame.
+		if pkt.Marker {
+			isFrame = true
+			isKeyFrame = st.rtpKeyFrame
+			st.rtpKeyFrame = false
+		}
+	}
+
+	return isFrame, isKeyFrame
+}
+
+// rtpKeyFrameStart checks whether a RTP payload contains the beginning of a key frame.
+func rtpKeyFrameStart(forma format.Format, payload []byte) bool {
+	if len(payload) == 0 {
+		return false
+	}
+
+	switch forma.(type) {
+	case *format.H264:
+		switch h264.NALUType(payload[0] & 0x1F) {
+		case h264.NALUTypeSTAPA:
+			return h264.IDRPresent(splitAggregationUnit(payload[1:]))
+
+		case h264.NALUTypeFUA:
+			return len(payload) >= 2 && (payload[1]&0x80) != 0 &&
+				h264.IDRPresent([][]byte{{payload[1] & 0x1F}})
+		}
+		return h264.IDRPresent([][]byte{payload})
+
+	case *format.H265:
+		switch h265.NALUType((payload[0] >> 1) & 0b111111) {
+		case h265.NALUType_AggregationUnit:
+			return len(payload) >= 2 && h265.IsRandomAccess(splitAggregationUnit(payload[2:]))
+
+		case h265.NALUType_FragmentationUnit:
+			return len(payload) >= 3 && (payload[2]&0x80) != 0 &&
+				h265.IsRandomAccess([][]byte{{(payload[2] & 0b111111) << 1}})
+		}
+		return h265.IsRandomAccess([][]byte{payload})
+
+	case *format.AV1:
+		// N bit of the aggregation header, set on the first packet of a coded video sequence.
+		return (payload[0] & 0x08) != 0
+	}
+
+	return false
+}
+
+// splitAggregationUnit splits NALUs of a STAP-A or H265 aggregation unit,
+// each prefixed by its 16-bit size.
+func splitAggregationUnit(buf []byte) [][]byte {
+	var nalus [][]byte
+
+	for len(buf) > 2 {
+		size := int(buf[0])<<8 | int(buf[1])
+		if size == 0 || len(buf) < 2+size {
+			break
+		}
+
+		nalus = append(nalus, buf[2:2+size])
+		buf = buf[2+size:]
+	}
+
+	return nalus
+}
+
+// rtpAV1FindSequenceHeader finds a sequence header among the OBU elements of a AV1 RTP payload.
+func rtpAV1FindSequenceHeader(payload []byte) *av1.SequenceHeader {
+	if len(payload) == 0 {
+		return nil
+	}
+
+	// Z bit: the first element is the continuation of an OBU of the previous packet.
+	z := (payload[0] & 0x80) != 0
+	// W field: count of elements, the last one of which has no length.
+	w := int((payload[0] >> 4) & 0b11)
+	buf := payload[1:]
+
+	var obus [][]byte
+
+	for i := 0; len(buf) != 0; i++ {
+		var obu []byte
+
+		if w != 0 && i == (w-1) {
+			obu = buf
+			buf = nil
+		} else {
+			size, n, err := av1.LEB128Unmarshal(buf)
+			if err != nil || len(buf[n:]) < int(size) {
+				return nil
+			}
+			obu = buf[n : n+int(size)]
+			buf = buf[n+int(size):]
+		}
+
+		if i != 0 || !z {
+			obus = append(obus, obu)
+		}
+	}
+
+	return av1FindSequenceHeader(obus)
+}
+
+func av1FindSequenceHeader(tu [][]byte) *av1.SequenceHeader {
+	for _, obu := range tu {
+		var h av1.OBUHeader
+		if h.Unmarshal(obu) != nil || h.Type != av1.OBUTypeSequenceHeader {
+			continue
+		}
+
+		var sh av1.SequenceHeader
+		if sh.Unmarshal(obu) == nil {
+			return &sh
+		}
+	}
+	return nil
+}
+
+func audioChannelCount(forma format.Format) int {
+	switch forma := forma.(type) {
+	case *format.Opus:
+		return forma.ChannelCount
+
+	case *format.MPEG4Audio:
+		if forma.Config != nil {
+			return forma.Config.ChannelCount
+		}
+		if forma.StreamMuxConfig != nil && len(forma.StreamMuxConfig.Programs) != 0 &&
+			len(forma.StreamMuxConfig.Programs[0].Layers) != 0 &&
+			forma.StreamMuxConfig.Programs[0].Layers[0].AudioSpecificConfig != nil {
+			return forma.StreamMuxConfig.Programs[0].Layers[0].AudioSpecificConfig.ChannelCount
+		}
+
+	case *format.G711:
+		return forma.ChannelCount
+
+	case *format.LPCM:
+		return forma.ChannelCount
+
+	case *format.AC3:
+		return forma.ChannelCount
+	}
+
+	return 0
+}
+
+func h264ProfileName(idc uint8) string {
+	switch idc {
+	case 66:
+		return "Baseline"
+	case 77:
+		return "Main"
+	case 88:
+		return "Extended"
+	case 100:
+		return "High"
+	case 110:
+		return "High 10"
+	case 122:
+		return "High 4:2:2"
+	case 244:
+		return "High 4:4:4 Predictive"
+	}
+	return ""
+}
+
+func h265ProfileName(idc uint8) string {
+	switch idc {
+	case 1:
+		return "Main"
+	case 2:
+		return "Main 10"
+	case 3:
+		return "Main Still Picture"
+	case 4:
+		return "Format Range Extensions"
+	}
+	return ""
+}
+
+func av1ProfileName(profile uint8) string {
+	switch profile {
+	case 0:
+		return "Main"
+	case 1:
+		return "High"
+	case 2:
+		return "Professional"
+	}
+	return ""
+}
@@ -0,0 +1,151 @@
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+func TestStreamFormatStatsVideo(t *testing.T) {
+	forma := &format.H264{
+		PayloadTyp: 96,
+		SPS: []byte{ // 1920x1080 baseline
+			0x67, 0x42, 0xc0, 0x28, 0xd9, 0x00, 0x78, 0x02,
+			0x27, 0xe5, 0x84, 0x00, 0x00, 0x03, 0x00, 0x04,
+			0x00, 0x00, 0x03, 0x00, 0xf0, 0x3c, 0x60, 0xc9, 0x20,
+		},
+		PPS:               []byte{0x08, 0x06, 0x07, 0x08},
+		PacketizationMode: 1,
+	}
+
+	st := &streamFormatStats{
+		format:    forma,
+		mediaType: description.MediaTypeVideo,
+	}
+
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	idr := [][]byte{{0x65, 0x01}}
+	nonIDR := [][]byte{{0x41, 0x01}}
+
+	// 10 fps with a key frame every 5 frames, for 10 seconds
+	for i := 0; i < 100; i++ {
+		au := nonIDR
+		if i%5 == 0 {
+			au = idr
+		}
+		st.onUnit(&unit.H264{AU: au}, 100, t0.Add(time.Duration(i)*100*time.Millisecond))
+
+		// incomplete frame
+		st.onUnit(&unit.H264{}, 100, t0.Add(time.Duration(i)*100*time.Millisecond))
+	}
+
+	stats := st.get(t0.Add(9950 * time.Millisecond))
+
+	require.Equal(t, "H264", stats.Codec)
+	require.Nil(t, stats.Audio)
+	require.InDelta(t, 16000, stats.Bitrate, 500)
+	require.InDelta(t, 10, stats.Video.FrameRate, 0.5)
+	require.Equal(t, 5, stats.Video.GOPLength)
+	require.Equal(t, 500*time.Millisecond, stats.Video.KeyFrameInterval)
+	require.Equal(t, t0.Add(9500*time.Millisecond), stats.Video.LastKeyFrameTime)
+	require.Equal(t, 1920, stats.Video.Width)
+	require.Equal(t, 1080, stats.Video.Height)
+	require.Equal(t, "Baseline", stats.Video.Profile)
+
+	// no frames in the last window
+	stats = st.get(t0.Add(20 * time.Second))
+	require.Equal(t, float64(0), stats.Bitrate)
+	require.Equal(t, float64(0), stats.Video.FrameRate)
+}
+
+func TestStreamFormatStatsVideoRTP(t *testing.T) {
+	st := &streamFormatStats{
+		format: &format.H264{
+			PayloadTyp:        96,
+			PacketizationMode: 1,
+		},
+		mediaType: description.MediaTypeVideo,
+	}
+
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// 10 fps with a key frame every 5 frames, for 10 seconds.
+	// Units are not decoded: key frames are split into FU-A packets,
+	// the other frames are sent as single NALUs.
+	for i := 0; i < 100; i++ {
+		now := t0.Add(time.Duration(i) * 100 * time.Millisecond)
+
+		if i%5 == 0 {
+			st.onUnit(&unit.H264{Base: unit.Base{RTPPackets: []*rtp.Packet{{
+				Payload: []byte{0x7c, 0x85, 0x01},
+			}}}}, 100, now)
+			st.onUnit(&unit.H264{Base: unit.Base{RTPPackets: []*rtp.Packet{{
+				Header:  rtp.Header{Marker: true},
+				Payload: []byte{0x7c, 0x45, 0x02},
+			}}}}, 100, now)
+		} else {
+			st.onUnit(&unit.H264{Base: unit.Base{RTPPackets: []*rtp.Packet{{
+				Header:  rtp.Header{Marker: true},
+				Payload: []byte{0x41, 0x01},
+			}}}}, 100, now)
+		}
+	}
+
+	stats := st.get(t0.Add(9950 * time.Millisecond))
+
+	require.InDelta(t, 10, stats.Video.FrameRate, 0.5)
+	require.Equal(t, 5, stats.Video.GOPLength)
+	require.Equal(t, 500*time.Millisecond, stats.Video.KeyFrameInterval)
+	require.Equal(t, t0.Add(9500*time.Millisecond), stats.Video.LastKeyFrameTime)
+}
+
+func TestStreamFormatStatsAV1RTP(t *testing.T) {
+	st := &streamFormatStats{
+		format:    &format.AV1{PayloadTyp: 96},
+		mediaType: description.MediaTypeVideo,
+	}
+
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// the key frame contains a sequence header and a frame OBU
+	st.onUnit(&unit.AV1{Base: unit.Base{RTPPackets: []*rtp.Packet{{
+		Header: rtp.Header{Marker: true},
+		Payload: []byte{
+			0x28, 12,
+			8, 0, 0, 0, 66, 167, 191, 228, 96, 13, 0, 64,
+			0x30, 0x01,
+		},
+	}}}}, 100, t0)
+
+	stats := st.get(t0.Add(time.Second))
+
+	require.Equal(t, t0, stats.Video.LastKeyFrameTime)
+	require.Equal(t, 1920, stats.Video.Width)
+	require.Equal(t, 804, stats.Video.Height)
+	require.Equal(t, "Main", stats.Video.Profile)
+}
+
+func TestStreamFormatStatsAudio(t *testing.T) {
+	st := &streamFormatStats{
+		format: &format.Opus{
+			PayloadTyp:   96,
+			ChannelCount: 2,
+		},
+		mediaType: description.MediaTypeAudio,
+	}
+
+	stats := st.get(time.Now())
+	require.Equal(t, TrackStats{
+		Codec: "Opus",
+		Audio: &AudioTrackStats{
+			SampleRate:   48000,
+			ChannelCount: 2,
+		},
+	}, stats)
+}
@@ -24,6 +24,7 @@ func newStreamMedia(udpMaxPayloadSize int,
 		sf := &streamFormat{
 			udpMaxPayloadSize:  udpMaxPayloadSize,
 			format:             forma,
+			mediaType:          medi.Type,
 			generateRTPPackets: generateRTPPackets,
 			decodeErrLogger:    decodeErrLogger,
 		}
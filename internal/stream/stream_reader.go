@@ -2,18 +2,35 @@ package stream
 
 import (
 	"fmt"
+	"sync/atomic"
+	"time"
 
 	"github.com/bluenviron/gortsplib/v4/pkg/ringbuffer"
 	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/unit"
 )
 
+// streamReaderEntry is a unit waiting to be delivered to a reader.
+type streamReaderEntry struct {
+	cb     ReadFunc
+	u      unit.Unit
+	size   uint64
+	queued time.Time
+}
+
 type streamReader struct {
 	queueSize int
+	bytesSent *uint64
 	parent    logger.Writer
 
 	writeErrLogger logger.Writer
 	buffer         *ringbuffer.RingBuffer
 	started        bool
+	unitsDelivered atomic.Uint64
+	unitsDropped   atomic.Uint64
+	queueDepth     atomic.Int64
+	maxLag         atomic.Int64
+	timeShift      *timeShiftReader
 
 	// out
 	err chan error
@@ -49,21 +66,62 @@ func (w *streamReader) run() {
 
 func (w *streamReader) runInner() error {
 	for {
-		cb, ok := w.buffer.Pull()
+		e, ok := w.buffer.Pull()
 		if !ok {
 			return fmt.Errorf("terminated")
 		}
 
-		err := cb.(func() error)()
+		err := w.deliver(e.(*streamReaderEntry))
 		if err != nil {
 			return err
 		}
 	}
 }
 
-func (w *streamReader) push(cb func() error) {
-	ok := w.buffer.Push(cb)
+func (w *streamReader) deliver(e *streamReaderEntry) error {
+	w.queueDepth.Add(-1)
+	w.updateMaxLag(time.Since(e.queued))
+
+	atomic.AddUint64(w.bytesSent, e.size)
+
+	err := e.cb(e.u)
+	if err == nil {
+		w.unitsDelivered.Add(1)
+	}
+	return err
+}
+
+func (w *streamReader) push(cb ReadFunc, u unit.Unit, size uint64) {
+	// depth is increased before pushing, since the entry may be pulled immediately.
+	w.queueDepth.Add(1)
+
+	ok := w.buffer.Push(&streamReaderEntry{
+		cb:     cb,
+		u:      u,
+		size:   size,
+		queued: time.Now(),
+	})
 	if !ok {
+		w.queueDepth.Add(-1)
+		w.unitsDropped.Add(1)
 		w.writeErrLogger.Log(logger.Warn, "write queue is full")
 	}
 }
+
+func (w *streamReader) updateMaxLag(lag time.Duration) {
+	for {
+		cur := w.maxLag.Load()
+		if int64(lag) <= cur || w.maxLag.CompareAndSwap(cur, int64(lag)) {
+			return
+		}
+	}
+}
+
+func (w *streamReader) stats() ReaderStats {
+	return ReaderStats{
+		UnitsDelivered: w.unitsDelivered.Load(),
+		UnitsDropped:   w.unitsDropped.Load(),
+		QueueDepth:     int(w.queueDepth.Load()),
+		MaxLag:         time.Duration(w.maxLag.Load()),
+	}
+}
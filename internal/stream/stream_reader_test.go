@@ -0,0 +1,82 @@
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+func TestStreamReaderStatsOverflow(t *testing.T) {
+	medi := newRelayTestMedia()
+
+	s, err := New(4, 1472, &description.Session{Medias: []*description.Media{medi}}, true, &nilLogger{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	pulled := make(chan struct{}, 10)
+	release := make(chan struct{})
+	released := false
+
+	reader := &nilLogger{}
+	s.AddReader(reader, medi, medi.Formats[0], func(_ unit.Unit) error {
+		pulled <- struct{}{}
+		<-release
+		return nil
+	})
+	s.StartReader(reader)
+	defer s.RemoveReader(reader)
+
+	// unblock the reader in case of failure, in order to allow its removal.
+	defer func() {
+		if !released {
+			close(release)
+		}
+	}()
+
+	writeFrame := func(id byte) {
+		s.WriteUnit(medi, medi.Formats[0], &unit.H264{
+			Base: unit.Base{PTS: int64(id) * 3000},
+			AU:   [][]byte{{5, id}},
+		})
+	}
+
+	// the first unit is pulled and blocks the reader.
+	writeFrame(1)
+	<-pulled
+
+	// the next 4 units fill the queue, the others are dropped.
+	for i := 2; i <= 8; i++ {
+		writeFrame(byte(i))
+	}
+
+	stats, ok := s.ReaderStats(reader)
+	require.Equal(t, true, ok)
+	require.Equal(t, uint64(0), stats.UnitsDelivered)
+	require.Equal(t, uint64(3), stats.UnitsDropped)
+	require.Equal(t, 4, stats.QueueDepth)
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	released = true
+
+	for i := 0; i < 4; i++ {
+		<-pulled
+	}
+
+	// wait for the last callback to return.
+	for {
+		stats, _ = s.ReaderStats(reader)
+		if stats.UnitsDelivered == 5 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	require.Equal(t, uint64(3), stats.UnitsDropped)
+	require.Equal(t, 0, stats.QueueDepth)
+	require.GreaterOrEqual(t, stats.MaxLag, 50*time.Millisecond)
+}
@@ -0,0 +1,240 @@
+package stream
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+const (
+	// speed at which units of the time-shift buffer are sent to readers,
+	// in order to allow them to catch up with the live stream.
+	timeShiftCatchUpSpeed = 2
+)
+
+type timeShiftEntry struct {
+	sf       *streamFormat
+	u        unit.Unit
+	size     uint64
+	t        time.Time
+	keyFrame bool
+}
+
+// timeShiftBuffer stores units received in the last period,
+// up to a maximum size.
+type timeShiftBuffer struct {
+	duration time.Duration
+	maxSize  uint64
+
+	mutex   sync.Mutex
+	entries []*timeShiftEntry
+	size    uint64
+	// count of entries removed from the buffer,
+	// used to compute absolute positions.
+	removed uint64
+}
+
+func (b *timeShiftBuffer) push(e *timeShiftEntry) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.entries = append(b.entries, e)
+	b.size += e.size
+
+	for len(b.entries) > 1 && (e.t.Sub(b.entries[0].t) > b.duration || b.size > b.maxSize) {
+		b.size -= b.entries[0].size
+		b.entries[0] = nil
+		b.entries = b.entries[1:]
+		b.removed++
+	}
+}
+
+// seek returns the position of the first entry that must be sent to a reader
+// that wants to start from the given time.
+// When the reader reads a video format, the position of a key frame is returned,
+// in order to allow the reader to decode video.
+func (b *timeShiftBuffer) seek(t time.Time, isRandomAccess func(*timeShiftEntry) bool) uint64 {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	start := len(b.entries)
+	for i, e := range b.entries {
+		if !e.t.Before(t) {
+			start = i
+			break
+		}
+	}
+
+	if isRandomAccess != nil {
+		for i := min(start, len(b.entries)-1); i >= 0; i-- {
+			if isRandomAccess(b.entries[i]) {
+				return b.removed + uint64(i)
+			}
+		}
+		if i := b.nextRandomAccess(start+1, isRandomAccess); i < len(b.entries) {
+			return b.removed + uint64(i)
+		}
+	}
+
+	return b.removed + uint64(start)
+}
+
+// nextRandomAccess returns the index of the first entry, starting from the given one,
+// from which a reader can start reading.
+// It must be called with mutex locked.
+func (b *timeShiftBuffer) nextRandomAccess(start int, isRandomAccess func(*timeShiftEntry) bool) int {
+	if isRandomAccess == nil {
+		return start
+	}
+
+	for i := start; i < len(b.entries); i++ {
+		if isRandomAccess(b.entries[i]) {
+			return i
+		}
+	}
+
+	return len(b.entries)
+}
+
+// get returns the entry at the given position.
+// If the entry at the given position has been removed from the buffer,
+// the entry from which the reader can resume reading is returned, in the same way as seek.
+// It returns false if there are no entries to send.
+func (b *timeShiftBuffer) get(pos uint64, isRandomAccess func(*timeShiftEntry) bool) (*timeShiftEntry, uint64, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	i := len(b.entries)
+	switch {
+	case pos < b.removed:
+		i = b.nextRandomAccess(0, isRandomAccess)
+	case pos-b.removed < uint64(len(b.entries)):
+		i = int(pos - b.removed)
+	}
+
+	if i >= len(b.entries) {
+		return nil, 0, false
+	}
+
+	return b.entries[i], b.removed + uint64(i), true
+}
+
+// timeShiftReader sends units of the time-shift buffer to a reader,
+// then switches the reader to the live stream.
+type timeShiftReader struct {
+	s   *Stream
+	sr  *streamReader
+	cbs map[*streamFormat]ReadFunc
+	pos uint64
+
+	isRandomAccess func(*timeShiftEntry) bool
+
+	done chan struct{}
+}
+
+func (r *timeShiftReader) initialize(offset time.Duration) {
+	r.done = make(chan struct{})
+
+	hasVideo := false
+	for sf := range r.cbs {
+		if sf.mediaType == description.MediaTypeVideo {
+			hasVideo = true
+		}
+	}
+
+	if hasVideo {
+		r.isRandomAccess = func(e *timeShiftEntry) bool {
+			_, ok := r.cbs[e.sf]
+			return ok && e.keyFrame
+		}
+	}
+
+	r.pos = r.s.timeShift.seek(time.Now().Add(offset), r.isRandomAccess)
+}
+
+func (r *timeShiftReader) close() {
+	close(r.done)
+}
+
+func (r *timeShiftReader) run() {
+	var start time.Time
+	var first time.Time
+
+	for {
+		e, pos, ok := r.s.timeShift.get(r.pos, r.isRandomAccess)
+		if !ok {
+			if r.goLive() {
+				return
+			}
+			continue
+		}
+
+		if start.IsZero() {
+			start = time.Now()
+			first = e.t
+		}
+
+		deliveryTime := start.Add(e.t.Sub(first) / timeShiftCatchUpSpeed)
+
+		select {
+		case <-time.After(time.Until(deliveryTime)):
+		case <-r.done:
+			return
+		}
+
+		if !r.push(e) {
+			return
+		}
+
+		r.pos = pos + 1
+	}
+}
+
+func (r *timeShiftReader) push(e *timeShiftEntry) bool {
+	// the stream mutex prevents the reader from being removed during the push.
+	r.s.mutex.RLock()
+	defer r.s.mutex.RUnlock()
+
+	select {
+	case <-r.done:
+		return false
+	default:
+	}
+
+	if cb, ok := r.cbs[e.sf]; ok {
+		r.sr.push(cb, e.u, e.size)
+	}
+
+	return true
+}
+
+// goLive switches the reader to the live stream when there are no more entries to send.
+// Since writes are performed with the stream mutex locked,
+// no unit can be lost or duplicated during the switch.
+func (r *timeShiftReader) goLive() bool {
+	r.s.mutex.Lock()
+	defer r.s.mutex.Unlock()
+
+	select {
+	case <-r.done:
+		return true
+	default:
+	}
+
+	if _, _, ok := r.s.timeShift.get(r.pos, r.isRandomAccess); ok {
+		return false
+	}
+
+	for _, sm := range r.s.streamMedias {
+		for _, sf := range sm.formats {
+			sf.startReader(r.sr)
+		}
+	}
+
+	r.sr.timeShift = nil
+
+	return true
+}
@@ -0,0 +1,141 @@
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+func TestTimeShiftBufferTrim(t *testing.T) {
+	b := &timeShiftBuffer{duration: 2 * time.Second}
+	start := time.Now()
+
+	for i := 0; i < 5; i++ {
+		b.push(&timeShiftEntry{t: start.Add(time.Duration(i) * time.Second)})
+	}
+
+	require.Equal(t, uint64(2), b.removed)
+	require.Len(t, b.entries, 3)
+
+	// removed entries are skipped
+	e, pos, ok := b.get(0, nil)
+	require.Equal(t, true, ok)
+	require.Equal(t, uint64(2), pos)
+	require.Equal(t, start.Add(2*time.Second), e.t)
+
+	_, _, ok = b.get(5, nil)
+	require.Equal(t, false, ok)
+}
+
+func TestTimeShiftBufferTrimSize(t *testing.T) {
+	b := &timeShiftBuffer{duration: time.Hour, maxSize: 250}
+	start := time.Now()
+
+	for i := 0; i < 5; i++ {
+		b.push(&timeShiftEntry{
+			size: 100,
+			t:    start.Add(time.Duration(i) * time.Second),
+		})
+	}
+
+	require.Equal(t, uint64(3), b.removed)
+	require.Len(t, b.entries, 2)
+	require.Equal(t, uint64(200), b.size)
+
+	// the last entry is kept even if it exceeds the maximum size
+	b.push(&timeShiftEntry{
+		size: 1000,
+		t:    start.Add(5 * time.Second),
+	})
+
+	require.Len(t, b.entries, 1)
+	require.Equal(t, uint64(1000), b.size)
+}
+
+func TestTimeShiftBufferGetRemoved(t *testing.T) {
+	b := &timeShiftBuffer{duration: 2 * time.Second}
+	start := time.Now()
+
+	for i := 0; i < 6; i++ {
+		b.push(&timeShiftEntry{
+			t:        start.Add(time.Duration(i) * time.Second),
+			keyFrame: i == 0 || i == 4,
+		})
+	}
+
+	isRandomAccess := func(e *timeShiftEntry) bool {
+		return e.keyFrame
+	}
+
+	// a reader whose entries have been removed resumes from the next key frame
+	e, pos, ok := b.get(1, isRandomAccess)
+	require.Equal(t, true, ok)
+	require.Equal(t, uint64(4), pos)
+	require.Equal(t, start.Add(4*time.Second), e.t)
+
+	// entries that are still in the buffer are returned as they are
+	e, pos, ok = b.get(3, isRandomAccess)
+	require.Equal(t, true, ok)
+	require.Equal(t, uint64(3), pos)
+	require.Equal(t, start.Add(3*time.Second), e.t)
+
+	// there are no key frames after the removed entries
+	b.push(&timeShiftEntry{t: start.Add(7 * time.Second)})
+
+	_, _, ok = b.get(1, isRandomAccess)
+	require.Equal(t, false, ok)
+}
+
+func TestStreamTimeShift(t *testing.T) {
+	medi := newRelayTestMedia()
+
+	s, err := New(512, 1472, &description.Session{Medias: []*description.Media{medi}}, true, &nilLogger{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	s.EnableTimeShift(10*time.Second, 1024*1024)
+	require.Equal(t, true, s.TimeShiftEnabled())
+
+	writeFrame := func(typ byte, id byte) {
+		s.WriteUnit(medi, medi.Formats[0], &unit.H264{
+			Base: unit.Base{PTS: int64(id) * 3000},
+			AU:   [][]byte{{typ, id}},
+		})
+	}
+
+	writeFrame(1, 1)
+	writeFrame(5, 2)
+	writeFrame(1, 3)
+
+	recv := make(chan byte, 10)
+	reader := &nilLogger{}
+	s.AddReader(reader, medi, medi.Formats[0], func(u unit.Unit) error {
+		au := u.(*unit.H264).AU
+		recv <- au[len(au)-1][1]
+		return nil
+	})
+	s.StartReaderWithOffset(reader, -time.Hour)
+	defer s.RemoveReader(reader)
+
+	// the reader starts from the last key frame before the requested position
+	require.Equal(t, byte(2), <-recv)
+	require.Equal(t, byte(3), <-recv)
+
+	// then switches to the live stream
+	for {
+		s.mutex.RLock()
+		live := s.streamReaders[reader].timeShift == nil
+		s.mutex.RUnlock()
+		if live {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	writeFrame(1, 4)
+	require.Equal(t, byte(4), <-recv)
+}
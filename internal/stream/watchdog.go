@@ -0,0 +1,128 @@
+package stream
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// time during which a stream is unhealthy after a timestamp jump.
+	watchdogTimestampJumpHold = 10 * time.Second
+)
+
+// Health is the health state of a stream.
+type Health struct {
+	Healthy bool
+	// reason of the unhealthy state.
+	Reason string
+	// time of the last change.
+	Since time.Time
+}
+
+// Watchdog checks the health of a stream.
+// It detects tracks that stopped receiving data, timestamp jumps and missing key frames.
+// Checks are performed when Check() is called.
+type Watchdog struct {
+	Stream              *Stream
+	NoDataTimeout       time.Duration
+	MaxTimestampJump    time.Duration
+	MaxKeyFrameInterval time.Duration
+
+	created       time.Time
+	lastJump      time.Time
+	lastJumpTrack string
+	health        Health
+}
+
+// Initialize initializes Watchdog.
+func (w *Watchdog) Initialize() {
+	w.created = time.Now()
+	w.health = Health{
+		Healthy: true,
+		Since:   w.created,
+	}
+}
+
+// Health returns the current health state.
+func (w *Watchdog) Health() Health {
+	return w.health
+}
+
+// Check checks the stream.
+// It returns the health state and whether it has changed since the previous call.
+func (w *Watchdog) Check(now time.Time) (Health, bool) {
+	reason := w.check(now)
+
+	healthy := (reason == "")
+	if healthy == w.health.Healthy {
+		w.health.Reason = reason
+		return w.health, false
+	}
+
+	w.health = Health{
+		Healthy: healthy,
+		Reason:  reason,
+		Since:   now,
+	}
+	return w.health, true
+}
+
+func (w *Watchdog) check(now time.Time) string {
+	i := 0
+	var reasons []string
+
+	for _, media := range w.Stream.desc.Medias {
+		sm := w.Stream.streamMedias[media]
+
+		for _, forma := range media.Formats {
+			st := sm.formats[forma].stats
+			track := fmt.Sprintf("track %d (%s)", i+1, forma.Codec())
+			i++
+
+			if w.MaxTimestampJump > 0 {
+				if jump := st.popMaxTimestampJump(); jump > w.MaxTimestampJump {
+					w.lastJump = now
+					w.lastJumpTrack = fmt.Sprintf("%s: timestamp jump of %v", track, jump.Round(time.Millisecond))
+				}
+			}
+
+			if w.NoDataTimeout > 0 {
+				last := st.lastData()
+				if last.IsZero() {
+					last = w.created
+				}
+
+				if now.Sub(last) > w.NoDataTimeout {
+					reasons = append(reasons, fmt.Sprintf("%s: no data since %v",
+						track, now.Sub(last).Round(time.Second)))
+				}
+			}
+
+			if w.MaxKeyFrameInterval > 0 && st.hasKeyFrames() {
+				last := st.lastKeyFrameTime()
+				if last.IsZero() {
+					last = w.created
+				}
+
+				if now.Sub(last) > w.MaxKeyFrameInterval {
+					reasons = append(reasons, fmt.Sprintf("%s: no key frames since %v",
+						track, now.Sub(last).Round(time.Second)))
+				}
+			}
+		}
+	}
+
+	if !w.lastJump.IsZero() && now.Sub(w.lastJump) < watchdogTimestampJumpHold {
+		reasons = append(reasons, w.lastJumpTrack)
+	}
+
+	if len(reasons) == 0 {
+		return ""
+	}
+
+	ret := reasons[0]
+	for _, r := range reasons[1:] {
+		ret += ", " + r
+	}
+	return ret
+}
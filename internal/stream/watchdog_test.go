@@ -0,0 +1,157 @@
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+func newWatchdogTestStream() (*Stream, *streamFormatStats) {
+	forma := &format.H264{
+		PayloadTyp:        96,
+		PacketizationMode: 1,
+	}
+
+	medi := &description.Media{
+		Type:    description.MediaTypeVideo,
+		Formats: []format.Format{forma},
+	}
+
+	st := &streamFormatStats{
+		format:    forma,
+		mediaType: description.MediaTypeVideo,
+	}
+
+	s := &Stream{
+		desc: &description.Session{
+			Medias: []*description.Media{medi},
+		},
+		streamMedias: map[*description.Media]*streamMedia{
+			medi: {
+				formats: map[format.Format]*streamFormat{
+					forma: {stats: st},
+				},
+			},
+		},
+	}
+
+	return s, st
+}
+
+func TestWatchdogNoData(t *testing.T) {
+	s, st := newWatchdogTestStream()
+
+	w := &Watchdog{
+		Stream:        s,
+		NoDataTimeout: 5 * time.Second,
+	}
+	w.Initialize()
+	t0 := w.created
+
+	h, changed := w.Check(t0.Add(4 * time.Second))
+	require.False(t, changed)
+	require.True(t, h.Healthy)
+
+	h, changed = w.Check(t0.Add(6 * time.Second))
+	require.True(t, changed)
+	require.False(t, h.Healthy)
+	require.Equal(t, "track 1 (H264): no data since 6s", h.Reason)
+	require.Equal(t, t0.Add(6*time.Second), h.Since)
+
+	st.onUnit(&unit.H264{
+		Base: unit.Base{PTS: 0},
+		AU:   [][]byte{{0x65, 0x01}},
+	}, 100, t0.Add(7*time.Second))
+
+	h, changed = w.Check(t0.Add(8 * time.Second))
+	require.True(t, changed)
+	require.True(t, h.Healthy)
+	require.Equal(t, "", h.Reason)
+}
+
+func TestWatchdogKeyFrameInterval(t *testing.T) {
+	s, st := newWatchdogTestStream()
+
+	w := &Watchdog{
+		Stream:              s,
+		MaxKeyFrameInterval: 2 * time.Second,
+	}
+	w.Initialize()
+	t0 := w.created
+
+	st.onUnit(&unit.H264{
+		Base: unit.Base{PTS: 0},
+		AU:   [][]byte{{0x65, 0x01}},
+	}, 100, t0)
+
+	for i := 1; i <= 30; i++ {
+		st.onUnit(&unit.H264{
+			Base: unit.Base{PTS: int64(i) * 9000},
+			AU:   [][]byte{{0x41, 0x01}},
+		}, 100, t0.Add(time.Duration(i)*100*time.Millisecond))
+	}
+
+	h, changed := w.Check(t0.Add(3 * time.Second))
+	require.True(t, changed)
+	require.False(t, h.Healthy)
+	require.Equal(t, "track 1 (H264): no key frames since 3s", h.Reason)
+}
+
+func TestWatchdogTimestampJump(t *testing.T) {
+	s, st := newWatchdogTestStream()
+
+	w := &Watchdog{
+		Stream:           s,
+		MaxTimestampJump: time.Second,
+	}
+	w.Initialize()
+	t0 := w.created
+
+	sps := []byte{ // 1920x1080 baseline
+		0x67, 0x42, 0xc0, 0x28, 0xd9, 0x00, 0x78, 0x02,
+		0x27, 0xe5, 0x84, 0x00, 0x00, 0x03, 0x00, 0x04,
+		0x00, 0x00, 0x03, 0x00, 0xf0, 0x3c, 0x60, 0xc9, 0x20,
+	}
+
+	st.onUnit(&unit.H264{
+		Base: unit.Base{PTS: 0},
+		AU:   [][]byte{sps, {0x65, 0x01}},
+	}, 100, t0)
+
+	// frames are received in a burst, as with HLS, that is not a jump
+	for i := 1; i <= 20; i++ {
+		st.onUnit(&unit.H264{
+			Base: unit.Base{PTS: int64(i) * 9000},
+			AU:   [][]byte{{0x41, 0x01}},
+		}, 100, t0.Add(10*time.Millisecond))
+	}
+
+	h, changed := w.Check(t0.Add(time.Second))
+	require.False(t, changed)
+	require.True(t, h.Healthy)
+
+	// timestamps advance by 10 seconds between two frames
+	st.onUnit(&unit.H264{
+		Base: unit.Base{PTS: 20*9000 + 10*90000},
+		AU:   [][]byte{{0x41, 0x01}},
+	}, 100, t0.Add(time.Second))
+
+	h, changed = w.Check(t0.Add(2 * time.Second))
+	require.True(t, changed)
+	require.False(t, h.Healthy)
+	require.Equal(t, "track 1 (H264): timestamp jump of 9.9s", h.Reason)
+
+	// the unhealthy state is held for a while
+	h, changed = w.Check(t0.Add(5 * time.Second))
+	require.False(t, changed)
+	require.False(t, h.Healthy)
+
+	h, changed = w.Check(t0.Add(13 * time.Second))
+	require.True(t, changed)
+	require.True(t, h.Healthy)
+}
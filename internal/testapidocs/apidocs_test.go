@@ -62,7 +62,7 @@ func TestAPIDocs(t *testing.T) {
 		},
 		{
 			"PathReader",
-			defs.APIPathSourceOrReader{},
+			defs.APIPathReader{},
 		},
 		{
 			"HLSMuxer",
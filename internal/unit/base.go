@@ -27,3 +27,13 @@ func (u *Base) GetNTP() time.Time {
 func (u *Base) GetPTS() int64 {
 	return u.PTS
 }
+
+// SetRTPPackets implements Unit.
+func (u *Base) SetRTPPackets(pkts []*rtp.Packet) {
+	u.RTPPackets = pkts
+}
+
+// SetPTS implements Unit.
+func (u *Base) SetPTS(pts int64) {
+	u.PTS = pts
+}
@@ -17,4 +17,10 @@ type Unit interface {
 
 	// returns the PTS of the unit.
 	GetPTS() int64
+
+	// sets RTP packets contained into the unit.
+	SetRTPPackets([]*rtp.Packet)
+
+	// sets the PTS of the unit.
+	SetPTS(int64)
 }